@@ -23,6 +23,16 @@ type (
 type (
 	Creator func(socketStore SocketStore, parserCreator parser.Creator) Adapter
 
+	// err is ErrRequestTimeout if some of the nodes didn't respond within the timeout.
+	// In that case, the responses received so far are given.
+	ServerSideEmitAckFunc func(err error, responses []any)
+
+	// Called once per node with the number of the sockets that a broadcast was sent to.
+	BroadcastClientCountFunc func(clientCount int)
+
+	// Called with the acknowledgement of a socket (the first argument the client acknowledged with).
+	BroadcastAckFunc func(response any)
+
 	Adapter interface {
 		ServerCount() int
 		Close()
@@ -32,6 +42,12 @@ type (
 		DeleteAll(sid SocketID)
 
 		Broadcast(header *parser.PacketHeader, v []any, opts *BroadcastOptions)
+		// Send the packet to the matching sockets of every node and collect their acknowledgements.
+		//
+		// clientCount is called once per node (including this one) with the number of
+		// the sockets the packet was sent to, and ack is called once per acknowledgement.
+		// The acknowledgements received after the timeout are dropped.
+		BroadcastWithAck(header *parser.PacketHeader, v []any, opts *BroadcastOptions, timeout time.Duration, clientCount BroadcastClientCountFunc, ack BroadcastAckFunc)
 
 		// The return value 'sids' is a thread safe mapset.Set.
 		Sockets(rooms mapset.Set[Room]) (sids mapset.Set[SocketID])
@@ -45,6 +61,10 @@ type (
 		DisconnectSockets(opts *BroadcastOptions, close bool)
 
 		ServerSideEmit(header *parser.PacketHeader, v []any)
+		// Send a server side event and collect the acknowledgements of the other nodes (one response per node).
+		//
+		// ack is called once, either when every node has responded or when the timeout is reached.
+		ServerSideEmitWithAck(header *parser.PacketHeader, v []any, timeout time.Duration, ack ServerSideEmitAckFunc)
 
 		// Save the client session in order to restore it upon reconnection.
 		PersistSession(session *SessionToPersist)
@@ -62,6 +82,8 @@ type (
 		PID PrivateSessionID
 
 		Rooms []Room
+		// Data of the socket, it can be nil.
+		Data *SocketData
 
 		MissedPackets []*PersistedPacket
 	}
@@ -86,10 +108,12 @@ func (s SessionToPersist) MarshalBinary() ([]byte, error) {
 		SID   SocketID
 		PID   PrivateSessionID
 		Rooms []Room
+		Data  *SocketData `json:",omitempty"`
 	}{
 		SID:   s.SID,
 		PID:   s.PID,
 		Rooms: s.Rooms,
+		Data:  s.Data,
 	})
 }
 
@@ -98,6 +122,7 @@ func (s *SessionToPersist) UnmarshalBinary(data []byte) error {
 		SID   SocketID
 		PID   PrivateSessionID
 		Rooms []Room
+		Data  *SocketData
 	}
 	err := json.Unmarshal(data, &tmp)
 	if err != nil {
@@ -107,5 +132,6 @@ func (s *SessionToPersist) UnmarshalBinary(data []byte) error {
 	s.SID = tmp.SID
 	s.PID = tmp.PID
 	s.Rooms = tmp.Rooms
+	s.Data = tmp.Data
 	return nil
 }
@@ -2,6 +2,7 @@ package adapter
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/hhuuson97/socket.io-go/internal/sync"
 
@@ -37,6 +38,9 @@ func (a *inMemoryAdapter) ServerCount() int { return 1 }
 func (a *inMemoryAdapter) Close() {}
 
 func (a *inMemoryAdapter) AddAll(sid SocketID, rooms []Room) {
+	var events roomEvents
+	defer events.emit(a.sockets)
+
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
@@ -53,14 +57,19 @@ func (a *inMemoryAdapter) AddAll(sid SocketID, rooms []Room) {
 		if !ok {
 			r = mapset.NewThreadUnsafeSet[SocketID]()
 			a.rooms[room] = r
+			events.add(roomEventCreate, room, "")
 		}
 		if !r.Contains(sid) {
 			r.Add(sid)
+			events.add(roomEventJoin, room, sid)
 		}
 	}
 }
 
 func (a *inMemoryAdapter) Delete(sid SocketID, room Room) {
+	var events roomEvents
+	defer events.emit(a.sockets)
+
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
@@ -69,20 +78,42 @@ func (a *inMemoryAdapter) Delete(sid SocketID, room Room) {
 		s.Remove(room)
 	}
 
-	a.delete(sid, room)
+	a.delete(sid, room, &events)
 }
 
-func (a *inMemoryAdapter) delete(sid SocketID, room Room) {
+func (a *inMemoryAdapter) delete(sid SocketID, room Room, events *roomEvents) {
 	r, ok := a.rooms[room]
-	if ok {
+	if ok && r.Contains(sid) {
 		r.Remove(sid)
+		events.add(roomEventLeave, room, sid)
 		if r.Cardinality() == 0 {
 			delete(a.rooms, room)
+			events.add(roomEventDelete, room, "")
 		}
 	}
 }
 
+func (a *inMemoryAdapter) hasRoom(room Room) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, ok := a.rooms[room]
+	return ok
+}
+
+func (a *inMemoryAdapter) allRooms() []Room {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	rooms := make([]Room, 0, len(a.rooms))
+	for room := range a.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
+
 func (a *inMemoryAdapter) DeleteAll(sid SocketID) {
+	var events roomEvents
+	defer events.emit(a.sockets)
+
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
@@ -92,7 +123,7 @@ func (a *inMemoryAdapter) DeleteAll(sid SocketID) {
 	}
 
 	s.Each(func(room Room) bool {
-		a.delete(sid, room)
+		a.delete(sid, room, &events)
 		return false
 	})
 
@@ -110,6 +141,33 @@ func (a *inMemoryAdapter) Broadcast(header *parser.PacketHeader, v []any, opts *
 	})
 }
 
+func (a *inMemoryAdapter) BroadcastWithAck(header *parser.PacketHeader, v []any, opts *BroadcastOptions, timeout time.Duration, clientCount BroadcastClientCountFunc, ack BroadcastAckFunc) {
+	header, buffers := encodeWithAckID(a.sockets, a.parser, header, v)
+
+	count := 0
+	a.apply(opts, func(socket Socket) {
+		if a.sockets.RegisterAck(socket.ID(), *header.ID, timeout, ack) {
+			a.sockets.SendBuffers(socket.ID(), buffers)
+			count++
+		}
+	})
+	clientCount(count)
+}
+
+// Every socket is sent the same packet, thus the same acknowledgement ID.
+// IDs are unique within the namespace, so that this is safe.
+func encodeWithAckID(sockets SocketStore, p parser.Parser, header *parser.PacketHeader, v []any) (*parser.PacketHeader, [][]byte) {
+	id := sockets.NextAckID()
+	h := *header
+	h.ID = &id
+
+	buffers, err := p.Encode(&h, &v)
+	if err != nil {
+		panic(fmt.Errorf("sio: %w", err))
+	}
+	return &h, buffers
+}
+
 // The return value 'sids' must be a thread safe mapset.Set.
 func (a *inMemoryAdapter) Sockets(rooms mapset.Set[Room]) (sids mapset.Set[SocketID]) {
 	a.mu.Lock()
@@ -237,6 +295,11 @@ func (a *inMemoryAdapter) computeExceptSids(exceptRooms mapset.Set[Room]) (excep
 
 func (a *inMemoryAdapter) ServerSideEmit(header *parser.PacketHeader, v []any) {}
 
+// There are no other nodes, so the acknowledgement is called right away without any responses.
+func (a *inMemoryAdapter) ServerSideEmitWithAck(header *parser.PacketHeader, v []any, timeout time.Duration, ack ServerSideEmitAckFunc) {
+	go ack(nil, nil)
+}
+
 func (a *inMemoryAdapter) PersistSession(session *SessionToPersist) {}
 
 func (a *inMemoryAdapter) RestoreSession(pid PrivateSessionID, offset string) (*SessionToPersist, bool) {
@@ -45,6 +45,37 @@ func TestInMemoryAdapterAddDelete(t *testing.T) {
 	require.False(t, ok)
 }
 
+func TestInMemoryAdapterRoomEvents(t *testing.T) {
+	adapter := newTestInMemoryAdapter()
+	store := adapter.sockets.(*TestSocketStore)
+
+	var events []string
+	store.SetOnRoomEvent(func(eventName string, room Room, sid SocketID) {
+		// The adapter must not be locked.
+		adapter.Sockets(mapset.NewSet(room))
+		events = append(events, eventName+" "+string(room)+" "+string(sid))
+	})
+
+	adapter.AddAll("s1", []Room{"r1"})
+	adapter.AddAll("s2", []Room{"r1"})
+	adapter.AddAll("s2", []Room{"r1"})
+	require.Equal(t, []string{
+		"create-room r1 ",
+		"join-room r1 s1",
+		"join-room r1 s2",
+	}, events)
+
+	events = nil
+	adapter.Delete("s1", "r1")
+	adapter.Delete("s1", "r1")
+	adapter.DeleteAll("s2")
+	require.Equal(t, []string{
+		"leave-room r1 s1",
+		"leave-room r1 s2",
+		"delete-room r1 ",
+	}, events)
+}
+
 func TestSockets(t *testing.T) {
 	adapter := newTestInMemoryAdapter()
 	store := adapter.sockets.(*TestSocketStore)
@@ -0,0 +1,486 @@
+package adapter
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hhuuson97/socket.io-go/parser"
+)
+
+const (
+	DefaultPubSubPrefix            = "socket.io"
+	DefaultPubSubRequestsTimeout   = 5 * time.Second
+	DefaultPubSubHeartbeatInterval = 5 * time.Second
+	DefaultPubSubHeartbeatTimeout  = 10 * time.Second
+)
+
+type PubSubAdapterOptions struct {
+	// The prefix of the subjects. The subject of a namespace is
+	// the prefix, followed by "." and the namespace encoded with base64url.
+	//
+	// Default: "socket.io"
+	Prefix string
+
+	// Duration to wait for the responses of the other nodes
+	// to a request (such as FetchSockets).
+	//
+	// Default: 5 seconds
+	RequestsTimeout time.Duration
+
+	// Interval between the heartbeats sent to the other nodes.
+	//
+	// Default: 5 seconds
+	HeartbeatInterval time.Duration
+
+	// Duration after which a node that hasn't sent a heartbeat is considered down.
+	//
+	// Default: 10 seconds
+	HeartbeatTimeout time.Duration
+
+	// Called when an error occurs (such as when a message cannot be published or a malformed message is received).
+	//
+	// Default: errors are ignored.
+	ErrorHandler func(err error)
+}
+
+// This adapter keeps the rooms in memory (as inMemoryAdapter does),
+// and sends everything that concerns the other nodes through a PubSub.
+type pubSubAdapter struct {
+	*inMemoryAdapter
+
+	pubSub PubSub
+	opts   PubSubAdapterOptions
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	uid string
+	nsp string
+
+	// Broadcasts, requests and heartbeats are published to this subject.
+	subject string
+	// Responses to the requests of this node are published to this subject.
+	inbox         string
+	subscriptions []PubSubSubscription
+
+	requests    *pendingRequests
+	ackRequests *broadcastAckRequests
+	nodes       *nodeRegistry
+}
+
+func NewPubSubAdapterCreator(pubSub PubSub, opts *PubSubAdapterOptions) Creator {
+	var options PubSubAdapterOptions
+	if opts != nil {
+		options = *opts
+	}
+	if options.Prefix == "" {
+		options.Prefix = DefaultPubSubPrefix
+	}
+	if options.RequestsTimeout == 0 {
+		options.RequestsTimeout = DefaultPubSubRequestsTimeout
+	}
+	if options.HeartbeatInterval == 0 {
+		options.HeartbeatInterval = DefaultPubSubHeartbeatInterval
+	}
+	if options.HeartbeatTimeout == 0 {
+		options.HeartbeatTimeout = DefaultPubSubHeartbeatTimeout
+	}
+	if options.ErrorHandler == nil {
+		options.ErrorHandler = func(err error) {}
+	}
+
+	creator := NewInMemoryAdapterCreator()
+	return func(socketStore SocketStore, parserCreator parser.Creator) Adapter {
+		inMemoryAdapter := creator(socketStore, parserCreator).(*inMemoryAdapter)
+		return newPubSubAdapter(inMemoryAdapter, pubSub, options)
+	}
+}
+
+// The namespace is encoded, since it can contain the separator
+// and the wildcards of the subjects ('.', '*' and '>') and whitespace.
+func pubSubSubject(prefix, nsp string) string {
+	return prefix + "." + base64.RawURLEncoding.EncodeToString([]byte(nsp))
+}
+
+func newPubSubAdapter(inMemoryAdapter *inMemoryAdapter, pubSub PubSub, opts PubSubAdapterOptions) *pubSubAdapter {
+	ctx, cancel := context.WithCancel(context.Background())
+	nsp := inMemoryAdapter.sockets.Namespace()
+	a := &pubSubAdapter{
+		inMemoryAdapter: inMemoryAdapter,
+		pubSub:          pubSub,
+		opts:            opts,
+		ctx:             ctx,
+		cancel:          cancel,
+		uid:             newUID(),
+		nsp:             nsp,
+		subject:         pubSubSubject(opts.Prefix, nsp),
+		inbox:           pubSub.NewInbox(),
+		requests:        newPendingRequests(),
+		ackRequests:     newBroadcastAckRequests(),
+		nodes:           newNodeRegistry(opts.HeartbeatTimeout),
+	}
+
+	for subject, handler := range map[string]func(msg *PubSubMessage){
+		a.subject: a.onMessage,
+		a.inbox:   a.onResponse,
+	} {
+		sub, err := pubSub.Subscribe(subject, handler)
+		if err != nil {
+			a.onError(fmt.Errorf("adapter: subscribing to %s: %w", subject, err))
+			continue
+		}
+		a.subscriptions = append(a.subscriptions, sub)
+	}
+
+	go a.heartbeat()
+	return a
+}
+
+func (a *pubSubAdapter) onError(err error) {
+	if a.ctx.Err() != nil {
+		return
+	}
+	a.opts.ErrorHandler(err)
+}
+
+// Announce this node to the other nodes and send heartbeats periodically until the adapter is closed.
+func (a *pubSubAdapter) heartbeat() {
+	a.publish(&clusterMessage{Type: requestTypeInitialHeartbeat})
+
+	ticker := time.NewTicker(a.opts.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			a.publish(&clusterMessage{Type: requestTypeHeartbeat})
+		}
+	}
+}
+
+// Returns the number of the live nodes of the cluster, including this one.
+func (a *pubSubAdapter) ServerCount() int {
+	return a.nodes.count() + 1
+}
+
+// Notifies the other nodes and unsubscribes. The PubSub is not closed.
+func (a *pubSubAdapter) Close() {
+	if a.ctx.Err() != nil {
+		return
+	}
+	a.publish(&clusterMessage{Type: requestTypeAdapterClose})
+	a.cancel()
+	for _, sub := range a.subscriptions {
+		sub.Unsubscribe()
+	}
+}
+
+func (a *pubSubAdapter) Broadcast(header *parser.PacketHeader, v []any, opts *BroadcastOptions) {
+	buffers, err := a.parser.Encode(header, &v)
+	if err != nil {
+		panic(fmt.Errorf("sio: %w", err))
+	}
+
+	if !opts.Flags.Local {
+		a.publish(&clusterMessage{
+			Type:    requestTypeBroadcast,
+			Header:  header,
+			Buffers: buffers,
+			Opts:    newRawBroadcastOptions(opts),
+		})
+	}
+
+	a.apply(opts, func(socket Socket) {
+		a.sockets.SendBuffers(socket.ID(), buffers)
+	})
+}
+
+func (a *pubSubAdapter) BroadcastWithAck(header *parser.PacketHeader, v []any, opts *BroadcastOptions, timeout time.Duration, clientCount BroadcastClientCountFunc, ack BroadcastAckFunc) {
+	if !opts.Flags.Local {
+		data, err := encodeBroadcastAckData(v)
+		if err != nil {
+			panic(fmt.Errorf("sio: %w", err))
+		}
+		requestID := newUID()
+		a.ackRequests.add(requestID, timeout, clientCount, ack)
+		a.publish(&clusterMessage{
+			Type:      requestTypeBroadcast,
+			RequestID: requestID,
+			Header:    header,
+			Opts:      newRawBroadcastOptionsWithTimeout(opts, timeout),
+			Packet:    data,
+		})
+	}
+	a.inMemoryAdapter.BroadcastWithAck(header, v, opts, timeout, clientCount, ack)
+}
+
+func (a *pubSubAdapter) FetchSockets(opts *BroadcastOptions) (sockets []Socket) {
+	sockets = a.inMemoryAdapter.FetchSockets(opts)
+	if opts.Flags.Local {
+		return
+	}
+
+	expected := a.nodes.count()
+	if expected == 0 {
+		return
+	}
+
+	requestID := newUID()
+	r := a.requests.add(requestID, requestTypeRemoteFetch, expected)
+	defer a.requests.remove(requestID)
+
+	err := a.publish(&clusterMessage{
+		Type:      requestTypeRemoteFetch,
+		RequestID: requestID,
+		Opts:      newRawBroadcastOptions(opts),
+	})
+	if err != nil {
+		return
+	}
+
+	responses, err := r.wait(a.opts.RequestsTimeout)
+	if err != nil {
+		a.onError(fmt.Errorf("adapter: FetchSockets: %w", err))
+	}
+	for _, response := range responses {
+		for _, details := range response.([]SocketDetails) {
+			sockets = append(sockets, NewRemoteSocket(a, a.nsp, details))
+		}
+	}
+	return
+}
+
+func (a *pubSubAdapter) AddSockets(opts *BroadcastOptions, rooms ...Room) {
+	if !opts.Flags.Local {
+		a.publish(&clusterMessage{
+			Type:  requestTypeRemoteJoin,
+			Opts:  newRawBroadcastOptions(opts),
+			Rooms: rooms,
+		})
+	}
+	a.inMemoryAdapter.AddSockets(opts, rooms...)
+}
+
+func (a *pubSubAdapter) DelSockets(opts *BroadcastOptions, rooms ...Room) {
+	if !opts.Flags.Local {
+		a.publish(&clusterMessage{
+			Type:  requestTypeRemoteLeave,
+			Opts:  newRawBroadcastOptions(opts),
+			Rooms: rooms,
+		})
+	}
+	a.inMemoryAdapter.DelSockets(opts, rooms...)
+}
+
+func (a *pubSubAdapter) DisconnectSockets(opts *BroadcastOptions, close bool) {
+	if !opts.Flags.Local {
+		a.publish(&clusterMessage{
+			Type:  requestTypeRemoteDisconnect,
+			Opts:  newRawBroadcastOptions(opts),
+			Close: close,
+		})
+	}
+	a.inMemoryAdapter.DisconnectSockets(opts, close)
+}
+
+func (a *pubSubAdapter) ServerSideEmit(header *parser.PacketHeader, v []any) {
+	a.publish(&clusterMessage{
+		Type: requestTypeServerSideEmit,
+		Data: v,
+	})
+}
+
+func (a *pubSubAdapter) ServerSideEmitWithAck(header *parser.PacketHeader, v []any, timeout time.Duration, ack ServerSideEmitAckFunc) {
+	expected := a.nodes.count()
+	if expected == 0 {
+		go ack(nil, nil)
+		return
+	}
+
+	requestID := newUID()
+	r := a.requests.add(requestID, requestTypeServerSideEmit, expected)
+
+	err := a.publish(&clusterMessage{
+		Type:      requestTypeServerSideEmit,
+		RequestID: requestID,
+		Data:      v,
+	})
+	if err != nil {
+		a.requests.remove(requestID)
+		go ack(err, nil)
+		return
+	}
+
+	go func() {
+		defer a.requests.remove(requestID)
+		responses, err := r.wait(timeout)
+		ack(err, responses)
+	}()
+}
+
+func (a *pubSubAdapter) onMessage(m *PubSubMessage) {
+	var msg clusterMessage
+	err := json.Unmarshal(m.Data, &msg)
+	if err != nil {
+		a.onError(fmt.Errorf("adapter: malformed message: %w", err))
+		return
+	}
+	if msg.UID == a.uid {
+		return
+	}
+
+	opts := msg.Opts.toBroadcastOptions()
+	switch msg.Type {
+	case requestTypeInitialHeartbeat:
+		a.nodes.seen(msg.UID)
+		a.publish(&clusterMessage{Type: requestTypeHeartbeat})
+
+	case requestTypeHeartbeat:
+		a.nodes.seen(msg.UID)
+
+	case requestTypeAdapterClose:
+		a.nodes.remove(msg.UID)
+
+	case requestTypeBroadcast:
+		if msg.RequestID != "" {
+			a.onBroadcastWithAck(m.Reply, &msg, opts)
+			return
+		}
+		a.apply(opts, func(socket Socket) {
+			a.sockets.SendBuffers(socket.ID(), msg.Buffers)
+		})
+
+	case requestTypeRemoteJoin:
+		a.inMemoryAdapter.AddSockets(opts, msg.Rooms...)
+
+	case requestTypeRemoteLeave:
+		a.inMemoryAdapter.DelSockets(opts, msg.Rooms...)
+
+	case requestTypeRemoteDisconnect:
+		a.inMemoryAdapter.DisconnectSockets(opts, msg.Close)
+
+	case requestTypeRemoteFetch:
+		sockets := a.inMemoryAdapter.FetchSockets(opts)
+		details := make([]SocketDetails, len(sockets))
+		for i, socket := range sockets {
+			details[i] = newSocketDetails(a, socket)
+		}
+		a.respond(m.Reply, &clusterMessage{
+			Type:       msg.Type,
+			RequestID:  msg.RequestID,
+			IsResponse: true,
+			Sockets:    details,
+		})
+
+	case requestTypeServerSideEmit:
+		if len(msg.Data) == 0 {
+			return
+		}
+		eventName, ok := msg.Data[0].(string)
+		if !ok {
+			a.onError(fmt.Errorf("adapter: malformed server side emit: event name is not a string"))
+			return
+		}
+		var ack func(response any)
+		if msg.RequestID != "" {
+			ack = func(response any) {
+				a.respond(m.Reply, &clusterMessage{
+					Type:       msg.Type,
+					RequestID:  msg.RequestID,
+					IsResponse: true,
+					Response:   response,
+				})
+			}
+		}
+		a.sockets.OnServerSideEmit(eventName, msg.Data[1:], ack)
+	}
+}
+
+func (a *pubSubAdapter) onBroadcastWithAck(reply string, msg *clusterMessage, opts *BroadcastOptions) {
+	data, err := decodeBroadcastAckData(msg.Packet)
+	if err != nil || msg.Header == nil {
+		a.onError(fmt.Errorf("adapter: malformed broadcast: %w", err))
+		return
+	}
+	timeout := msg.Opts.timeout(a.opts.RequestsTimeout)
+	a.inMemoryAdapter.BroadcastWithAck(msg.Header, data, opts, timeout, func(clientCount int) {
+		a.respond(reply, &clusterMessage{
+			Type:        requestTypeBroadcastClientCount,
+			RequestID:   msg.RequestID,
+			IsResponse:  true,
+			ClientCount: clientCount,
+		})
+	}, func(response any) {
+		a.respond(reply, &clusterMessage{
+			Type:       requestTypeBroadcastAck,
+			RequestID:  msg.RequestID,
+			IsResponse: true,
+			Response:   response,
+		})
+	})
+}
+
+func (a *pubSubAdapter) onResponse(m *PubSubMessage) {
+	var msg clusterMessage
+	err := json.Unmarshal(m.Data, &msg)
+	if err != nil {
+		a.onError(fmt.Errorf("adapter: malformed response: %w", err))
+		return
+	}
+	if a.ackRequests.onResponse(msg.Type, msg.RequestID, msg.ClientCount, msg.Response) {
+		return
+	}
+
+	r, ok := a.requests.get(msg.RequestID)
+	if !ok {
+		return
+	}
+	switch msg.Type {
+	case requestTypeRemoteFetch:
+		r.addResponse(msg.Sockets)
+	case requestTypeServerSideEmit:
+		r.addResponse(msg.Response)
+	}
+}
+
+// Publishes the message to the other nodes. Requests are
+// published with the inbox of this node as the reply subject.
+func (a *pubSubAdapter) publish(msg *clusterMessage) error {
+	msg.UID = a.uid
+	msg.Nsp = a.nsp
+	data, err := json.Marshal(msg)
+	if err != nil {
+		a.onError(err)
+		return err
+	}
+	if msg.RequestID != "" {
+		err = a.pubSub.PublishRequest(a.subject, a.inbox, data)
+	} else {
+		err = a.pubSub.Publish(a.subject, data)
+	}
+	if err != nil {
+		a.onError(fmt.Errorf("adapter: publishing to %s: %w", a.subject, err))
+	}
+	return err
+}
+
+func (a *pubSubAdapter) respond(reply string, msg *clusterMessage) {
+	if reply == "" {
+		return
+	}
+	msg.UID = a.uid
+	msg.Nsp = a.nsp
+	data, err := json.Marshal(msg)
+	if err != nil {
+		a.onError(err)
+		return
+	}
+	err = a.pubSub.Publish(reply, data)
+	if err != nil {
+		a.onError(fmt.Errorf("adapter: publishing to %s: %w", reply, err))
+	}
+}
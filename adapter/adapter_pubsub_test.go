@@ -0,0 +1,147 @@
+package adapter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hhuuson97/socket.io-go/parser"
+	jsonparser "github.com/hhuuson97/socket.io-go/parser/json"
+	"github.com/hhuuson97/socket.io-go/parser/json/serializer/stdjson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPubSubAdapter(t *testing.T) {
+	testClusterAdapter(t, func(t *testing.T, n int, opts *clusterTestOptions) []*clusterTestNode {
+		pubSub := NewInProcessPubSub()
+		nodes := make([]*clusterTestNode, n)
+		for i := range nodes {
+			a, store := newTestPubSubAdapterWithOptions(t, pubSub, &PubSubAdapterOptions{
+				RequestsTimeout: opts.requestsTimeout,
+				ErrorHandler:    opts.errorHandler,
+			})
+			nodes[i] = &clusterTestNode{adapter: a, store: store}
+		}
+		return nodes
+	})
+
+	t.Run("should return the number of the live nodes", func(t *testing.T) {
+		pubSub := NewInProcessPubSub()
+		a1, _ := newTestPubSubAdapter(t, pubSub)
+		a2, _ := newTestPubSubAdapter(t, pubSub)
+		newTestPubSubAdapter(t, pubSub)
+
+		require.Eventually(t, func() bool {
+			return a1.ServerCount() == 3
+		}, 3*time.Second, 10*time.Millisecond)
+
+		a2.Close()
+		require.Eventually(t, func() bool {
+			return a1.ServerCount() == 2
+		}, 3*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("should escape the namespace in the subject", func(t *testing.T) {
+		subjects := make(map[string]bool)
+		for _, nsp := range []string{"/", "/a.b", "/a", "/*", "/>", "/a b", "/a\tb"} {
+			subject := pubSubSubject(DefaultPubSubPrefix, nsp)
+			require.False(t, subjects[subject])
+			subjects[subject] = true
+
+			tokens := strings.Split(subject, ".")
+			require.Len(t, tokens, 3)
+			require.Equal(t, []string{"socket", "io"}, tokens[:2])
+			require.NotEmpty(t, tokens[2])
+			require.NotContains(t, tokens[2], "*")
+			require.NotContains(t, tokens[2], ">")
+			require.False(t, strings.ContainsAny(tokens[2], " \t\r\n"))
+		}
+	})
+}
+
+func TestPubSubAdapterBroadcastWithAck(t *testing.T) {
+	pubSub := NewInProcessPubSub()
+	a1, store1 := newTestPubSubAdapter(t, pubSub)
+	a2, store2 := newTestPubSubAdapter(t, pubSub)
+
+	store1.Set(NewTestSocket("s1"))
+	a1.AddAll("s1", []Room{"s1"})
+	store2.Set(NewTestSocket("s2"))
+	a2.AddAll("s2", []Room{"s2"})
+	store2.Set(NewTestSocket("s3"))
+	a2.AddAll("s3", []Room{"s3"})
+
+	received := make(chan string, 3)
+	sendBuffers := func(sid SocketID, buffers [][]byte) (ok bool) {
+		received <- string(buffers[0])
+		return true
+	}
+	store1.SetSendBuffers(sendBuffers)
+	store2.SetSendBuffers(sendBuffers)
+
+	require.Eventually(t, func() bool {
+		return a1.ServerCount() == 2
+	}, 3*time.Second, 10*time.Millisecond)
+
+	var (
+		clientCount = make(chan int, 2)
+		responses   = make(chan any, 3)
+	)
+	header := &parser.PacketHeader{Type: parser.PacketTypeEvent, Namespace: "/"}
+	a1.BroadcastWithAck(header, []any{"hello", 1}, NewBroadcastOptions(), 2*time.Second, func(count int) {
+		clientCount <- count
+	}, func(response any) {
+		responses <- response
+	})
+
+	for i := 0; i < 3; i++ {
+		select {
+		case buf := <-received:
+			// Every node has its own acknowledgement ID.
+			require.Regexp(t, `^2\d+\["hello",1\]$`, buf)
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout exceeded")
+		}
+	}
+
+	require.True(t, store1.Ack("s1", "from s1"))
+	require.True(t, store2.Ack("s2", "from s2"))
+	require.True(t, store2.Ack("s3", "from s3"))
+
+	var counts []int
+	for i := 0; i < 2; i++ {
+		select {
+		case count := <-clientCount:
+			counts = append(counts, count)
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout exceeded")
+		}
+	}
+	require.ElementsMatch(t, []int{1, 2}, counts)
+
+	var acks []any
+	for i := 0; i < 3; i++ {
+		select {
+		case response := <-responses:
+			acks = append(acks, response)
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout exceeded")
+		}
+	}
+	require.ElementsMatch(t, []any{"from s1", "from s2", "from s3"}, acks)
+}
+
+func newTestPubSubAdapter(t *testing.T, pubSub PubSub) (*pubSubAdapter, *TestSocketStore) {
+	return newTestPubSubAdapterWithOptions(t, pubSub, &PubSubAdapterOptions{
+		RequestsTimeout: 2 * time.Second,
+		ErrorHandler:    func(err error) { t.Error(err) },
+	})
+}
+
+func newTestPubSubAdapterWithOptions(t *testing.T, pubSub PubSub, opts *PubSubAdapterOptions) (*pubSubAdapter, *TestSocketStore) {
+	store := NewTestSocketStore()
+	creator := NewPubSubAdapterCreator(pubSub, opts)
+	a := creator(store, jsonparser.NewCreator(0, stdjson.New())).(*pubSubAdapter)
+	t.Cleanup(a.Close)
+	return a, store
+}
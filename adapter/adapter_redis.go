@@ -0,0 +1,655 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/hhuuson97/socket.io-go/parser"
+	"github.com/redis/go-redis/v9"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	DefaultRedisKey             = "socket.io"
+	DefaultRedisRequestsTimeout = 5 * time.Second
+)
+
+type RedisAdapterOptions struct {
+	// The prefix of the Redis Pub/Sub channels.
+	//
+	// Default: "socket.io"
+	Key string
+
+	// Duration to wait for the responses of the other nodes
+	// to a request (such as FetchSockets).
+	//
+	// Default: 5 seconds
+	RequestsTimeout time.Duration
+
+	// Whether to publish the responses to a channel
+	// specific to the requesting node.
+	//
+	// Default: false
+	PublishOnSpecificResponseChannel bool
+
+	// Called when an error occurs (such as when Redis is unreachable or a malformed message is received).
+	//
+	// Default: errors are ignored.
+	ErrorHandler func(err error)
+}
+
+// This is the equivalent of the Redis adapter of Socket.IO.
+// It uses the same channels and the same wire format, thus
+// Go and Node.js servers can be used together in a cluster.
+//
+// Have a look at: https://github.com/socketio/socket.io-redis-adapter
+type redisAdapter struct {
+	*inMemoryAdapter
+
+	client redis.UniversalClient
+	pubSub *redis.PubSub
+	opts   RedisAdapterOptions
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	uid string
+	nsp string
+
+	channel                 string
+	requestChannel          string
+	responseChannel         string
+	specificResponseChannel string
+
+	requests    *pendingRequests
+	ackRequests *broadcastAckRequests
+}
+
+type (
+	redisPacket struct {
+		Type int     `json:"type"`
+		Data []any   `json:"data"`
+		Nsp  string  `json:"nsp"`
+		ID   *uint64 `json:"id,omitempty"`
+	}
+
+	redisRequest struct {
+		UID       string               `json:"uid,omitempty"`
+		RequestID string               `json:"requestId,omitempty"`
+		Type      requestType          `json:"type"`
+		Opts      *rawBroadcastOptions `json:"opts,omitempty"`
+		Rooms     []Room               `json:"rooms,omitempty"`
+		Close     bool                 `json:"close,omitempty"`
+		Data      []any                `json:"data,omitempty"`
+		Packet    *redisPacket         `json:"packet,omitempty"`
+	}
+
+	redisFetchResponse struct {
+		RequestID string          `json:"requestId"`
+		Sockets   []SocketDetails `json:"sockets"`
+	}
+
+	redisSocketsResponse struct {
+		RequestID string     `json:"requestId"`
+		Sockets   []SocketID `json:"sockets"`
+	}
+
+	redisAllRoomsResponse struct {
+		RequestID string `json:"requestId"`
+		Rooms     []Room `json:"rooms"`
+	}
+
+	redisServerSideEmitResponse struct {
+		Type      requestType `json:"type"`
+		RequestID string      `json:"requestId"`
+		Data      any         `json:"data"`
+	}
+
+	// The response to a broadcast with acknowledgements. Either the client count or
+	// the acknowledgement of a socket (its first argument) is set, depending on Type.
+	redisBroadcastAckResponse struct {
+		Type        requestType `json:"type"`
+		RequestID   string      `json:"requestId"`
+		ClientCount int         `json:"clientCount,omitempty"`
+		Packet      any         `json:"packet,omitempty"`
+	}
+)
+
+func NewRedisAdapterCreator(client redis.UniversalClient, opts *RedisAdapterOptions) Creator {
+	options := redisAdapterOptionsWithDefaults(opts)
+	creator := NewInMemoryAdapterCreator()
+	return func(socketStore SocketStore, parserCreator parser.Creator) Adapter {
+		inMemoryAdapter := creator(socketStore, parserCreator).(*inMemoryAdapter)
+		a := newRedisAdapter(inMemoryAdapter, client, options)
+		a.subscribe()
+		return a
+	}
+}
+
+// Returns an adapter that only publishes to the channels. It doesn't subscribe to any channel.
+func NewRedisPublisherCreator(client redis.UniversalClient, opts *RedisAdapterOptions) PublisherCreator {
+	options := redisAdapterOptionsWithDefaults(opts)
+	creator := NewInMemoryAdapterCreator()
+	return func(nsp string, parserCreator parser.Creator) Adapter {
+		inMemoryAdapter := creator(newPublisherSocketStore(nsp), parserCreator).(*inMemoryAdapter)
+		return newRedisAdapter(inMemoryAdapter, client, options)
+	}
+}
+
+func redisAdapterOptionsWithDefaults(opts *RedisAdapterOptions) RedisAdapterOptions {
+	var options RedisAdapterOptions
+	if opts != nil {
+		options = *opts
+	}
+	if options.Key == "" {
+		options.Key = DefaultRedisKey
+	}
+	if options.RequestsTimeout == 0 {
+		options.RequestsTimeout = DefaultRedisRequestsTimeout
+	}
+	if options.ErrorHandler == nil {
+		options.ErrorHandler = func(err error) {}
+	}
+	return options
+}
+
+func newRedisAdapter(inMemoryAdapter *inMemoryAdapter, client redis.UniversalClient, opts RedisAdapterOptions) *redisAdapter {
+	ctx, cancel := context.WithCancel(context.Background())
+	nsp := inMemoryAdapter.sockets.Namespace()
+	a := &redisAdapter{
+		inMemoryAdapter: inMemoryAdapter,
+		client:          client,
+		opts:            opts,
+		ctx:             ctx,
+		cancel:          cancel,
+		uid:             newUID(),
+		nsp:             nsp,
+		channel:         opts.Key + "#" + nsp + "#",
+		requestChannel:  opts.Key + "-request#" + nsp + "#",
+		responseChannel: opts.Key + "-response#" + nsp + "#",
+		requests:        newPendingRequests(),
+		ackRequests:     newBroadcastAckRequests(),
+	}
+	a.specificResponseChannel = a.responseChannel + a.uid + "#"
+	return a
+}
+
+func (a *redisAdapter) subscribe() {
+	a.pubSub = a.client.PSubscribe(a.ctx, a.channel+"*")
+	err := a.pubSub.Subscribe(a.ctx, a.requestChannel, a.responseChannel, a.specificResponseChannel)
+	if err != nil {
+		a.onError(err)
+	}
+	a.waitForSubscriptions(4)
+
+	go a.listen()
+}
+
+// Wait until the subscriptions are confirmed,
+// so that no message is missed after the adapter is created.
+func (a *redisAdapter) waitForSubscriptions(n int) {
+	ctx, cancel := context.WithTimeout(a.ctx, a.opts.RequestsTimeout)
+	defer cancel()
+	for i := 0; i < n; i++ {
+		_, err := a.pubSub.ReceiveTimeout(ctx, a.opts.RequestsTimeout)
+		if err != nil {
+			a.onError(fmt.Errorf("adapter: subscription could not be confirmed: %w", err))
+			return
+		}
+	}
+}
+
+func (a *redisAdapter) listen() {
+	for msg := range a.pubSub.Channel() {
+		payload := []byte(msg.Payload)
+		switch msg.Channel {
+		case a.requestChannel:
+			a.onRequest(payload)
+		case a.responseChannel, a.specificResponseChannel:
+			a.onResponse(payload)
+		default:
+			if strings.HasPrefix(msg.Channel, a.channel) {
+				a.onBroadcast(msg.Channel, payload)
+			}
+		}
+	}
+}
+
+func (a *redisAdapter) onError(err error) {
+	a.opts.ErrorHandler(err)
+}
+
+func (a *redisAdapter) ServerCount() int {
+	result, err := a.client.PubSubNumSub(a.ctx, a.requestChannel).Result()
+	if err != nil {
+		a.onError(err)
+		return 1
+	}
+	return int(result[a.requestChannel])
+}
+
+func (a *redisAdapter) Close() {
+	if a.ctx.Err() != nil {
+		return
+	}
+	a.cancel()
+	if a.pubSub == nil {
+		return
+	}
+	err := a.pubSub.Close()
+	if err != nil {
+		a.onError(err)
+	}
+}
+
+func (a *redisAdapter) Broadcast(header *parser.PacketHeader, v []any, opts *BroadcastOptions) {
+	if !opts.Flags.Local {
+		packet := redisPacket{
+			Type: int(header.Type),
+			Data: v,
+			Nsp:  a.nsp,
+			ID:   header.ID,
+		}
+		data, err := msgpackMarshal([]any{a.uid, &packet, newRawBroadcastOptions(opts)})
+		if err != nil {
+			panic(fmt.Errorf("sio: %w", err))
+		}
+
+		channel := a.channel
+		if opts.Rooms.Cardinality() == 1 {
+			channel += string(opts.Rooms.ToSlice()[0]) + "#"
+		}
+		a.publish(channel, data)
+	}
+	a.inMemoryAdapter.Broadcast(header, v, opts)
+}
+
+func (a *redisAdapter) BroadcastWithAck(header *parser.PacketHeader, v []any, opts *BroadcastOptions, timeout time.Duration, clientCount BroadcastClientCountFunc, ack BroadcastAckFunc) {
+	if !opts.Flags.Local {
+		requestID := newUID()
+		// The request is msgpack encoded, since the data can be binary.
+		data, err := msgpackMarshal(&redisRequest{
+			UID:       a.uid,
+			RequestID: requestID,
+			Type:      requestTypeBroadcast,
+			Packet: &redisPacket{
+				Type: int(header.Type),
+				Data: v,
+				Nsp:  a.nsp,
+			},
+			Opts: newRawBroadcastOptionsWithTimeout(opts, timeout),
+		})
+		if err != nil {
+			panic(fmt.Errorf("sio: %w", err))
+		}
+		a.ackRequests.add(requestID, timeout, clientCount, ack)
+		a.publish(a.requestChannel, data)
+	}
+	a.inMemoryAdapter.BroadcastWithAck(header, v, opts, timeout, clientCount, ack)
+}
+
+func (a *redisAdapter) onBroadcast(channel string, payload []byte) {
+	room := strings.TrimSuffix(strings.TrimPrefix(channel, a.channel), "#")
+	if room != "" && !a.hasRoom(Room(room)) {
+		return
+	}
+
+	var (
+		uid    string
+		packet redisPacket
+		opts   rawBroadcastOptions
+	)
+	err := decodeRedisBroadcast(payload, &uid, &packet, &opts)
+	if err != nil {
+		a.onError(fmt.Errorf("adapter: malformed broadcast message: %w", err))
+		return
+	}
+	if uid == a.uid {
+		return
+	}
+	if packet.Nsp == "" {
+		packet.Nsp = "/"
+	}
+	if packet.Nsp != a.nsp {
+		return
+	}
+
+	header := &parser.PacketHeader{
+		Type:      parser.PacketType(packet.Type),
+		Namespace: packet.Nsp,
+		ID:        packet.ID,
+	}
+	// The parser decides whether the packet is binary.
+	switch header.Type {
+	case parser.PacketTypeBinaryEvent:
+		header.Type = parser.PacketTypeEvent
+	case parser.PacketTypeBinaryAck:
+		header.Type = parser.PacketTypeAck
+	}
+
+	data := make([]any, len(packet.Data))
+	for i, v := range packet.Data {
+		data[i] = restoreBinary(v)
+	}
+	a.inMemoryAdapter.Broadcast(header, data, opts.toBroadcastOptions())
+}
+
+// A broadcast message is a msgpack array of: [uid, packet, opts]
+func decodeRedisBroadcast(payload []byte, uid *string, packet *redisPacket, opts *rawBroadcastOptions) error {
+	var message []msgpack.RawMessage
+	err := msgpackUnmarshal(payload, &message)
+	if err != nil {
+		return err
+	}
+	if len(message) != 3 {
+		return fmt.Errorf("expected 3 elements, got %d", len(message))
+	}
+	err = msgpackUnmarshal(message[0], uid)
+	if err != nil {
+		return err
+	}
+	err = msgpackUnmarshal(message[1], packet)
+	if err != nil {
+		return err
+	}
+	return msgpackUnmarshal(message[2], opts)
+}
+
+func (a *redisAdapter) FetchSockets(opts *BroadcastOptions) (sockets []Socket) {
+	sockets = a.inMemoryAdapter.FetchSockets(opts)
+	if opts.Flags.Local {
+		return
+	}
+
+	numSub := a.ServerCount()
+	if numSub <= 1 {
+		return
+	}
+
+	requestID := newUID()
+	r := a.requests.add(requestID, requestTypeRemoteFetch, numSub-1)
+	defer a.requests.remove(requestID)
+
+	a.publishRequest(&redisRequest{
+		UID:       a.uid,
+		RequestID: requestID,
+		Type:      requestTypeRemoteFetch,
+		Opts:      newRawBroadcastOptions(opts),
+	})
+
+	responses, err := r.wait(a.opts.RequestsTimeout)
+	if err != nil {
+		a.onError(fmt.Errorf("adapter: FetchSockets: %w", err))
+	}
+	for _, response := range responses {
+		for _, details := range response.([]SocketDetails) {
+			sockets = append(sockets, NewRemoteSocket(a, a.nsp, details))
+		}
+	}
+	return
+}
+
+func (a *redisAdapter) AddSockets(opts *BroadcastOptions, rooms ...Room) {
+	if !opts.Flags.Local {
+		a.publishRequest(&redisRequest{
+			UID:   a.uid,
+			Type:  requestTypeRemoteJoin,
+			Opts:  newRawBroadcastOptions(opts),
+			Rooms: rooms,
+		})
+	}
+	a.inMemoryAdapter.AddSockets(opts, rooms...)
+}
+
+func (a *redisAdapter) DelSockets(opts *BroadcastOptions, rooms ...Room) {
+	if !opts.Flags.Local {
+		a.publishRequest(&redisRequest{
+			UID:   a.uid,
+			Type:  requestTypeRemoteLeave,
+			Opts:  newRawBroadcastOptions(opts),
+			Rooms: rooms,
+		})
+	}
+	a.inMemoryAdapter.DelSockets(opts, rooms...)
+}
+
+func (a *redisAdapter) DisconnectSockets(opts *BroadcastOptions, close bool) {
+	if !opts.Flags.Local {
+		a.publishRequest(&redisRequest{
+			UID:   a.uid,
+			Type:  requestTypeRemoteDisconnect,
+			Opts:  newRawBroadcastOptions(opts),
+			Close: close,
+		})
+	}
+	a.inMemoryAdapter.DisconnectSockets(opts, close)
+}
+
+func (a *redisAdapter) ServerSideEmit(header *parser.PacketHeader, v []any) {
+	a.publishRequest(&redisRequest{
+		UID:  a.uid,
+		Type: requestTypeServerSideEmit,
+		Data: v,
+	})
+}
+
+func (a *redisAdapter) ServerSideEmitWithAck(header *parser.PacketHeader, v []any, timeout time.Duration, ack ServerSideEmitAckFunc) {
+	numSub := a.ServerCount()
+	if numSub <= 1 {
+		go ack(nil, nil)
+		return
+	}
+
+	requestID := newUID()
+	r := a.requests.add(requestID, requestTypeServerSideEmit, numSub-1)
+	a.publishRequest(&redisRequest{
+		UID:       a.uid,
+		RequestID: requestID,
+		Type:      requestTypeServerSideEmit,
+		Data:      v,
+	})
+
+	go func() {
+		defer a.requests.remove(requestID)
+		responses, err := r.wait(timeout)
+		ack(err, responses)
+	}()
+}
+
+func (a *redisAdapter) onRequest(payload []byte) {
+	var request redisRequest
+	err := decodeRedisPayload(payload, &request)
+	if err != nil {
+		a.onError(fmt.Errorf("adapter: malformed request: %w", err))
+		return
+	}
+	if request.UID == a.uid {
+		return
+	}
+
+	switch request.Type {
+	case requestTypeSockets:
+		sids := a.inMemoryAdapter.Sockets(mapset.NewThreadUnsafeSet(request.Rooms...))
+		a.publishResponse(&request, &redisSocketsResponse{
+			RequestID: request.RequestID,
+			Sockets:   sids.ToSlice(),
+		})
+
+	case requestTypeAllRooms:
+		a.publishResponse(&request, &redisAllRoomsResponse{
+			RequestID: request.RequestID,
+			Rooms:     a.allRooms(),
+		})
+
+	case requestTypeBroadcast:
+		if request.Packet == nil {
+			return
+		}
+		header := &parser.PacketHeader{Type: parser.PacketTypeEvent, Namespace: a.nsp}
+		data := make([]any, len(request.Packet.Data))
+		for i, v := range request.Packet.Data {
+			data[i] = restoreBinary(v)
+		}
+		timeout := request.Opts.timeout(a.opts.RequestsTimeout)
+		a.inMemoryAdapter.BroadcastWithAck(header, data, request.Opts.toBroadcastOptions(), timeout, func(clientCount int) {
+			a.publishResponse(&request, &redisBroadcastAckResponse{
+				Type:        requestTypeBroadcastClientCount,
+				RequestID:   request.RequestID,
+				ClientCount: clientCount,
+			})
+		}, func(response any) {
+			// The acknowledgement is msgpack encoded, since it can be binary.
+			data, err := msgpackMarshal(&redisBroadcastAckResponse{
+				Type:      requestTypeBroadcastAck,
+				RequestID: request.RequestID,
+				Packet:    response,
+			})
+			if err != nil {
+				a.onError(err)
+				return
+			}
+			a.publish(a.responseChannelOf(&request), data)
+		})
+
+	case requestTypeRemoteJoin:
+		if request.Opts != nil {
+			a.inMemoryAdapter.AddSockets(request.Opts.toBroadcastOptions(), request.Rooms...)
+		}
+
+	case requestTypeRemoteLeave:
+		if request.Opts != nil {
+			a.inMemoryAdapter.DelSockets(request.Opts.toBroadcastOptions(), request.Rooms...)
+		}
+
+	case requestTypeRemoteDisconnect:
+		if request.Opts != nil {
+			a.inMemoryAdapter.DisconnectSockets(request.Opts.toBroadcastOptions(), request.Close)
+		}
+
+	case requestTypeRemoteFetch:
+		sockets := a.inMemoryAdapter.FetchSockets(request.Opts.toBroadcastOptions())
+		details := make([]SocketDetails, len(sockets))
+		for i, socket := range sockets {
+			details[i] = newSocketDetails(a, socket)
+		}
+		a.publishResponse(&request, &redisFetchResponse{
+			RequestID: request.RequestID,
+			Sockets:   details,
+		})
+
+	case requestTypeServerSideEmit:
+		if len(request.Data) == 0 {
+			return
+		}
+		eventName, ok := request.Data[0].(string)
+		if !ok {
+			a.onError(fmt.Errorf("adapter: malformed server side emit: event name is not a string"))
+			return
+		}
+		var ack func(response any)
+		if request.RequestID != "" {
+			ack = func(response any) {
+				a.publishResponse(&request, &redisServerSideEmitResponse{
+					Type:      requestTypeServerSideEmit,
+					RequestID: request.RequestID,
+					Data:      response,
+				})
+			}
+		}
+		a.sockets.OnServerSideEmit(eventName, request.Data[1:], ack)
+	}
+}
+
+func (a *redisAdapter) onResponse(payload []byte) {
+	var head struct {
+		Type      requestType `json:"type"`
+		RequestID string      `json:"requestId"`
+	}
+	err := decodeRedisPayload(payload, &head)
+	if err != nil {
+		a.onError(fmt.Errorf("adapter: malformed response: %w", err))
+		return
+	}
+
+	switch head.Type {
+	case requestTypeBroadcastClientCount, requestTypeBroadcastAck:
+		var response redisBroadcastAckResponse
+		err = decodeRedisPayload(payload, &response)
+		if err != nil {
+			a.onError(fmt.Errorf("adapter: malformed response: %w", err))
+			return
+		}
+		a.ackRequests.onResponse(response.Type, response.RequestID, response.ClientCount, response.Packet)
+		return
+	}
+
+	r, ok := a.requests.get(head.RequestID)
+	if !ok {
+		return
+	}
+
+	switch r.typ {
+	case requestTypeRemoteFetch:
+		var response redisFetchResponse
+		err = decodeRedisPayload(payload, &response)
+		if err != nil {
+			a.onError(fmt.Errorf("adapter: malformed response: %w", err))
+			return
+		}
+		r.addResponse(response.Sockets)
+
+	case requestTypeServerSideEmit:
+		var response redisServerSideEmitResponse
+		err = decodeRedisPayload(payload, &response)
+		if err != nil {
+			a.onError(fmt.Errorf("adapter: malformed response: %w", err))
+			return
+		}
+		r.addResponse(response.Data)
+	}
+}
+
+// Requests and responses are JSON encoded,
+// unless they contain binary data (in that case they are msgpack encoded).
+func decodeRedisPayload(payload []byte, v any) error {
+	if len(payload) > 0 && payload[0] == '{' {
+		return json.Unmarshal(payload, v)
+	}
+	return msgpackUnmarshal(payload, v)
+}
+
+func (a *redisAdapter) publishRequest(request *redisRequest) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		a.onError(err)
+		return
+	}
+	a.publish(a.requestChannel, data)
+}
+
+func (a *redisAdapter) publishResponse(request *redisRequest, response any) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		a.onError(err)
+		return
+	}
+	a.publish(a.responseChannelOf(request), data)
+}
+
+func (a *redisAdapter) responseChannelOf(request *redisRequest) string {
+	if a.opts.PublishOnSpecificResponseChannel {
+		return a.responseChannel + request.UID + "#"
+	}
+	return a.responseChannel
+}
+
+func (a *redisAdapter) publish(channel string, data []byte) {
+	err := a.client.Publish(a.ctx, channel, data).Err()
+	if err != nil {
+		a.onError(err)
+	}
+}
@@ -1,7 +1,6 @@
 package adapter
 
 import (
-	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
@@ -10,26 +9,54 @@ import (
 	"github.com/hhuuson97/socket.io-go/parser"
 	"github.com/karagenc/yeast"
 	"github.com/redis/go-redis/v9"
-	"log"
 	"strconv"
 	"sync"
 	"time"
 )
 
 const (
-	DEFAULT_STREAM_NAME        = "socket.io"
-	DEFAULT_MAX_LEN            = 10000
-	DEFAULT_READ_COUNT         = 100
-	DEFAULT_SESSION_KEY_PREFIX = "sio:session:"
-	DEFAULT_MESSAGE_ID_PREFIX  = "sio:message:"
+	DEFAULT_STREAM_NAME          = "socket.io"
+	DEFAULT_MAX_LEN              = 10000
+	DEFAULT_READ_COUNT           = 100
+	DEFAULT_SESSION_KEY_PREFIX   = "sio:session:"
+	DEFAULT_MESSAGE_ID_PREFIX    = "sio:message:"
+	DEFAULT_REQUESTS_TIMEOUT     = 5 * time.Second
+	DEFAULT_READ_RETRY_DELAY     = 100 * time.Millisecond
+	DEFAULT_READ_RETRY_DELAY_MAX = 10 * time.Second
+	DEFAULT_HEARTBEAT_INTERVAL   = 5 * time.Second
+	DEFAULT_HEARTBEAT_TIMEOUT    = 10 * time.Second
+
+	// Maximum duration of a blocking read. Close takes effect after the ongoing read returns.
+	redisStreamReadBlock = time.Second
 )
 
 type RedisStreamsAdapterOptions struct {
-	StreamName            string
+	// Name of the stream of the broadcast packets. It is read to restore the connection state.
+	StreamName string
+	// Name of the stream of the messages exchanged between the nodes (heartbeats, requests and responses).
+	// These are kept apart from the packets, so that they don't shorten the window of the connection state recovery.
+	//
+	// Default: StreamName + ":control"
+	ControlStreamName string
+	// Approximate maximum length of each stream.
 	MaxLength             int64
 	ReadCount             int64
 	SessionKeyPrefix      string
 	MaxDisconnectDuration time.Duration
+	// Duration to wait for the responses of the other nodes to a request (such as FetchSockets).
+	RequestsTimeout time.Duration
+	// Delay before reading the stream again after a failed read.
+	// The delay is doubled after each consecutive failure, up to ReadRetryDelayMax.
+	ReadRetryDelay    time.Duration
+	ReadRetryDelayMax time.Duration
+	// Called when an error occurs (such as when Redis is unreachable or a malformed message is received).
+	//
+	// Default: errors are ignored.
+	ErrorHandler func(err error)
+	// Interval between the heartbeats sent to the other nodes.
+	HeartbeatInterval time.Duration
+	// Duration after which a node that hasn't sent a heartbeat is considered down.
+	HeartbeatTimeout time.Duration
 }
 
 type RedisStreamAdapter struct {
@@ -40,7 +67,8 @@ type RedisStreamAdapter struct {
 
 	yeaster *yeast.Yeaster
 
-	ctx context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
 
 	redisClient redis.Cmdable
 	opts        *RedisStreamsAdapterOptions
@@ -48,8 +76,43 @@ type RedisStreamAdapter struct {
 	sockets SocketStore
 
 	parser parser.Parser
+
+	uid         string
+	nsp         string
+	requests    *pendingRequests
+	ackRequests *broadcastAckRequests
+
+	// The other nodes of the cluster.
+	nodes *nodeRegistry
 }
 
+// Requests and responses exchanged between the nodes through the stream.
+// They are stored as JSON in the "request" and "response" fields of a stream entry.
+type (
+	redisStreamRequest struct {
+		UID       string               `json:"uid"`
+		Nsp       string               `json:"nsp"`
+		RequestID string               `json:"requestId,omitempty"`
+		Type      requestType          `json:"type"`
+		Opts      *rawBroadcastOptions `json:"opts,omitempty"`
+		Rooms     []Room               `json:"rooms,omitempty"`
+		Close     bool                 `json:"close,omitempty"`
+		Data      []any                `json:"data,omitempty"`
+		// The data of a broadcast with acknowledgements (see encodeBroadcastAckData).
+		Packet []byte `json:"packet,omitempty"`
+	}
+
+	redisStreamResponse struct {
+		UID         string          `json:"uid"`
+		Nsp         string          `json:"nsp"`
+		RequestID   string          `json:"requestId"`
+		Type        requestType     `json:"type"`
+		Sockets     []SocketDetails `json:"sockets,omitempty"`
+		Data        any             `json:"data,omitempty"`
+		ClientCount int             `json:"clientCount,omitempty"`
+	}
+)
+
 type RedisStreamBuffer [][]byte
 
 func (b RedisStreamBuffer) MarshalBinary() ([]byte, error) {
@@ -59,7 +122,6 @@ func (b RedisStreamBuffer) MarshalBinary() ([]byte, error) {
 	}
 	buffersMarshaled, err := json.Marshal(newBuffers)
 	if err != nil {
-		log.Fatalf("Error marshaling data to JSON: %v", err)
 		return nil, err
 	}
 
@@ -70,7 +132,6 @@ func (b *RedisStreamBuffer) UnmarshalJSON(data []byte) error {
 	var buffers []string
 	err := json.Unmarshal(data, &buffers)
 	if err != nil {
-		log.Fatalf("Error marshaling data to JSON: %v", err)
 		return err
 	}
 
@@ -84,6 +145,8 @@ func (b *RedisStreamBuffer) UnmarshalJSON(data []byte) error {
 }
 
 type RedisStreamMessage struct {
+	// The node that added the message to the stream.
+	NodeID    string
 	SessionId string
 	Header    *parser.PacketHeader
 	Buffers   [][]byte
@@ -92,25 +155,33 @@ type RedisStreamMessage struct {
 }
 
 func (m *RedisStreamMessage) Parse(msg redis.XMessage) error {
-	m.SessionId = msg.Values["sessionId"].(string)
-
-	err := json.Unmarshal([]byte(msg.Values["header"].(string)), &m.Header)
+	values, err := redisStreamStringValues(msg, "sessionId", "header", "buffers", "opts", "emittedAt")
 	if err != nil {
-		log.Fatalf("Error marshaling data to JSON: %v", err)
 		return err
 	}
+	m.SessionId = values[0]
+	// Messages added by older versions don't have a node ID.
+	m.NodeID, _ = msg.Values["nodeId"].(string)
+
+	err = json.Unmarshal([]byte(values[1]), &m.Header)
+	if err != nil {
+		return fmt.Errorf("adapter: malformed header: %w", err)
+	}
+	if m.Header == nil {
+		return fmt.Errorf("adapter: malformed header: header is null")
+	}
 
 	m.Buffers = make([][]byte, 0)
 	tmp := []string{}
-	err = json.Unmarshal([]byte(msg.Values["buffers"].(string)), &tmp)
+	err = json.Unmarshal([]byte(values[2]), &tmp)
 	if err != nil {
-		log.Fatalf("Error marshaling data to JSON: %v", err)
+		return fmt.Errorf("adapter: malformed buffers: %w", err)
 	}
 	if m.Header.IsBinary() {
 		for _, d := range tmp {
 			b, err := base64.StdEncoding.DecodeString(d)
 			if err != nil {
-				log.Fatalf("Error unmarshaling data to JSON: %v", err)
+				return fmt.Errorf("adapter: malformed buffers: %w", err)
 			}
 
 			m.Buffers = append(m.Buffers, b)
@@ -121,16 +192,17 @@ func (m *RedisStreamMessage) Parse(msg redis.XMessage) error {
 		}
 	}
 
-	err = json.Unmarshal([]byte(msg.Values["opts"].(string)), &m.Opts)
+	err = json.Unmarshal([]byte(values[3]), &m.Opts)
 	if err != nil {
-		log.Fatalf("Error marshaling data to JSON: %v", err)
-		return err
+		return fmt.Errorf("adapter: malformed opts: %w", err)
+	}
+	if m.Opts == nil {
+		m.Opts = NewBroadcastOptions()
 	}
 
-	unixTs, err := strconv.ParseInt(msg.Values["emittedAt"].(string), 10, 64)
+	unixTs, err := strconv.ParseInt(values[4], 10, 64)
 	if err != nil {
-		log.Fatalf("Error parsing emittedAt: %v", err)
-		return err
+		return fmt.Errorf("adapter: malformed emittedAt: %w", err)
 	}
 
 	m.EmittedAt = time.Unix(unixTs, 0)
@@ -138,7 +210,19 @@ func (m *RedisStreamMessage) Parse(msg redis.XMessage) error {
 	return nil
 }
 
-func (m RedisStreamMessage) ToStreamData() map[string]interface{} {
+func redisStreamStringValues(msg redis.XMessage, keys ...string) ([]string, error) {
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		value, ok := msg.Values[key].(string)
+		if !ok {
+			return nil, fmt.Errorf("adapter: stream entry %s: missing or invalid field: %s", msg.ID, key)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+func (m RedisStreamMessage) ToStreamData() (map[string]interface{}, error) {
 	b := make([]string, 0)
 	if m.Header.IsBinary() {
 		for _, t := range m.Buffers {
@@ -152,105 +236,232 @@ func (m RedisStreamMessage) ToStreamData() map[string]interface{} {
 
 	buffers, err := json.Marshal(b)
 	if err != nil {
-		fmt.Printf("Error marshaling data to JSON: %v", err)
+		return nil, err
 	}
 
 	return map[string]interface{}{
+		"nodeId":    m.NodeID,
 		"sessionId": m.SessionId,
 		"header":    m.Header,
 		"buffers":   buffers,
 		"opts":      m.Opts,
 		"emittedAt": m.EmittedAt.UTC().Unix(),
-	}
+	}, nil
 }
 
 func NewRedisStreamAdapterCreator(redisClient redis.Cmdable, opts *RedisStreamsAdapterOptions) Creator {
+	opts = redisStreamsAdapterOptionsWithDefaults(opts)
 	return func(socketStore SocketStore, parserCreator parser.Creator) Adapter {
-		if opts == nil {
-			opts = &RedisStreamsAdapterOptions{
-				StreamName:       DEFAULT_STREAM_NAME,
-				MaxLength:        DEFAULT_MAX_LEN,
-				ReadCount:        DEFAULT_READ_COUNT,
-				SessionKeyPrefix: DEFAULT_SESSION_KEY_PREFIX,
-			}
-		}
-		if opts.StreamName == "" {
-			opts.StreamName = DEFAULT_STREAM_NAME
-		}
-		if opts.SessionKeyPrefix == "" {
-			opts.SessionKeyPrefix = DEFAULT_SESSION_KEY_PREFIX
+		redisStreamAdapter := newRedisStreamAdapter(redisClient, opts, socketStore, parserCreator)
+
+		go redisStreamAdapter.read(
+			redisStreamAdapter.lastEntryID(opts.StreamName),
+			redisStreamAdapter.lastEntryID(opts.ControlStreamName),
+		)
+		go redisStreamAdapter.heartbeat()
+
+		return redisStreamAdapter
+	}
+}
+
+// Returns an adapter that only writes to the stream. It is neither reading the stream nor sending heartbeats.
+func NewRedisStreamPublisherCreator(redisClient redis.Cmdable, opts *RedisStreamsAdapterOptions) PublisherCreator {
+	opts = redisStreamsAdapterOptionsWithDefaults(opts)
+	return func(nsp string, parserCreator parser.Creator) Adapter {
+		return newRedisStreamAdapter(redisClient, opts, newPublisherSocketStore(nsp), parserCreator)
+	}
+}
+
+func redisStreamsAdapterOptionsWithDefaults(opts *RedisStreamsAdapterOptions) *RedisStreamsAdapterOptions {
+	if opts == nil {
+		opts = &RedisStreamsAdapterOptions{
+			StreamName:       DEFAULT_STREAM_NAME,
+			MaxLength:        DEFAULT_MAX_LEN,
+			ReadCount:        DEFAULT_READ_COUNT,
+			SessionKeyPrefix: DEFAULT_SESSION_KEY_PREFIX,
 		}
-		if opts.MaxLength == 0 {
-			opts.MaxLength = DEFAULT_MAX_LEN
+	}
+	if opts.StreamName == "" {
+		opts.StreamName = DEFAULT_STREAM_NAME
+	}
+	if opts.ControlStreamName == "" {
+		opts.ControlStreamName = opts.StreamName + ":control"
+	}
+	if opts.SessionKeyPrefix == "" {
+		opts.SessionKeyPrefix = DEFAULT_SESSION_KEY_PREFIX
+	}
+	if opts.MaxLength == 0 {
+		opts.MaxLength = DEFAULT_MAX_LEN
+	}
+	if opts.ReadCount == 0 {
+		opts.ReadCount = DEFAULT_READ_COUNT
+	}
+	if opts.MaxDisconnectDuration == 0 {
+		opts.MaxDisconnectDuration = 2 * time.Minute
+	}
+	if opts.RequestsTimeout == 0 {
+		opts.RequestsTimeout = DEFAULT_REQUESTS_TIMEOUT
+	}
+	if opts.ReadRetryDelay == 0 {
+		opts.ReadRetryDelay = DEFAULT_READ_RETRY_DELAY
+	}
+	if opts.ReadRetryDelayMax == 0 {
+		opts.ReadRetryDelayMax = DEFAULT_READ_RETRY_DELAY_MAX
+	}
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = func(err error) {}
+	}
+	if opts.HeartbeatInterval == 0 {
+		opts.HeartbeatInterval = DEFAULT_HEARTBEAT_INTERVAL
+	}
+	if opts.HeartbeatTimeout == 0 {
+		opts.HeartbeatTimeout = DEFAULT_HEARTBEAT_TIMEOUT
+	}
+	return opts
+}
+
+func newRedisStreamAdapter(redisClient redis.Cmdable, opts *RedisStreamsAdapterOptions, socketStore SocketStore, parserCreator parser.Creator) *RedisStreamAdapter {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &RedisStreamAdapter{
+		mu:                    sync.Mutex{},
+		rooms:                 make(map[Room]mapset.Set[SocketID]),
+		sids:                  make(map[SocketID]mapset.Set[Room]),
+		maxDisconnectDuration: opts.MaxDisconnectDuration,
+		yeaster:               yeast.New(),
+		ctx:                   ctx,
+		cancel:                cancel,
+		redisClient:           redisClient,
+		opts:                  opts,
+		sockets:               socketStore,
+		parser:                parserCreator(),
+		uid:                   newUID(),
+		nsp:                   socketStore.Namespace(),
+		requests:              newPendingRequests(),
+		ackRequests:           newBroadcastAckRequests(),
+		nodes:                 newNodeRegistry(opts.HeartbeatTimeout),
+	}
+}
+
+// The ID of the last entry of the stream. Only the entries added after it are read.
+func (a *RedisStreamAdapter) lastEntryID(stream string) string {
+	messages, err := a.redisClient.XRevRangeN(a.ctx, stream, "+", "-", 1).Result()
+	if err != nil {
+		a.onError(fmt.Errorf("adapter: reading from stream: %w", err))
+		return "$"
+	}
+	if len(messages) == 0 {
+		return "0-0"
+	}
+	return messages[0].ID
+}
+
+// Read the stream and the control stream until the adapter is closed.
+//
+// If a read fails, it is retried with an exponential backoff,
+// starting from the last entries that were read, so that no entry is missed.
+func (a *RedisStreamAdapter) read(offset, controlOffset string) {
+	retryDelay := a.opts.ReadRetryDelay
+
+	for {
+		result, err := a.redisClient.XRead(a.ctx, &redis.XReadArgs{
+			Streams: []string{a.opts.StreamName, a.opts.ControlStreamName, offset, controlOffset},
+			Count:   a.opts.ReadCount,
+			Block:   redisStreamReadBlock,
+		}).Result()
+		if a.ctx.Err() != nil {
+			return
 		}
-		if opts.ReadCount == 0 {
-			opts.ReadCount = DEFAULT_READ_COUNT
+		if err == redis.Nil {
+			continue
+		} else if err != nil {
+			a.onError(fmt.Errorf("adapter: reading from stream: %w", err))
+			select {
+			case <-a.ctx.Done():
+				return
+			case <-time.After(retryDelay):
+			}
+			retryDelay = min(retryDelay*2, a.opts.ReadRetryDelayMax)
+			continue
 		}
-		if opts.MaxDisconnectDuration == 0 {
-			opts.MaxDisconnectDuration = 2 * time.Minute
+		retryDelay = a.opts.ReadRetryDelay
+
+		for _, stream := range result {
+			for _, message := range stream.Messages {
+				if stream.Stream == a.opts.ControlStreamName {
+					controlOffset = message.ID
+				} else {
+					offset = message.ID
+				}
+				a.onMessage(message)
+			}
 		}
+	}
+}
 
-		ctx := context.Background()
-		packageParser := parserCreator()
-
-		redisStreamAdapter := &RedisStreamAdapter{
-			mu:                    sync.Mutex{},
-			rooms:                 make(map[Room]mapset.Set[SocketID]),
-			sids:                  make(map[SocketID]mapset.Set[Room]),
-			maxDisconnectDuration: opts.MaxDisconnectDuration,
-			yeaster:               yeast.New(),
-			ctx:                   ctx,
-			redisClient:           redisClient,
-			opts:                  opts,
-			sockets:               socketStore,
-			parser:                packageParser,
-		}
+func (a *RedisStreamAdapter) onMessage(message redis.XMessage) {
+	if request, ok := message.Values["request"].(string); ok {
+		a.onRequest(request)
+		return
+	}
+	if response, ok := message.Values["response"].(string); ok {
+		a.onResponse(response)
+		return
+	}
 
-		go func() {
-			// Get from beginning
-			offset := "$"
-
-			for {
-				result, err := redisClient.XRead(ctx, &redis.XReadArgs{
-					Streams: []string{opts.StreamName},
-					Count:   opts.ReadCount,
-					ID:      offset,
-				}).Result()
-				if err != nil {
-					log.Fatalf("Error reading from stream: %v", err)
-				}
+	msg := &RedisStreamMessage{}
+	err := msg.Parse(message)
+	if err != nil {
+		a.onError(err)
+		return
+	}
+	// The messages of this node were already delivered by Broadcast.
+	if msg.NodeID == a.uid || msg.Header.Namespace != a.nsp {
+		return
+	}
 
-				for _, stream := range result {
-					fmt.Printf("Stream: %s\n", stream.Stream)
-					for _, message := range stream.Messages {
-						offset = message.ID
-						msg := &RedisStreamMessage{}
-						err = msg.Parse(message)
-						if err != nil {
-							log.Fatalf("Error parsing message: %v", err)
-						}
-
-						redisStreamAdapter.apply(msg.Opts, func(socket Socket) {
-							redisStreamAdapter.sockets.SendBuffers(socket.ID(), msg.Buffers)
-						})
-					}
-				}
-			}
-		}()
+	a.apply(msg.Opts, func(socket Socket) {
+		a.sockets.SendBuffers(socket.ID(), msg.Buffers)
+	})
+}
 
-		return redisStreamAdapter
+func (a *RedisStreamAdapter) onError(err error) {
+	a.opts.ErrorHandler(err)
+}
+
+// Announce this node to the other nodes and send heartbeats periodically until the adapter is closed.
+func (a *RedisStreamAdapter) heartbeat() {
+	a.publishRequest(&redisStreamRequest{Type: requestTypeInitialHeartbeat})
+
+	ticker := time.NewTicker(a.opts.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			a.publishRequest(&redisStreamRequest{Type: requestTypeHeartbeat})
+		}
 	}
 }
 
+// Returns the number of the live nodes of the cluster, including this one.
 func (a *RedisStreamAdapter) ServerCount() int {
-	return 1
+	return a.nodes.count() + 1
 }
 
+// Notifies the other nodes and stops reading the stream.
 func (a *RedisStreamAdapter) Close() {
-
+	if a.ctx.Err() != nil {
+		return
+	}
+	a.publishRequest(&redisStreamRequest{Type: requestTypeAdapterClose})
+	a.cancel()
 }
 
 func (a *RedisStreamAdapter) AddAll(sid SocketID, rooms []Room) {
+	var events roomEvents
+	defer events.emit(a.sockets)
+
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
@@ -267,14 +478,19 @@ func (a *RedisStreamAdapter) AddAll(sid SocketID, rooms []Room) {
 		if !ok {
 			r = mapset.NewThreadUnsafeSet[SocketID]()
 			a.rooms[room] = r
+			events.add(roomEventCreate, room, "")
 		}
 		if !r.Contains(sid) {
 			r.Add(sid)
+			events.add(roomEventJoin, room, sid)
 		}
 	}
 }
 
 func (a *RedisStreamAdapter) Delete(sid SocketID, room Room) {
+	var events roomEvents
+	defer events.emit(a.sockets)
+
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
@@ -283,20 +499,25 @@ func (a *RedisStreamAdapter) Delete(sid SocketID, room Room) {
 		s.Remove(room)
 	}
 
-	a.delete(sid, room)
+	a.delete(sid, room, &events)
 }
 
-func (a *RedisStreamAdapter) delete(sid SocketID, room Room) {
+func (a *RedisStreamAdapter) delete(sid SocketID, room Room, events *roomEvents) {
 	r, ok := a.rooms[room]
-	if ok {
+	if ok && r.Contains(sid) {
 		r.Remove(sid)
+		events.add(roomEventLeave, room, sid)
 		if r.Cardinality() == 0 {
 			delete(a.rooms, room)
+			events.add(roomEventDelete, room, "")
 		}
 	}
 }
 
 func (a *RedisStreamAdapter) DeleteAll(sid SocketID) {
+	var events roomEvents
+	defer events.emit(a.sockets)
+
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
@@ -306,17 +527,20 @@ func (a *RedisStreamAdapter) DeleteAll(sid SocketID) {
 	}
 
 	s.Each(func(room Room) bool {
-		a.delete(sid, room)
+		a.delete(sid, room, &events)
 		return false
 	})
 
 	delete(a.sids, sid)
 }
 
+// Sends the packet to the matching sockets of this node, and
+// adds it to the stream for the other nodes (unless the Local flag is set).
 func (a *RedisStreamAdapter) Broadcast(header *parser.PacketHeader, v []any, opts *BroadcastOptions) {
 	isEventPacket := header.Type == parser.PacketTypeEvent
 	withoutAcknowledgement := header.ID == nil
-	isIncludedSession := isEventPacket && withoutAcknowledgement
+	// Local packets are not added to the stream, thus they cannot be recovered.
+	isIncludedSession := isEventPacket && withoutAcknowledgement && !opts.Flags.Local
 	var sessionId string
 	if isIncludedSession {
 		a.mu.Lock()
@@ -330,20 +554,62 @@ func (a *RedisStreamAdapter) Broadcast(header *parser.PacketHeader, v []any, opt
 		panic(fmt.Errorf("sio: %w", err))
 	}
 
-	buf := bytes.Buffer{}
-	e := a.parser.JSONSerializer().NewEncoder(&buf)
-	err = e.Encode(&v)
-	if err != nil {
-		panic(fmt.Errorf("sio: %w", err))
+	if !opts.Flags.Local {
+		a.publishBroadcast(header, buffers, opts, sessionId)
+	}
+
+	a.apply(opts, func(socket Socket) {
+		a.sockets.SendBuffers(socket.ID(), buffers)
+	})
+}
+
+// Sends the packet to the matching sockets of this node, and
+// asks the other nodes to do the same (unless the Local flag is set).
+// Broadcasts with acknowledgements cannot be recovered.
+func (a *RedisStreamAdapter) BroadcastWithAck(header *parser.PacketHeader, v []any, opts *BroadcastOptions, timeout time.Duration, clientCount BroadcastClientCountFunc, ack BroadcastAckFunc) {
+	if !opts.Flags.Local {
+		data, err := encodeBroadcastAckData(v)
+		if err != nil {
+			panic(fmt.Errorf("sio: %w", err))
+		}
+		requestID := newUID()
+		a.ackRequests.add(requestID, timeout, clientCount, ack)
+		a.publishRequest(&redisStreamRequest{
+			RequestID: requestID,
+			Type:      requestTypeBroadcast,
+			Opts:      newRawBroadcastOptionsWithTimeout(opts, timeout),
+			Packet:    data,
+		})
 	}
+	a.broadcastWithAck(header, v, opts, timeout, clientCount, ack)
+}
+
+func (a *RedisStreamAdapter) broadcastWithAck(header *parser.PacketHeader, v []any, opts *BroadcastOptions, timeout time.Duration, clientCount BroadcastClientCountFunc, ack BroadcastAckFunc) {
+	header, buffers := encodeWithAckID(a.sockets, a.parser, header, v)
+
+	count := 0
+	a.apply(opts, func(socket Socket) {
+		if a.sockets.RegisterAck(socket.ID(), *header.ID, timeout, ack) {
+			a.sockets.SendBuffers(socket.ID(), buffers)
+			count++
+		}
+	})
+	clientCount(count)
+}
 
-	values := RedisStreamMessage{
+func (a *RedisStreamAdapter) publishBroadcast(header *parser.PacketHeader, buffers [][]byte, opts *BroadcastOptions, sessionId string) {
+	values, err := RedisStreamMessage{
+		NodeID:    a.uid,
 		SessionId: sessionId,
 		Header:    header,
 		Buffers:   buffers,
 		Opts:      opts,
 		EmittedAt: time.Now(),
 	}.ToStreamData()
+	if err != nil {
+		a.onError(fmt.Errorf("adapter: encoding message: %w", err))
+		return
+	}
 
 	messageID, err := a.redisClient.XAdd(a.ctx, &redis.XAddArgs{
 		Stream: a.opts.StreamName,
@@ -351,15 +617,14 @@ func (a *RedisStreamAdapter) Broadcast(header *parser.PacketHeader, v []any, opt
 		MaxLen: a.opts.MaxLength,
 	}).Result()
 	if err != nil {
-		log.Fatalf("Error adding message to Redis: %v", err)
-	} else {
-		log.Printf("Added message to Redis: %v", messageID)
+		a.onError(fmt.Errorf("adapter: adding message to stream: %w", err))
+		return
 	}
 
-	if isIncludedSession {
+	if sessionId != "" {
 		err = a.redisClient.Set(a.ctx, fmt.Sprintf("%s%s", DEFAULT_MESSAGE_ID_PREFIX, sessionId), messageID, a.opts.MaxDisconnectDuration).Err()
 		if err != nil {
-			log.Fatalf("Error setting message id: %v", err)
+			a.onError(fmt.Errorf("adapter: setting message ID: %w", err))
 		}
 	}
 }
@@ -396,7 +661,44 @@ func (a *RedisStreamAdapter) SocketRooms(sid SocketID) (rooms mapset.Set[Room],
 	return
 }
 
+// Returns the matching sockets of every node. The sockets of the other nodes are returned as RemoteSocket.
 func (a *RedisStreamAdapter) FetchSockets(opts *BroadcastOptions) (sockets []Socket) {
+	sockets = a.fetchSockets(opts)
+	if opts.Flags.Local {
+		return
+	}
+
+	expected := a.nodes.count()
+	if expected == 0 {
+		return
+	}
+
+	requestID := newUID()
+	r := a.requests.add(requestID, requestTypeRemoteFetch, expected)
+	defer a.requests.remove(requestID)
+
+	err := a.publishRequest(&redisStreamRequest{
+		RequestID: requestID,
+		Type:      requestTypeRemoteFetch,
+		Opts:      newRawBroadcastOptions(opts),
+	})
+	if err != nil {
+		return
+	}
+
+	responses, err := r.wait(a.opts.RequestsTimeout)
+	if err != nil {
+		a.onError(fmt.Errorf("adapter: FetchSockets: %w", err))
+	}
+	for _, response := range responses {
+		for _, details := range response.([]SocketDetails) {
+			sockets = append(sockets, NewRemoteSocket(a, a.nsp, details))
+		}
+	}
+	return
+}
+
+func (a *RedisStreamAdapter) fetchSockets(opts *BroadcastOptions) (sockets []Socket) {
 	a.apply(opts, func(socket Socket) {
 		sockets = append(sockets, socket)
 	})
@@ -404,12 +706,34 @@ func (a *RedisStreamAdapter) FetchSockets(opts *BroadcastOptions) (sockets []Soc
 }
 
 func (a *RedisStreamAdapter) AddSockets(opts *BroadcastOptions, rooms ...Room) {
+	if !opts.Flags.Local {
+		a.publishRequest(&redisStreamRequest{
+			Type:  requestTypeRemoteJoin,
+			Opts:  newRawBroadcastOptions(opts),
+			Rooms: rooms,
+		})
+	}
+	a.addSockets(opts, rooms...)
+}
+
+func (a *RedisStreamAdapter) addSockets(opts *BroadcastOptions, rooms ...Room) {
 	a.apply(opts, func(socket Socket) {
 		socket.Join(rooms...)
 	})
 }
 
 func (a *RedisStreamAdapter) DelSockets(opts *BroadcastOptions, rooms ...Room) {
+	if !opts.Flags.Local {
+		a.publishRequest(&redisStreamRequest{
+			Type:  requestTypeRemoteLeave,
+			Opts:  newRawBroadcastOptions(opts),
+			Rooms: rooms,
+		})
+	}
+	a.delSockets(opts, rooms...)
+}
+
+func (a *RedisStreamAdapter) delSockets(opts *BroadcastOptions, rooms ...Room) {
 	a.apply(opts, func(socket Socket) {
 		for _, room := range rooms {
 			socket.Leave(room)
@@ -418,11 +742,171 @@ func (a *RedisStreamAdapter) DelSockets(opts *BroadcastOptions, rooms ...Room) {
 }
 
 func (a *RedisStreamAdapter) DisconnectSockets(opts *BroadcastOptions, close bool) {
+	if !opts.Flags.Local {
+		a.publishRequest(&redisStreamRequest{
+			Type:  requestTypeRemoteDisconnect,
+			Opts:  newRawBroadcastOptions(opts),
+			Close: close,
+		})
+	}
+	a.disconnectSockets(opts, close)
+}
+
+func (a *RedisStreamAdapter) disconnectSockets(opts *BroadcastOptions, close bool) {
 	a.apply(opts, func(socket Socket) {
 		socket.Disconnect(close)
 	})
 }
 
+func (a *RedisStreamAdapter) onRequest(data string) {
+	var request redisStreamRequest
+	err := json.Unmarshal([]byte(data), &request)
+	if err != nil {
+		a.onError(fmt.Errorf("adapter: malformed request: %w", err))
+		return
+	}
+	if request.UID == a.uid || request.Nsp != a.nsp {
+		return
+	}
+
+	opts := request.Opts.toBroadcastOptions()
+	switch request.Type {
+	case requestTypeInitialHeartbeat:
+		a.nodes.seen(request.UID)
+		a.publishRequest(&redisStreamRequest{Type: requestTypeHeartbeat})
+	case requestTypeHeartbeat:
+		a.nodes.seen(request.UID)
+	case requestTypeAdapterClose:
+		a.nodes.remove(request.UID)
+	case requestTypeBroadcast:
+		data, err := decodeBroadcastAckData(request.Packet)
+		if err != nil {
+			a.onError(fmt.Errorf("adapter: malformed broadcast: %w", err))
+			return
+		}
+		header := &parser.PacketHeader{Type: parser.PacketTypeEvent, Namespace: a.nsp}
+		timeout := request.Opts.timeout(a.opts.RequestsTimeout)
+		a.broadcastWithAck(header, data, opts, timeout, func(clientCount int) {
+			a.publishResponse(&redisStreamResponse{
+				RequestID:   request.RequestID,
+				Type:        requestTypeBroadcastClientCount,
+				ClientCount: clientCount,
+			})
+		}, func(response any) {
+			a.publishResponse(&redisStreamResponse{
+				RequestID: request.RequestID,
+				Type:      requestTypeBroadcastAck,
+				Data:      response,
+			})
+		})
+	case requestTypeRemoteJoin:
+		a.addSockets(opts, request.Rooms...)
+	case requestTypeRemoteLeave:
+		a.delSockets(opts, request.Rooms...)
+	case requestTypeRemoteDisconnect:
+		a.disconnectSockets(opts, request.Close)
+	case requestTypeRemoteFetch:
+		sockets := a.fetchSockets(opts)
+		details := make([]SocketDetails, len(sockets))
+		for i, socket := range sockets {
+			details[i] = newSocketDetails(a, socket)
+		}
+		a.publishResponse(&redisStreamResponse{
+			RequestID: request.RequestID,
+			Type:      request.Type,
+			Sockets:   details,
+		})
+	case requestTypeServerSideEmit:
+		if len(request.Data) == 0 {
+			return
+		}
+		eventName, ok := request.Data[0].(string)
+		if !ok {
+			a.onError(fmt.Errorf("adapter: malformed server side emit: event name is not a string"))
+			return
+		}
+		var ack func(response any)
+		if request.RequestID != "" {
+			ack = func(response any) {
+				a.publishResponse(&redisStreamResponse{
+					RequestID: request.RequestID,
+					Type:      request.Type,
+					Data:      response,
+				})
+			}
+		}
+		a.sockets.OnServerSideEmit(eventName, request.Data[1:], ack)
+	}
+}
+
+func (a *RedisStreamAdapter) onResponse(data string) {
+	var response redisStreamResponse
+	err := json.Unmarshal([]byte(data), &response)
+	if err != nil {
+		a.onError(fmt.Errorf("adapter: malformed response: %w", err))
+		return
+	}
+	if response.UID == a.uid || response.Nsp != a.nsp {
+		return
+	}
+	if a.ackRequests.onResponse(response.Type, response.RequestID, response.ClientCount, response.Data) {
+		return
+	}
+
+	r, ok := a.requests.get(response.RequestID)
+	if !ok {
+		return
+	}
+	switch response.Type {
+	case requestTypeRemoteFetch:
+		r.addResponse(response.Sockets)
+	case requestTypeServerSideEmit:
+		r.addResponse(response.Data)
+	}
+}
+
+func (a *RedisStreamAdapter) publishRequest(request *redisStreamRequest) error {
+	request.UID = a.uid
+	request.Nsp = a.nsp
+	data, err := json.Marshal(request)
+	if err != nil {
+		a.onError(err)
+		return err
+	}
+	return a.xAdd(map[string]any{"request": data})
+}
+
+func (a *RedisStreamAdapter) publishResponse(response *redisStreamResponse) error {
+	response.UID = a.uid
+	response.Nsp = a.nsp
+	data, err := json.Marshal(response)
+	if err != nil {
+		a.onError(err)
+		return err
+	}
+	return a.xAdd(map[string]any{"response": data})
+}
+
+func isRedisStreamRequestOrResponse(message redis.XMessage) bool {
+	_, isRequest := message.Values["request"]
+	_, isResponse := message.Values["response"]
+	return isRequest || isResponse
+}
+
+// Adds a request or a response to the control stream.
+func (a *RedisStreamAdapter) xAdd(values map[string]any) error {
+	err := a.redisClient.XAdd(a.ctx, &redis.XAddArgs{
+		Stream: a.opts.ControlStreamName,
+		Values: values,
+		MaxLen: a.opts.MaxLength,
+	}).Err()
+	// Errors caused by closing the adapter are not reported.
+	if err != nil && a.ctx.Err() == nil {
+		a.onError(fmt.Errorf("adapter: adding message to control stream: %w", err))
+	}
+	return err
+}
+
 func (a *RedisStreamAdapter) apply(opts *BroadcastOptions, callback func(socket Socket)) {
 	a.mu.Lock()
 
@@ -489,12 +973,45 @@ func (a *RedisStreamAdapter) computeExceptSids(exceptRooms mapset.Set[Room]) (ex
 	return
 }
 
-func (a *RedisStreamAdapter) ServerSideEmit(header *parser.PacketHeader, v []any) {}
+func (a *RedisStreamAdapter) ServerSideEmit(header *parser.PacketHeader, v []any) {
+	a.publishRequest(&redisStreamRequest{
+		Type: requestTypeServerSideEmit,
+		Data: v,
+	})
+}
+
+func (a *RedisStreamAdapter) ServerSideEmitWithAck(header *parser.PacketHeader, v []any, timeout time.Duration, ack ServerSideEmitAckFunc) {
+	expected := a.nodes.count()
+	if expected == 0 {
+		go ack(nil, nil)
+		return
+	}
+
+	requestID := newUID()
+	r := a.requests.add(requestID, requestTypeServerSideEmit, expected)
+
+	err := a.publishRequest(&redisStreamRequest{
+		RequestID: requestID,
+		Type:      requestTypeServerSideEmit,
+		Data:      v,
+	})
+	if err != nil {
+		a.requests.remove(requestID)
+		go ack(err, nil)
+		return
+	}
+
+	go func() {
+		defer a.requests.remove(requestID)
+		responses, err := r.wait(timeout)
+		ack(err, responses)
+	}()
+}
 
 func (a *RedisStreamAdapter) PersistSession(session *SessionToPersist) {
 	err := a.redisClient.Set(a.ctx, fmt.Sprintf("%s%s", DEFAULT_SESSION_KEY_PREFIX, session.PID), session, a.maxDisconnectDuration).Err()
 	if err != nil {
-		log.Fatalf("Error persisting session: %v", err)
+		a.onError(fmt.Errorf("adapter: persisting session: %w", err))
 	}
 }
 
@@ -513,16 +1030,23 @@ func (a *RedisStreamAdapter) RestoreSession(pid PrivateSessionID, offset string)
 
 	messages, err := a.redisClient.XRange(a.ctx, a.opts.StreamName, messageID, "+").Result()
 	if err != nil {
-		log.Fatalf("Error reading from stream: %v", err)
+		a.onError(fmt.Errorf("adapter: reading from stream: %w", err))
+		return nil, false
 	}
 
 	var missedPackets []*PersistedPacket
 	for _, message := range messages {
 		offset = message.ID
+		// The requests and the responses are sent to the control stream,
+		// but the nodes of the older versions added them to this stream.
+		if isRedisStreamRequestOrResponse(message) {
+			continue
+		}
 		msg := &RedisStreamMessage{}
 		err = msg.Parse(message)
 		if err != nil {
-			log.Fatalf("Error parsing message: %v", err)
+			a.onError(err)
+			continue
 		}
 
 		if shouldIncludePacket(session.Rooms, msg.Opts) {
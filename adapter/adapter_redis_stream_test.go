@@ -0,0 +1,274 @@
+package adapter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hhuuson97/socket.io-go/internal/sync"
+	"github.com/hhuuson97/socket.io-go/parser"
+	jsonparser "github.com/hhuuson97/socket.io-go/parser/json"
+	"github.com/hhuuson97/socket.io-go/parser/json/serializer/stdjson"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisStreamAdapter(t *testing.T) {
+	testClusterAdapter(t, func(t *testing.T, n int, opts *clusterTestOptions) []*clusterTestNode {
+		client := newTestRedisStreamClient(t)
+		nodes := make([]*clusterTestNode, n)
+		for i := range nodes {
+			a, store := newTestRedisStreamAdapterWithOptions(t, client, &RedisStreamsAdapterOptions{
+				RequestsTimeout: opts.requestsTimeout,
+				ErrorHandler:    opts.errorHandler,
+			})
+			nodes[i] = &clusterTestNode{adapter: a, store: store}
+		}
+		return nodes
+	})
+
+	t.Run("should broadcast once to the sockets of every node", func(t *testing.T) {
+		client := newTestRedisStreamClient(t)
+		a1, store1 := newTestRedisStreamAdapter(t, client)
+		a2, store2 := newTestRedisStreamAdapter(t, client)
+
+		store1.Set(NewTestSocket("s1"))
+		a1.AddAll("s1", []Room{"s1"})
+		store2.Set(NewTestSocket("s2"))
+		a2.AddAll("s2", []Room{"s2"})
+
+		var (
+			mu  sync.Mutex
+			ids []SocketID
+		)
+		sendBuffers := func(sid SocketID, buffers [][]byte) (ok bool) {
+			mu.Lock()
+			ids = append(ids, sid)
+			mu.Unlock()
+			return true
+		}
+		store1.SetSendBuffers(sendBuffers)
+		store2.SetSendBuffers(sendBuffers)
+
+		header := &parser.PacketHeader{Type: parser.PacketTypeEvent, Namespace: "/"}
+		a1.Broadcast(header, []any{"hello"}, NewBroadcastOptions())
+
+		time.Sleep(200 * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		require.ElementsMatch(t, []SocketID{"s1", "s2"}, ids)
+	})
+
+	t.Run("should not add local broadcasts to the stream", func(t *testing.T) {
+		client := newTestRedisStreamClient(t)
+		a1, store1 := newTestRedisStreamAdapter(t, client)
+		a2, store2 := newTestRedisStreamAdapter(t, client)
+
+		store1.Set(NewTestSocket("s1"))
+		a1.AddAll("s1", []Room{"s1"})
+		store2.Set(NewTestSocket("s2"))
+		a2.AddAll("s2", []Room{"s2"})
+
+		received := make(chan string, 1)
+		store1.SetSendBuffers(func(sid SocketID, buffers [][]byte) (ok bool) {
+			received <- string(buffers[0])
+			return true
+		})
+		store2.SetSendBuffers(func(sid SocketID, buffers [][]byte) (ok bool) {
+			t.Error("local broadcast was received by another node")
+			return true
+		})
+
+		ctx := context.Background()
+		length, err := client.XLen(ctx, DEFAULT_STREAM_NAME).Result()
+		require.NoError(t, err)
+
+		header := &parser.PacketHeader{Type: parser.PacketTypeEvent, Namespace: "/"}
+		opts := NewBroadcastOptions()
+		opts.Flags.Local = true
+		a1.Broadcast(header, []any{"hello"}, opts)
+
+		select {
+		case buf := <-received:
+			require.Equal(t, `2["hello"]`, buf)
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout exceeded")
+		}
+		newLength, err := client.XLen(ctx, DEFAULT_STREAM_NAME).Result()
+		require.NoError(t, err)
+		require.Equal(t, length, newLength)
+		time.Sleep(200 * time.Millisecond)
+	})
+
+	t.Run("should keep the control messages out of the stream of the packets", func(t *testing.T) {
+		client := newTestRedisStreamClient(t)
+		a1, _ := newTestRedisStreamAdapter(t, client)
+		a2, store2 := newTestRedisStreamAdapter(t, client)
+
+		store2.Set(NewTestSocket("s1"))
+		a2.AddAll("s1", []Room{"s1"})
+
+		require.Eventually(t, func() bool {
+			return a1.ServerCount() == 2
+		}, 3*time.Second, 10*time.Millisecond)
+		require.Len(t, a1.FetchSockets(NewBroadcastOptions()), 1)
+
+		ctx := context.Background()
+		length, err := client.XLen(ctx, DEFAULT_STREAM_NAME).Result()
+		require.NoError(t, err)
+		require.Zero(t, length)
+		length, err = client.XLen(ctx, DEFAULT_STREAM_NAME+":control").Result()
+		require.NoError(t, err)
+		require.NotZero(t, length)
+	})
+
+	t.Run("should emit the room events", func(t *testing.T) {
+		a, store := newTestRedisStreamAdapter(t, newTestRedisStreamClient(t))
+
+		var events []string
+		store.SetOnRoomEvent(func(eventName string, room Room, sid SocketID) {
+			events = append(events, eventName+" "+string(room)+" "+string(sid))
+		})
+
+		a.AddAll("s1", []Room{"r1"})
+		a.DeleteAll("s1")
+		require.Equal(t, []string{
+			"create-room r1 ",
+			"join-room r1 s1",
+			"leave-room r1 s1",
+			"delete-room r1 ",
+		}, events)
+	})
+
+	t.Run("should return the number of the live nodes", func(t *testing.T) {
+		client := newTestRedisStreamClient(t)
+		newAdapter := func() *RedisStreamAdapter {
+			a, _ := newTestRedisStreamAdapterWithOptions(t, client, &RedisStreamsAdapterOptions{
+				ErrorHandler:      func(err error) { t.Error(err) },
+				HeartbeatInterval: 50 * time.Millisecond,
+				HeartbeatTimeout:  200 * time.Millisecond,
+			})
+			return a
+		}
+		a1 := newAdapter()
+		a2 := newAdapter()
+		a3 := newAdapter()
+
+		serverCount := func(n int) func() bool {
+			return func() bool {
+				return a1.ServerCount() == n
+			}
+		}
+		require.Eventually(t, serverCount(3), 3*time.Second, 10*time.Millisecond)
+		require.Equal(t, 3, a2.ServerCount())
+
+		// A closed node is removed right away.
+		a2.Close()
+		require.Eventually(t, serverCount(2), 3*time.Second, 10*time.Millisecond)
+
+		// A node that stops sending heartbeats is removed once they expire.
+		a3.cancel()
+		require.Eventually(t, serverCount(1), 3*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("should report malformed entries and keep reading the stream", func(t *testing.T) {
+		client := newTestRedisStreamClient(t)
+		errs := make(chan error, 1)
+		a, store := newTestRedisStreamAdapterWithOptions(t, client, &RedisStreamsAdapterOptions{
+			ErrorHandler: func(err error) { errs <- err },
+		})
+
+		socket := NewTestSocket("s1")
+		store.Set(socket)
+		a.AddAll("s1", []Room{"s1"})
+
+		err := client.XAdd(context.Background(), &redis.XAddArgs{
+			Stream: DEFAULT_STREAM_NAME,
+			Values: map[string]any{"header": "not json"},
+		}).Err()
+		require.NoError(t, err)
+
+		select {
+		case err := <-errs:
+			require.Error(t, err)
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout exceeded")
+		}
+
+		opts := NewBroadcastOptions()
+		opts.Rooms.Add("s1")
+		a.DisconnectSockets(opts, false)
+		require.Eventually(t, func() bool {
+			return !socket.IsConnected()
+		}, 3*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("should retry reading the stream after a failure", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { client.Close() })
+
+		errs := make(chan error, 1)
+		newAdapter := func() (*RedisStreamAdapter, *TestSocketStore) {
+			return newTestRedisStreamAdapterWithOptions(t, client, &RedisStreamsAdapterOptions{
+				ErrorHandler: func(err error) {
+					select {
+					case errs <- err:
+					default:
+					}
+				},
+				ReadRetryDelay: 10 * time.Millisecond,
+			})
+		}
+		a1, _ := newAdapter()
+		a2, store2 := newAdapter()
+
+		socket := NewTestSocket("s1")
+		store2.Set(socket)
+		a2.AddAll("s1", []Room{"s1"})
+
+		// Make the next reads fail.
+		mr.SetError("ERR unavailable")
+		select {
+		case err := <-errs:
+			require.Error(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout exceeded")
+		}
+		mr.SetError("")
+
+		opts := NewBroadcastOptions()
+		opts.Rooms.Add("s1")
+		require.Eventually(t, func() bool {
+			a1.AddSockets(opts, "r1")
+			return len(socket.CurrentRooms()) == 2
+		}, 5*time.Second, 100*time.Millisecond)
+	})
+}
+
+func newTestRedisStreamClient(t *testing.T) *redis.Client {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func newTestRedisStreamAdapter(t *testing.T, client *redis.Client) (*RedisStreamAdapter, *TestSocketStore) {
+	return newTestRedisStreamAdapterWithOptions(t, client, &RedisStreamsAdapterOptions{
+		ErrorHandler: func(err error) { t.Error(err) },
+	})
+}
+
+func newTestRedisStreamAdapterWithOptions(t *testing.T, client *redis.Client, opts *RedisStreamsAdapterOptions) (*RedisStreamAdapter, *TestSocketStore) {
+	store := NewTestSocketStore()
+	if opts.RequestsTimeout == 0 {
+		opts.RequestsTimeout = 500 * time.Millisecond
+	}
+	creator := NewRedisStreamAdapterCreator(client, opts)
+	a := creator(store, jsonparser.NewCreator(0, stdjson.New())).(*RedisStreamAdapter)
+	t.Cleanup(a.Close)
+	// Wait for the adapter to start reading the stream.
+	time.Sleep(50 * time.Millisecond)
+	return a, store
+}
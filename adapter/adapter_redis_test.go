@@ -0,0 +1,54 @@
+package adapter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	jsonparser "github.com/hhuuson97/socket.io-go/parser/json"
+	"github.com/hhuuson97/socket.io-go/parser/json/serializer/stdjson"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisAdapter(t *testing.T) {
+	testClusterAdapter(t, func(t *testing.T, n int, opts *clusterTestOptions) []*clusterTestNode {
+		mr := miniredis.RunT(t)
+		nodes := make([]*clusterTestNode, n)
+		for i := range nodes {
+			a, store := newTestRedisAdapterWithOptions(t, mr, &RedisAdapterOptions{
+				RequestsTimeout: opts.requestsTimeout,
+				ErrorHandler:    opts.errorHandler,
+			})
+			nodes[i] = &clusterTestNode{adapter: a, store: store}
+		}
+		return nodes
+	})
+
+	t.Run("should return the number of nodes", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		a1, _ := newTestRedisAdapter(t, mr)
+		require.Equal(t, 1, a1.ServerCount())
+		newTestRedisAdapter(t, mr)
+		require.Equal(t, 2, a1.ServerCount())
+	})
+}
+
+func newTestRedisAdapter(t *testing.T, mr *miniredis.Miniredis) (*redisAdapter, *TestSocketStore) {
+	return newTestRedisAdapterWithOptions(t, mr, &RedisAdapterOptions{
+		RequestsTimeout: 2 * time.Second,
+		ErrorHandler:    func(err error) { t.Error(err) },
+	})
+}
+
+func newTestRedisAdapterWithOptions(t *testing.T, mr *miniredis.Miniredis, opts *RedisAdapterOptions) (*redisAdapter, *TestSocketStore) {
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	store := NewTestSocketStore()
+	creator := NewRedisAdapterCreator(client, opts)
+	a := creator(store, jsonparser.NewCreator(0, stdjson.New())).(*redisAdapter)
+	t.Cleanup(func() {
+		a.Close()
+		client.Close()
+	})
+	return a, store
+}
@@ -1,6 +1,7 @@
 package adapter
 
 import (
+	"context"
 	"time"
 
 	"github.com/hhuuson97/socket.io-go/internal/sync"
@@ -17,6 +18,9 @@ type (
 		yeaster               *yeast.Yeaster
 		cleanerDuration       time.Duration
 
+		ctx    context.Context
+		cancel context.CancelFunc
+
 		sessions map[PrivateSessionID]*sessionWithTimestamp
 		packets  []*PersistedPacket
 		mu       sync.Mutex
@@ -50,8 +54,11 @@ func newSessionAwareAdapter(
 	maxDisconnectionDuration,
 	cleanerDuration time.Duration,
 ) *sessionAwareAdapter {
+	ctx, cancel := context.WithCancel(context.Background())
 	a := &sessionAwareAdapter{
 		inMemoryAdapter:       inMemoryAdapter,
+		ctx:                   ctx,
+		cancel:                cancel,
 		maxDisconnectDuration: maxDisconnectionDuration,
 		sessions:              make(map[PrivateSessionID]*sessionWithTimestamp),
 		yeaster:               yeast.New(),
@@ -63,9 +70,21 @@ func newSessionAwareAdapter(
 	return a
 }
 
+// Stops the cleaner.
+func (a *sessionAwareAdapter) Close() {
+	a.cancel()
+	a.inMemoryAdapter.Close()
+}
+
 func (a *sessionAwareAdapter) cleaner() {
+	ticker := time.NewTicker(a.cleanerDuration)
+	defer ticker.Stop()
 	for {
-		time.Sleep(a.cleanerDuration)
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+		}
 
 		a.mu.Lock()
 		for sessionID, session := range a.sessions {
@@ -224,6 +224,23 @@ func TestCleaner(t *testing.T) {
 	require.False(t, ok)
 }
 
+func TestCleanerStopsOnClose(t *testing.T) {
+	adapter := newTestSessionAwareAdapter(50*time.Millisecond, 20*time.Millisecond)
+	adapter.Close()
+	// Wait for the cleaner to notice.
+	time.Sleep(40 * time.Millisecond)
+
+	adapter.PersistSession(&SessionToPersist{
+		SID: "s1",
+		PID: "p1",
+	})
+	time.Sleep(200 * time.Millisecond)
+
+	adapter.mu.Lock()
+	defer adapter.mu.Unlock()
+	require.Len(t, adapter.sessions, 1)
+}
+
 func TestSessionExpiration(t *testing.T) {
 	adapter := newTestSessionAwareAdapter(1*time.Millisecond, 0)
 	adapter.AddAll("s1", []Room{"r1"})
@@ -0,0 +1,871 @@
+package adapter
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/hhuuson97/socket.io-go/internal/sync"
+
+	"github.com/hhuuson97/socket.io-go/parser"
+)
+
+const (
+	DefaultTCPDiscoveryInterval = 5 * time.Second
+	DefaultTCPRequestsTimeout   = 5 * time.Second
+	DefaultTCPDialTimeout       = 5 * time.Second
+
+	// Maximum size of a message exchanged between the nodes.
+	tcpMaxMessageSize = 64 << 20
+
+	// Size of the challenges of the handshake (see TCPNodeOptions.Secret).
+	tcpChallengeSize = 32
+)
+
+var errTCPAuthentication = errors.New("adapter: authentication of the node failed")
+
+type TCPNodeOptions struct {
+	// The address to listen on for the connections of the other nodes.
+	//
+	// Example: "127.0.0.1:7000"
+	ListenAddr string
+
+	// The address the other nodes use to connect to this node. It must be
+	// the same as the address given to the other nodes (in Peers or by Discover).
+	//
+	// Default: the address of the listener.
+	AdvertiseAddr string
+
+	// Addresses of the other nodes of the cluster.
+	// It can also contain the address of this node, it is skipped.
+	//
+	// A node only connects to the addresses of Peers and Discover, so every node
+	// must be given the addresses of the other nodes (and not only some of them).
+	Peers []string
+
+	// Optional function that returns the addresses of the other nodes (such as from a DNS lookup or a service registry).
+	// It is called periodically and the returned addresses are used alongside Peers.
+	Discover func(ctx context.Context) ([]string, error)
+
+	// Interval between the attempts to find and connect to the other nodes.
+	//
+	// Default: 5 seconds
+	DiscoveryInterval time.Duration
+
+	// Duration to wait for the responses of the other nodes
+	// to a request (such as FetchSockets).
+	//
+	// Default: 5 seconds
+	RequestsTimeout time.Duration
+
+	// Timeout of the connection to another node, including the handshake.
+	//
+	// Default: 5 seconds
+	DialTimeout time.Duration
+
+	// Shared secret of the nodes of the cluster. If it is set, the nodes prove
+	// to each other that they know the secret (with HMAC-SHA256) upon connection,
+	// and the connections of the nodes that don't know it are closed.
+	//
+	// Without Secret or TLSConfig, any host that can reach ListenAddr can send
+	// messages to the cluster. Only leave both unset on a trusted network.
+	Secret []byte
+
+	// If it is set, the connections between the nodes use TLS. It is used
+	// both to accept and to make connections, so it should have Certificates
+	// (or GetCertificate), and RootCAs and ClientCAs to verify the other nodes.
+	//
+	// Set ClientAuth to tls.RequireAndVerifyClientCert to authenticate the other nodes (mutual TLS).
+	TLSConfig *tls.Config
+
+	// Called when an error occurs (such as when a node is unreachable or a malformed message is received).
+	//
+	// Default: errors are ignored.
+	ErrorHandler func(err error)
+}
+
+// A TCPNode is a node of a broker-less cluster. Every node
+// connects directly to the other nodes of the cluster over TCP.
+//
+// A TCPNode is shared between the namespaces, use NewTCPAdapterCreator to create the adapters.
+type TCPNode struct {
+	opts TCPNodeOptions
+	uid  string
+	addr string
+
+	listener net.Listener
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// Connections to the other nodes, by address. Messages are sent through them.
+	peers map[string]*tcpPeer
+	// Incoming connections. Messages are received through them.
+	conns    map[net.Conn]struct{}
+	adapters map[string]*tcpAdapter
+	// Addresses of Peers and of the last call to Discover.
+	// These are the only addresses this node connects to.
+	known map[string]struct{}
+	mu    sync.Mutex
+
+	wg sync.WaitGroup
+}
+
+type tcpPeer struct {
+	addr string
+	conn net.Conn
+	mu   sync.Mutex
+}
+
+// Starts listening for the connections of the other nodes and connects to them.
+func NewTCPNode(opts *TCPNodeOptions) (*TCPNode, error) {
+	var options TCPNodeOptions
+	if opts != nil {
+		options = *opts
+	}
+	if options.DiscoveryInterval == 0 {
+		options.DiscoveryInterval = DefaultTCPDiscoveryInterval
+	}
+	if options.RequestsTimeout == 0 {
+		options.RequestsTimeout = DefaultTCPRequestsTimeout
+	}
+	if options.DialTimeout == 0 {
+		options.DialTimeout = DefaultTCPDialTimeout
+	}
+	if options.ErrorHandler == nil {
+		options.ErrorHandler = func(err error) {}
+	}
+
+	listener, err := net.Listen("tcp", options.ListenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("adapter: %w", err)
+	}
+	if options.TLSConfig != nil {
+		listener = tls.NewListener(listener, options.TLSConfig)
+	}
+
+	addr := options.AdvertiseAddr
+	if addr == "" {
+		addr = listener.Addr().String()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &TCPNode{
+		opts:     options,
+		uid:      newUID(),
+		addr:     addr,
+		listener: listener,
+		ctx:      ctx,
+		cancel:   cancel,
+		peers:    make(map[string]*tcpPeer),
+		conns:    make(map[net.Conn]struct{}),
+		adapters: make(map[string]*tcpAdapter),
+		known:    make(map[string]struct{}),
+	}
+	for _, addr := range options.Peers {
+		n.known[addr] = struct{}{}
+	}
+
+	n.wg.Add(2)
+	go n.accept()
+	go n.discover()
+	return n, nil
+}
+
+// The address the other nodes use to connect to this node.
+func (n *TCPNode) Addr() string { return n.addr }
+
+// Closes the listener and the connections to the other nodes.
+func (n *TCPNode) Close() error {
+	n.cancel()
+	err := n.listener.Close()
+
+	n.mu.Lock()
+	for _, peer := range n.peers {
+		peer.conn.Close()
+	}
+	for conn := range n.conns {
+		conn.Close()
+	}
+	n.mu.Unlock()
+
+	n.wg.Wait()
+	return err
+}
+
+func (n *TCPNode) onError(err error) {
+	if n.ctx.Err() != nil {
+		return
+	}
+	n.opts.ErrorHandler(err)
+}
+
+// Returns the number of the other nodes this node is connected to.
+func (n *TCPNode) peerCount() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.peers)
+}
+
+func (n *TCPNode) accept() {
+	defer n.wg.Done()
+	for {
+		conn, err := n.listener.Accept()
+		if err != nil {
+			if n.ctx.Err() == nil {
+				n.onError(fmt.Errorf("adapter: %w", err))
+			}
+			return
+		}
+
+		n.mu.Lock()
+		n.conns[conn] = struct{}{}
+		n.wg.Add(1)
+		n.mu.Unlock()
+
+		go n.receive(conn)
+	}
+}
+
+func (n *TCPNode) receive(conn net.Conn) {
+	defer n.wg.Done()
+	defer func() {
+		conn.Close()
+		n.mu.Lock()
+		delete(n.conns, conn)
+		n.mu.Unlock()
+	}()
+
+	err := n.handshake(conn, false)
+	if err != nil {
+		if n.ctx.Err() == nil {
+			n.onError(fmt.Errorf("adapter: accepting %s: %w", conn.RemoteAddr(), err))
+		}
+		return
+	}
+
+	r := bufio.NewReader(conn)
+	for {
+		var msg clusterMessage
+		err := readTCPMessage(r, &msg)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				n.onError(fmt.Errorf("adapter: receiving from %s: %w", conn.RemoteAddr(), err))
+			}
+			return
+		}
+		n.onMessage(&msg)
+	}
+}
+
+func (n *TCPNode) onMessage(msg *clusterMessage) {
+	if msg.UID == n.uid {
+		return
+	}
+
+	// The sender has just started, connect to it without waiting for the next discovery.
+	// Only the addresses given by Peers and Discover are connected to.
+	if msg.Type == requestTypeInitialHeartbeat {
+		if n.isKnown(msg.Addr) {
+			go n.connect(msg.Addr)
+		}
+		return
+	}
+
+	n.mu.Lock()
+	a, ok := n.adapters[msg.Nsp]
+	n.mu.Unlock()
+	if ok {
+		a.onMessage(msg)
+	}
+}
+
+// Connects to the known nodes periodically, until the node is closed.
+func (n *TCPNode) discover() {
+	defer n.wg.Done()
+	ticker := time.NewTicker(n.opts.DiscoveryInterval)
+	defer ticker.Stop()
+	for {
+		addrs := n.opts.Peers
+		if n.opts.Discover != nil {
+			discovered, err := n.opts.Discover(n.ctx)
+			if err != nil {
+				n.onError(fmt.Errorf("adapter: discovering nodes: %w", err))
+			} else {
+				addrs = append(addrs[:len(addrs):len(addrs)], discovered...)
+				n.setKnown(addrs)
+			}
+		}
+		for _, addr := range addrs {
+			go n.connect(addr)
+		}
+
+		select {
+		case <-n.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (n *TCPNode) isKnown(addr string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	_, ok := n.known[addr]
+	return ok
+}
+
+func (n *TCPNode) setKnown(addrs []string) {
+	known := make(map[string]struct{}, len(addrs))
+	for _, addr := range addrs {
+		known[addr] = struct{}{}
+	}
+	n.mu.Lock()
+	n.known = known
+	n.mu.Unlock()
+}
+
+// Connects to the node with the given address if this node is not already connected to it.
+func (n *TCPNode) connect(addr string) (*tcpPeer, error) {
+	if addr == n.addr {
+		return nil, fmt.Errorf("adapter: cannot connect to self")
+	}
+
+	n.mu.Lock()
+	peer, ok := n.peers[addr]
+	n.mu.Unlock()
+	if ok {
+		return peer, nil
+	}
+
+	conn, err := n.dial(addr)
+	if err != nil {
+		n.onError(fmt.Errorf("adapter: connecting to %s: %w", addr, err))
+		return nil, err
+	}
+
+	n.mu.Lock()
+	if n.ctx.Err() != nil {
+		n.mu.Unlock()
+		conn.Close()
+		return nil, n.ctx.Err()
+	}
+	// Another connection might have been established in the meantime.
+	if peer, ok := n.peers[addr]; ok {
+		n.mu.Unlock()
+		conn.Close()
+		return peer, nil
+	}
+	peer = &tcpPeer{addr: addr, conn: conn}
+	n.peers[addr] = peer
+	n.wg.Add(1)
+	n.mu.Unlock()
+
+	// Nothing is sent by the other node through this connection.
+	// Reading is only done to find out when the connection is closed.
+	go func() {
+		defer n.wg.Done()
+		io.Copy(io.Discard, conn)
+		n.removePeer(peer)
+	}()
+
+	err = n.sendTo(peer, &clusterMessage{Type: requestTypeInitialHeartbeat})
+	if err != nil {
+		return nil, err
+	}
+	return peer, nil
+}
+
+func (n *TCPNode) dial(addr string) (net.Conn, error) {
+	netDialer := &net.Dialer{Timeout: n.opts.DialTimeout}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if n.opts.TLSConfig != nil {
+		dialer := tls.Dialer{NetDialer: netDialer, Config: n.opts.TLSConfig}
+		conn, err = dialer.DialContext(n.ctx, "tcp", addr)
+	} else {
+		conn, err = netDialer.DialContext(n.ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = n.handshake(conn, true)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Authenticates the other end of the connection with the shared secret, if there is one.
+//
+// The accepting node sends a challenge. The dialing node answers with the HMAC of the challenge
+// and sends its own challenge, which the accepting node answers in the same way.
+// The role is part of the HMAC, so that an answer cannot be sent back to the node that computed it.
+func (n *TCPNode) handshake(conn net.Conn, dialing bool) error {
+	conn.SetDeadline(time.Now().Add(n.opts.DialTimeout))
+	defer conn.SetDeadline(time.Time{})
+
+	if c, ok := conn.(*tls.Conn); ok {
+		err := c.HandshakeContext(n.ctx)
+		if err != nil {
+			return err
+		}
+	}
+	if len(n.opts.Secret) == 0 {
+		return nil
+	}
+
+	sum := func(role string, challenges ...[]byte) []byte {
+		mac := hmac.New(sha256.New, n.opts.Secret)
+		mac.Write([]byte(role))
+		for _, c := range challenges {
+			mac.Write(c)
+		}
+		return mac.Sum(nil)
+	}
+	newChallenge := func() ([]byte, error) {
+		challenge := make([]byte, tcpChallengeSize)
+		_, err := rand.Read(challenge)
+		return challenge, err
+	}
+
+	if dialing {
+		challenge := make([]byte, tcpChallengeSize)
+		_, err := io.ReadFull(conn, challenge)
+		if err != nil {
+			return err
+		}
+		own, err := newChallenge()
+		if err != nil {
+			return err
+		}
+		_, err = conn.Write(append(sum("dial", challenge, own), own...))
+		if err != nil {
+			return err
+		}
+		answer := make([]byte, sha256.Size)
+		_, err = io.ReadFull(conn, answer)
+		if err != nil {
+			return err
+		}
+		if !hmac.Equal(answer, sum("accept", own, challenge)) {
+			return errTCPAuthentication
+		}
+		return nil
+	}
+
+	challenge, err := newChallenge()
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(challenge)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, sha256.Size+tcpChallengeSize)
+	_, err = io.ReadFull(conn, buf)
+	if err != nil {
+		return err
+	}
+	answer, other := buf[:sha256.Size], buf[sha256.Size:]
+	if !hmac.Equal(answer, sum("dial", challenge, other)) {
+		return errTCPAuthentication
+	}
+	_, err = conn.Write(sum("accept", other, challenge))
+	return err
+}
+
+func (n *TCPNode) removePeer(peer *tcpPeer) {
+	peer.conn.Close()
+	n.mu.Lock()
+	if n.peers[peer.addr] == peer {
+		delete(n.peers, peer.addr)
+	}
+	n.mu.Unlock()
+}
+
+func (n *TCPNode) sendTo(peer *tcpPeer, msg *clusterMessage) error {
+	msg.UID = n.uid
+	msg.Addr = n.addr
+
+	peer.mu.Lock()
+	peer.conn.SetWriteDeadline(time.Now().Add(n.opts.DialTimeout))
+	err := writeTCPMessage(peer.conn, msg)
+	peer.mu.Unlock()
+	if err != nil {
+		n.onError(fmt.Errorf("adapter: sending to %s: %w", peer.addr, err))
+		n.removePeer(peer)
+	}
+	return err
+}
+
+// Sends the message to the node with the given address, if this node is connected to it.
+//
+// This is used to respond to the requests. The address of the requester is taken from
+// the request, so this node never connects to it (see TCPNode.known).
+func (n *TCPNode) send(addr string, msg *clusterMessage) error {
+	n.mu.Lock()
+	peer, ok := n.peers[addr]
+	n.mu.Unlock()
+	if !ok {
+		err := fmt.Errorf("adapter: sending to %s: not connected", addr)
+		n.onError(err)
+		return err
+	}
+	return n.sendTo(peer, msg)
+}
+
+// Sends the message to every connected node and returns the number of the nodes it was sent to.
+func (n *TCPNode) sendAll(msg *clusterMessage) int {
+	n.mu.Lock()
+	peers := make([]*tcpPeer, 0, len(n.peers))
+	for _, peer := range n.peers {
+		peers = append(peers, peer)
+	}
+	n.mu.Unlock()
+
+	sent := 0
+	for _, peer := range peers {
+		m := *msg
+		if n.sendTo(peer, &m) == nil {
+			sent++
+		}
+	}
+	return sent
+}
+
+// Messages are JSON encoded and prefixed by their length.
+func writeTCPMessage(w io.Writer, msg *clusterMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(buf, uint32(len(data)))
+	copy(buf[4:], data)
+	_, err = w.Write(buf)
+	return err
+}
+
+func readTCPMessage(r io.Reader, msg *clusterMessage) error {
+	var size [4]byte
+	_, err := io.ReadFull(r, size[:])
+	if err != nil {
+		return err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > tcpMaxMessageSize {
+		return fmt.Errorf("message too large: %d bytes", n)
+	}
+	data := make([]byte, n)
+	_, err = io.ReadFull(r, data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, msg)
+}
+
+// This adapter forwards broadcasts, room operations and server side emits
+// to the other nodes of a TCPNode cluster, without a message broker.
+type tcpAdapter struct {
+	*inMemoryAdapter
+
+	node        *TCPNode
+	nsp         string
+	requests    *pendingRequests
+	ackRequests *broadcastAckRequests
+}
+
+func NewTCPAdapterCreator(node *TCPNode) Creator {
+	creator := NewInMemoryAdapterCreator()
+	return func(socketStore SocketStore, parserCreator parser.Creator) Adapter {
+		a := &tcpAdapter{
+			inMemoryAdapter: creator(socketStore, parserCreator).(*inMemoryAdapter),
+			node:            node,
+			nsp:             socketStore.Namespace(),
+			requests:        newPendingRequests(),
+			ackRequests:     newBroadcastAckRequests(),
+		}
+		node.mu.Lock()
+		node.adapters[a.nsp] = a
+		node.mu.Unlock()
+		return a
+	}
+}
+
+// Returns the number of the nodes of the cluster (including this one) that this node is connected to.
+func (a *tcpAdapter) ServerCount() int {
+	return a.node.peerCount() + 1
+}
+
+// Stops receiving the messages of this namespace. The node is not closed.
+func (a *tcpAdapter) Close() {
+	a.node.mu.Lock()
+	if a.node.adapters[a.nsp] == a {
+		delete(a.node.adapters, a.nsp)
+	}
+	a.node.mu.Unlock()
+}
+
+func (a *tcpAdapter) Broadcast(header *parser.PacketHeader, v []any, opts *BroadcastOptions) {
+	buffers, err := a.parser.Encode(header, &v)
+	if err != nil {
+		panic(fmt.Errorf("sio: %w", err))
+	}
+
+	if !opts.Flags.Local {
+		a.node.sendAll(&clusterMessage{
+			Nsp:     a.nsp,
+			Type:    requestTypeBroadcast,
+			Header:  header,
+			Buffers: buffers,
+			Opts:    newRawBroadcastOptions(opts),
+		})
+	}
+
+	a.apply(opts, func(socket Socket) {
+		a.sockets.SendBuffers(socket.ID(), buffers)
+	})
+}
+
+func (a *tcpAdapter) BroadcastWithAck(header *parser.PacketHeader, v []any, opts *BroadcastOptions, timeout time.Duration, clientCount BroadcastClientCountFunc, ack BroadcastAckFunc) {
+	if !opts.Flags.Local {
+		data, err := encodeBroadcastAckData(v)
+		if err != nil {
+			panic(fmt.Errorf("sio: %w", err))
+		}
+		requestID := newUID()
+		a.ackRequests.add(requestID, timeout, clientCount, ack)
+		a.node.sendAll(&clusterMessage{
+			Nsp:       a.nsp,
+			Type:      requestTypeBroadcast,
+			RequestID: requestID,
+			Header:    header,
+			Opts:      newRawBroadcastOptionsWithTimeout(opts, timeout),
+			Packet:    data,
+		})
+	}
+	a.inMemoryAdapter.BroadcastWithAck(header, v, opts, timeout, clientCount, ack)
+}
+
+func (a *tcpAdapter) FetchSockets(opts *BroadcastOptions) (sockets []Socket) {
+	sockets = a.inMemoryAdapter.FetchSockets(opts)
+	if opts.Flags.Local {
+		return
+	}
+
+	requestID := newUID()
+	r := a.requests.add(requestID, requestTypeRemoteFetch, a.node.peerCount())
+	defer a.requests.remove(requestID)
+
+	sent := a.node.sendAll(&clusterMessage{
+		Nsp:       a.nsp,
+		Type:      requestTypeRemoteFetch,
+		RequestID: requestID,
+		Opts:      newRawBroadcastOptions(opts),
+	})
+	if sent == 0 {
+		return
+	}
+	r.setExpected(sent)
+
+	responses, err := r.wait(a.node.opts.RequestsTimeout)
+	if err != nil {
+		a.node.onError(fmt.Errorf("adapter: FetchSockets: %w", err))
+	}
+	for _, response := range responses {
+		for _, details := range response.([]SocketDetails) {
+			sockets = append(sockets, NewRemoteSocket(a, a.nsp, details))
+		}
+	}
+	return
+}
+
+func (a *tcpAdapter) AddSockets(opts *BroadcastOptions, rooms ...Room) {
+	if !opts.Flags.Local {
+		a.node.sendAll(&clusterMessage{
+			Nsp:   a.nsp,
+			Type:  requestTypeRemoteJoin,
+			Opts:  newRawBroadcastOptions(opts),
+			Rooms: rooms,
+		})
+	}
+	a.inMemoryAdapter.AddSockets(opts, rooms...)
+}
+
+func (a *tcpAdapter) DelSockets(opts *BroadcastOptions, rooms ...Room) {
+	if !opts.Flags.Local {
+		a.node.sendAll(&clusterMessage{
+			Nsp:   a.nsp,
+			Type:  requestTypeRemoteLeave,
+			Opts:  newRawBroadcastOptions(opts),
+			Rooms: rooms,
+		})
+	}
+	a.inMemoryAdapter.DelSockets(opts, rooms...)
+}
+
+func (a *tcpAdapter) DisconnectSockets(opts *BroadcastOptions, close bool) {
+	if !opts.Flags.Local {
+		a.node.sendAll(&clusterMessage{
+			Nsp:   a.nsp,
+			Type:  requestTypeRemoteDisconnect,
+			Opts:  newRawBroadcastOptions(opts),
+			Close: close,
+		})
+	}
+	a.inMemoryAdapter.DisconnectSockets(opts, close)
+}
+
+func (a *tcpAdapter) ServerSideEmit(header *parser.PacketHeader, v []any) {
+	a.node.sendAll(&clusterMessage{
+		Nsp:  a.nsp,
+		Type: requestTypeServerSideEmit,
+		Data: v,
+	})
+}
+
+func (a *tcpAdapter) ServerSideEmitWithAck(header *parser.PacketHeader, v []any, timeout time.Duration, ack ServerSideEmitAckFunc) {
+	requestID := newUID()
+	r := a.requests.add(requestID, requestTypeServerSideEmit, a.node.peerCount())
+
+	sent := a.node.sendAll(&clusterMessage{
+		Nsp:       a.nsp,
+		Type:      requestTypeServerSideEmit,
+		RequestID: requestID,
+		Data:      v,
+	})
+	if sent == 0 {
+		a.requests.remove(requestID)
+		go ack(nil, nil)
+		return
+	}
+	r.setExpected(sent)
+
+	go func() {
+		defer a.requests.remove(requestID)
+		responses, err := r.wait(timeout)
+		ack(err, responses)
+	}()
+}
+
+func (a *tcpAdapter) onMessage(msg *clusterMessage) {
+	if msg.IsResponse {
+		a.onResponse(msg)
+		return
+	}
+
+	opts := msg.Opts.toBroadcastOptions()
+	switch msg.Type {
+	case requestTypeBroadcast:
+		if msg.RequestID != "" {
+			a.onBroadcastWithAck(msg, opts)
+			return
+		}
+		a.apply(opts, func(socket Socket) {
+			a.sockets.SendBuffers(socket.ID(), msg.Buffers)
+		})
+
+	case requestTypeRemoteJoin:
+		a.inMemoryAdapter.AddSockets(opts, msg.Rooms...)
+
+	case requestTypeRemoteLeave:
+		a.inMemoryAdapter.DelSockets(opts, msg.Rooms...)
+
+	case requestTypeRemoteDisconnect:
+		a.inMemoryAdapter.DisconnectSockets(opts, msg.Close)
+
+	case requestTypeRemoteFetch:
+		sockets := a.inMemoryAdapter.FetchSockets(opts)
+		details := make([]SocketDetails, len(sockets))
+		for i, socket := range sockets {
+			details[i] = newSocketDetails(a, socket)
+		}
+		a.node.send(msg.Addr, &clusterMessage{
+			Nsp:        a.nsp,
+			Type:       msg.Type,
+			RequestID:  msg.RequestID,
+			IsResponse: true,
+			Sockets:    details,
+		})
+
+	case requestTypeServerSideEmit:
+		if len(msg.Data) == 0 {
+			return
+		}
+		eventName, ok := msg.Data[0].(string)
+		if !ok {
+			a.node.onError(fmt.Errorf("adapter: malformed server side emit: event name is not a string"))
+			return
+		}
+		var ack func(response any)
+		if msg.RequestID != "" {
+			ack = func(response any) {
+				a.node.send(msg.Addr, &clusterMessage{
+					Nsp:        a.nsp,
+					Type:       msg.Type,
+					RequestID:  msg.RequestID,
+					IsResponse: true,
+					Response:   response,
+				})
+			}
+		}
+		a.sockets.OnServerSideEmit(eventName, msg.Data[1:], ack)
+	}
+}
+
+func (a *tcpAdapter) onBroadcastWithAck(msg *clusterMessage, opts *BroadcastOptions) {
+	data, err := decodeBroadcastAckData(msg.Packet)
+	if err != nil || msg.Header == nil {
+		a.node.onError(fmt.Errorf("adapter: malformed broadcast: %w", err))
+		return
+	}
+	timeout := msg.Opts.timeout(a.node.opts.RequestsTimeout)
+	a.inMemoryAdapter.BroadcastWithAck(msg.Header, data, opts, timeout, func(clientCount int) {
+		a.node.send(msg.Addr, &clusterMessage{
+			Nsp:         a.nsp,
+			Type:        requestTypeBroadcastClientCount,
+			RequestID:   msg.RequestID,
+			IsResponse:  true,
+			ClientCount: clientCount,
+		})
+	}, func(response any) {
+		a.node.send(msg.Addr, &clusterMessage{
+			Nsp:        a.nsp,
+			Type:       requestTypeBroadcastAck,
+			RequestID:  msg.RequestID,
+			IsResponse: true,
+			Response:   response,
+		})
+	})
+}
+
+func (a *tcpAdapter) onResponse(msg *clusterMessage) {
+	if a.ackRequests.onResponse(msg.Type, msg.RequestID, msg.ClientCount, msg.Response) {
+		return
+	}
+	r, ok := a.requests.get(msg.RequestID)
+	if !ok {
+		return
+	}
+	switch msg.Type {
+	case requestTypeRemoteFetch:
+		r.addResponse(msg.Sockets)
+	case requestTypeServerSideEmit:
+		r.addResponse(msg.Response)
+	}
+}
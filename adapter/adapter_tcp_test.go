@@ -0,0 +1,252 @@
+package adapter
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hhuuson97/socket.io-go/internal/sync"
+	"github.com/hhuuson97/socket.io-go/parser"
+	jsonparser "github.com/hhuuson97/socket.io-go/parser/json"
+	"github.com/hhuuson97/socket.io-go/parser/json/serializer/stdjson"
+	"github.com/madflojo/testcerts"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTCPAdapter(t *testing.T) {
+	testClusterAdapter(t, func(t *testing.T, n int, opts *clusterTestOptions) []*clusterTestNode {
+		nodes := make([]*clusterTestNode, n)
+		for i, node := range newTestTCPNodes(t, n, &TCPNodeOptions{
+			Secret:          []byte("secret"),
+			RequestsTimeout: opts.requestsTimeout,
+			ErrorHandler:    opts.errorHandler,
+		}) {
+			a, store := newTestTCPAdapter(node)
+			nodes[i] = &clusterTestNode{adapter: a, store: store}
+		}
+		return nodes
+	})
+
+	t.Run("should connect to the static peers and the discovered nodes", func(t *testing.T) {
+		var discovery testTCPDiscovery
+		n1 := newTestTCPNode(t, &TCPNodeOptions{Discover: discovery.discover})
+		n2 := newTestTCPNode(t, &TCPNodeOptions{Peers: []string{n1.Addr()}, Discover: discovery.discover})
+		n3 := newTestTCPNode(t, &TCPNodeOptions{Peers: []string{n1.Addr()}, Discover: discovery.discover})
+		// n1 is only given as a static peer. n2 and n3 are discovered by every node.
+		discovery.set(n2.Addr(), n3.Addr())
+		a1, _ := newTestTCPAdapter(n1)
+		a2, _ := newTestTCPAdapter(n2)
+		a3, _ := newTestTCPAdapter(n3)
+
+		for _, a := range []*tcpAdapter{a1, a2, a3} {
+			a := a
+			require.Eventually(t, func() bool {
+				return a.ServerCount() == 3
+			}, 3*time.Second, 10*time.Millisecond)
+		}
+
+		n3.Close()
+		require.Eventually(t, func() bool {
+			return a1.ServerCount() == 2 && a2.ServerCount() == 2
+		}, 3*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("should not connect to the addresses sent by the other nodes", func(t *testing.T) {
+		n1 := newTestTCPNode(t, nil)
+		a1, _ := newTestTCPAdapter(n1)
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+		accepted := make(chan struct{}, 1)
+		go func() {
+			conn, err := listener.Accept()
+			if err == nil {
+				conn.Close()
+				accepted <- struct{}{}
+			}
+		}()
+
+		conn, err := net.Dial("tcp", n1.Addr())
+		require.NoError(t, err)
+		defer conn.Close()
+		err = writeTCPMessage(conn, &clusterMessage{
+			UID:  "unknown",
+			Addr: listener.Addr().String(),
+			Type: requestTypeInitialHeartbeat,
+		})
+		require.NoError(t, err)
+
+		select {
+		case <-accepted:
+			t.Fatal("the node connected to an unknown address")
+		case <-time.After(300 * time.Millisecond):
+		}
+		require.Equal(t, 1, a1.ServerCount())
+	})
+
+	t.Run("should reject the nodes that don't know the secret", func(t *testing.T) {
+		errs := make(chan error, 10)
+		errorHandler := func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		}
+		var discovery testTCPDiscovery
+		n1 := newTestTCPNode(t, &TCPNodeOptions{Secret: []byte("secret"), Discover: discovery.discover, ErrorHandler: errorHandler})
+		n2 := newTestTCPNode(t, &TCPNodeOptions{Secret: []byte("other"), Discover: discovery.discover, ErrorHandler: errorHandler})
+		discovery.set(n1.Addr(), n2.Addr())
+		a1, store1 := newTestTCPAdapter(n1)
+		a2, _ := newTestTCPAdapter(n2)
+
+		store1.Set(NewTestSocket("s1"))
+		a1.AddAll("s1", []Room{"s1"})
+		store1.SetSendBuffers(func(sid SocketID, buffers [][]byte) (ok bool) {
+			t.Error("a message of an unauthenticated node was received")
+			return true
+		})
+
+		// A connection that skips the handshake.
+		conn, err := net.Dial("tcp", n1.Addr())
+		require.NoError(t, err)
+		defer conn.Close()
+		writeTCPMessage(conn, &clusterMessage{
+			UID:     "unknown",
+			Nsp:     "/",
+			Type:    requestTypeBroadcast,
+			Header:  &parser.PacketHeader{Type: parser.PacketTypeEvent, Namespace: "/"},
+			Buffers: [][]byte{[]byte(`2["hello"]`)},
+		})
+
+		select {
+		case err := <-errs:
+			require.Error(t, err)
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout exceeded")
+		}
+		time.Sleep(200 * time.Millisecond)
+		require.Equal(t, 1, a1.ServerCount())
+		require.Equal(t, 1, a2.ServerCount())
+	})
+
+	t.Run("should connect the nodes with mutual TLS", func(t *testing.T) {
+		ca := testcerts.NewCA()
+		newTLSConfig := func() *tls.Config {
+			kp, err := ca.NewKeyPair()
+			require.NoError(t, err)
+			cert, err := tls.X509KeyPair(kp.PublicKey(), kp.PrivateKey())
+			require.NoError(t, err)
+			return &tls.Config{
+				Certificates: []tls.Certificate{cert},
+				RootCAs:      ca.CertPool(),
+				ClientCAs:    ca.CertPool(),
+				ClientAuth:   tls.RequireAndVerifyClientCert,
+			}
+		}
+
+		errs := make(chan error, 10)
+		nodes := newTestTCPNodes(t, 2, &TCPNodeOptions{
+			TLSConfig: newTLSConfig(),
+			ErrorHandler: func(err error) {
+				select {
+				case errs <- err:
+				default:
+				}
+			},
+		})
+		a1, _ := newTestTCPAdapter(nodes[0])
+		a2, store2 := newTestTCPAdapter(nodes[1])
+		require.Eventually(t, func() bool {
+			return a1.ServerCount() == 2 && a2.ServerCount() == 2
+		}, 3*time.Second, 10*time.Millisecond)
+
+		store2.Set(NewTestSocket("s1"))
+		a2.AddAll("s1", []Room{"s1"})
+		received := make(chan string, 1)
+		store2.SetSendBuffers(func(sid SocketID, buffers [][]byte) (ok bool) {
+			received <- string(buffers[0])
+			return true
+		})
+		a1.Broadcast(&parser.PacketHeader{Type: parser.PacketTypeEvent, Namespace: "/"}, []any{"hello"}, NewBroadcastOptions())
+		select {
+		case buf := <-received:
+			require.Equal(t, `2["hello"]`, buf)
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout exceeded")
+		}
+
+		// A node without a client certificate is rejected.
+		config := newTLSConfig()
+		config.Certificates = nil
+		conn, err := tls.Dial("tcp", nodes[0].Addr(), config)
+		if err == nil {
+			defer conn.Close()
+			conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+			_, err = conn.Read(make([]byte, 1))
+		}
+		require.Error(t, err)
+		select {
+		case err := <-errs:
+			require.ErrorContains(t, err, "certificate")
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout exceeded")
+		}
+	})
+}
+
+// Returns the addresses of the nodes to TCPNodeOptions.Discover.
+type testTCPDiscovery struct {
+	addrs []string
+	mu    sync.Mutex
+}
+
+func (d *testTCPDiscovery) set(addrs ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.addrs = addrs
+}
+
+func (d *testTCPDiscovery) discover(ctx context.Context) ([]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.addrs, nil
+}
+
+func newTestTCPNode(t *testing.T, opts *TCPNodeOptions) *TCPNode {
+	if opts == nil {
+		opts = new(TCPNodeOptions)
+	}
+	opts.ListenAddr = "127.0.0.1:0"
+	opts.DiscoveryInterval = 50 * time.Millisecond
+	if opts.RequestsTimeout == 0 {
+		opts.RequestsTimeout = 2 * time.Second
+	}
+	node, err := NewTCPNode(opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { node.Close() })
+	return node
+}
+
+// Returns n nodes that discover each other. opts is shared by the nodes.
+func newTestTCPNodes(t *testing.T, n int, opts *TCPNodeOptions) []*TCPNode {
+	var discovery testTCPDiscovery
+	nodes := make([]*TCPNode, n)
+	addrs := make([]string, n)
+	for i := range nodes {
+		o := *opts
+		o.Discover = discovery.discover
+		nodes[i] = newTestTCPNode(t, &o)
+		addrs[i] = nodes[i].Addr()
+	}
+	discovery.set(addrs...)
+	return nodes
+}
+
+func newTestTCPAdapter(node *TCPNode) (*tcpAdapter, *TestSocketStore) {
+	store := NewTestSocketStore()
+	a := NewTCPAdapterCreator(node)(store, jsonparser.NewCreator(0, stdjson.New())).(*tcpAdapter)
+	return a, store
+}
@@ -2,14 +2,22 @@ package adapter
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
+	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
 
+	"github.com/hhuuson97/socket.io-go/internal/sync"
+	"github.com/hhuuson97/socket.io-go/internal/utils"
 	"github.com/hhuuson97/socket.io-go/parser"
 )
 
+// Passed to the acknowledgement function of a broadcast when
+// some of the clients haven't responded before the timeout.
+var ErrAckTimeout = errors.New("ack timeout")
+
 type (
 	BroadcastOperator struct {
 		nsp     string
@@ -18,6 +26,7 @@ type (
 		rooms       mapset.Set[Room]
 		exceptRooms mapset.Set[Room]
 		flags       BroadcastFlags
+		timeout     time.Duration
 
 		isEventReserved func(string) bool
 	}
@@ -30,8 +39,8 @@ type (
 
 	BroadcastFlags struct {
 		// This flag is unused at the moment, but for compatibility with the socket.io API, it stays here.
-		Compress bool
-		Local    bool
+		Compress bool `json:"compress,omitempty"`
+		Local    bool `json:"local,omitempty"`
 	}
 )
 
@@ -57,13 +66,21 @@ func NewBroadcastOperator(
 }
 
 // Emits an event to all choosen clients.
+//
+// If a timeout is set (see Timeout), the last argument can be an acknowledgement function with the signature:
+// func(err error, responses []T). It is called once, with one response per client,
+// either when every client has responded or when the timeout is reached.
+// In the latter case err is ErrAckTimeout and responses contains the responses received so far.
+//
+// A response that cannot be converted to T is left out of responses,
+// and the conversion error is joined to err (see errors.Join).
 func (b *BroadcastOperator) Emit(eventName string, _v ...any) {
 	header := &parser.PacketHeader{
 		Type:      parser.PacketTypeEvent,
 		Namespace: b.nsp,
 	}
 
-	if b.isEventReserved(eventName) {
+	if b.isEventReserved != nil && b.isEventReserved(eventName) {
 		panic(fmt.Errorf("sio: BroadcastOperator.Emit: attempted to emit a reserved event: `%s`", eventName))
 	}
 
@@ -73,19 +90,114 @@ func (b *BroadcastOperator) Emit(eventName string, _v ...any) {
 	v = append(v, eventName)
 	v = append(v, _v...)
 
+	opts := NewBroadcastOptions()
+	opts.Rooms = b.rooms
+	opts.Except = b.exceptRooms
+	opts.Flags = b.flags
+
 	f := v[len(v)-1]
 	rt := reflect.TypeOf(f)
 	if f != nil && rt.Kind() == reflect.Func {
-		panic(fmt.Errorf("sio: BroadcastOperator.Emit: callbacks are not supported when broadcasting"))
+		if b.timeout == 0 {
+			panic(fmt.Errorf("sio: BroadcastOperator.Emit: callbacks are only supported when a timeout is set (see BroadcastOperator.Timeout)"))
+		}
+		err := checkBroadcastAckFunc(f)
+		if err != nil {
+			panic(err)
+		}
+		b.emitWithAck(header, v[:len(v)-1], opts, f)
+		return
 	}
 
-	opts := NewBroadcastOptions()
-	opts.Rooms = b.rooms
-	opts.Except = b.exceptRooms
-	opts.Flags = b.flags
 	b.adapter.Broadcast(header, v, opts)
 }
 
+func (b *BroadcastOperator) emitWithAck(header *parser.PacketHeader, v []any, opts *BroadcastOptions, f any) {
+	var (
+		ackValue     = reflect.ValueOf(f)
+		responseType = ackValue.Type().In(1).Elem()
+		responses    = reflect.MakeSlice(ackValue.Type().In(1), 0, 0)
+
+		// Each server sends the number of the clients it has broadcast to.
+		expectedServerCount = 1
+		serverCount         = 0
+		expectedClientCount = 0
+		clientCount         = 0
+
+		// Errors of the responses that cannot be converted to the type of the responses.
+		convErr error
+
+		done bool
+		mu   sync.Mutex
+	)
+	if !opts.Flags.Local {
+		expectedServerCount = b.adapter.ServerCount()
+	}
+
+	// Must be called with mu held.
+	call := func(err error) {
+		if done {
+			return
+		}
+		done = true
+
+		if convErr != nil {
+			err = errors.Join(err, convErr)
+		}
+		errValue := reflect.New(reflectError).Elem()
+		if err != nil {
+			errValue.Set(reflect.ValueOf(err))
+		}
+		go ackValue.Call([]reflect.Value{errValue, responses})
+	}
+
+	timer := time.AfterFunc(b.timeout, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		call(ErrAckTimeout)
+	})
+
+	// Must be called with mu held.
+	checkCompleteness := func() {
+		if serverCount == expectedServerCount && clientCount == expectedClientCount {
+			timer.Stop()
+			call(nil)
+		}
+	}
+
+	b.adapter.BroadcastWithAck(header, v, opts, b.timeout, func(count int) {
+		mu.Lock()
+		defer mu.Unlock()
+		expectedClientCount += count
+		serverCount++
+		checkCompleteness()
+	}, func(response any) {
+		mu.Lock()
+		defer mu.Unlock()
+		clientCount++
+		value, err := utils.ConvertValue(responseType, response)
+		if err != nil {
+			convErr = errors.Join(convErr, fmt.Errorf("sio: BroadcastOperator.Emit: %w", err))
+		} else {
+			responses = reflect.Append(responses, value)
+		}
+		checkCompleteness()
+	})
+}
+
+var reflectError = reflect.TypeOf((*error)(nil)).Elem()
+
+func checkBroadcastAckFunc(f any) error {
+	rt := reflect.TypeOf(f)
+	if rt.NumOut() != 0 {
+		return fmt.Errorf("sio: ack handler must not have a return value")
+	}
+	if rt.NumIn() != 2 || rt.In(0) != reflectError || rt.In(1).Kind() != reflect.Slice {
+		return fmt.Errorf("sio: ack handler of a broadcast must have the signature: func(err error, responses []T)")
+	}
+	return nil
+}
+
 // Sets a modifier for a subsequent event emission that the event
 // will only be broadcast to clients that have joined the given room.
 //
@@ -131,13 +243,31 @@ func (b *BroadcastOperator) Local() *BroadcastOperator {
 	return &n
 }
 
+// Sets a modifier for a subsequent event emission that the acknowledgement function
+// will be called with an error when the given duration has elapsed without
+// every client having acknowledged the event.
+//
+// See Emit for the signature of the acknowledgement function.
+func (b *BroadcastOperator) Timeout(timeout time.Duration) *BroadcastOperator {
+	n := *b
+	n.timeout = timeout
+	return &n
+}
+
 // Returns the matching socket instances. This method works across a cluster of several Socket.IO servers.
 func (b *BroadcastOperator) FetchSockets() []Socket {
 	opts := NewBroadcastOptions()
 	opts.Rooms = b.rooms.Clone()
 	opts.Except = b.exceptRooms.Clone()
 	opts.Flags = b.flags
-	return b.adapter.FetchSockets(opts)
+
+	sockets := b.adapter.FetchSockets(opts)
+	for _, socket := range sockets {
+		if remoteSocket, ok := socket.(*RemoteSocket); ok {
+			remoteSocket.isEventReserved = b.isEventReserved
+		}
+	}
+	return sockets
 }
 
 // Makes the matching socket instances join the specified rooms.
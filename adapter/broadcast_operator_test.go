@@ -2,7 +2,9 @@ package adapter
 
 import (
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -35,6 +37,78 @@ func TestBroadcastOperator(t *testing.T) {
 			require.Contains(t, sids, SocketID("s3"))
 		})
 
+		t.Run("Emit with acknowledgements", func(t *testing.T) {
+			store.sendBuffers = func(sid SocketID, buffers [][]byte) (ok bool) {
+				return true
+			}
+			done := make(chan struct{})
+			b.To("r2").Timeout(time.Second).Emit("hi", func(err error, responses []string) {
+				defer close(done)
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, []string{"from s1", "from s3"}, responses)
+			})
+			require.True(t, store.Ack("s1", "from s1"))
+			require.False(t, store.Ack("s2", "from s2"))
+			require.True(t, store.Ack("s3", "from s3"))
+
+			select {
+			case <-done:
+			case <-time.After(3 * time.Second):
+				t.Fatal("timeout exceeded")
+			}
+		})
+
+		t.Run("Emit with acknowledgements and a timeout", func(t *testing.T) {
+			store.sendBuffers = func(sid SocketID, buffers [][]byte) (ok bool) {
+				return true
+			}
+			done := make(chan struct{})
+			b.To("r2").Timeout(100*time.Millisecond).Emit("hi", func(err error, responses []string) {
+				defer close(done)
+				assert.Equal(t, ErrAckTimeout, err)
+				assert.Equal(t, []string{"from s1"}, responses)
+			})
+			require.True(t, store.Ack("s1", "from s1"))
+
+			select {
+			case <-done:
+			case <-time.After(3 * time.Second):
+				t.Fatal("timeout exceeded")
+			}
+			// Clear the acknowledgements of s3.
+			store.Ack("s3", nil)
+		})
+
+		t.Run("Emit should report the acknowledgements that cannot be converted", func(t *testing.T) {
+			store.sendBuffers = func(sid SocketID, buffers [][]byte) (ok bool) {
+				return true
+			}
+			done := make(chan struct{})
+			b.To("r2").Timeout(time.Second).Emit("hi", func(err error, responses []int) {
+				defer close(done)
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, ErrAckTimeout)
+				assert.Equal(t, []int{1}, responses)
+			})
+			require.True(t, store.Ack("s1", 1))
+			require.True(t, store.Ack("s3", "not a number"))
+
+			select {
+			case <-done:
+			case <-time.After(3 * time.Second):
+				t.Fatal("timeout exceeded")
+			}
+		})
+
+		t.Run("Emit should panic when an acknowledgement is given without a timeout", func(t *testing.T) {
+			require.Panics(t, func() {
+				b.Emit("hi", func(err error, responses []string) {})
+			})
+			require.Panics(t, func() {
+				b.Timeout(time.Second).Emit("hi", func(responses []string) {})
+			})
+		})
+
 		t.Run("FetchSockets", func(t *testing.T) {
 			sockets := b.FetchSockets()
 			require.Contains(t, sockets, s1)
@@ -0,0 +1,482 @@
+package adapter
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/hhuuson97/socket.io-go/internal/sync"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/hhuuson97/socket.io-go/parser"
+	jsonparser "github.com/hhuuson97/socket.io-go/parser/json"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Types of the requests sent between the nodes of a cluster.
+//
+// These are the same as the ones used by the official Socket.IO adapters.
+type requestType int
+
+const (
+	requestTypeSockets requestType = iota
+	requestTypeAllRooms
+	requestTypeRemoteJoin
+	requestTypeRemoteLeave
+	requestTypeRemoteDisconnect
+	requestTypeRemoteFetch
+	requestTypeServerSideEmit
+	requestTypeBroadcast
+	requestTypeBroadcastClientCount
+	requestTypeBroadcastAck
+
+	// Used by the nodes to keep track of each other (see nodeRegistry).
+	requestTypeInitialHeartbeat
+	requestTypeHeartbeat
+	requestTypeAdapterClose
+)
+
+var ErrRequestTimeout = fmt.Errorf("adapter: timeout reached while waiting for the responses")
+
+// Serializable form of BroadcastOptions.
+type (
+	rawBroadcastOptions struct {
+		Rooms  []Room            `json:"rooms"`
+		Except []Room            `json:"except"`
+		Flags  rawBroadcastFlags `json:"flags"`
+	}
+
+	rawBroadcastFlags struct {
+		BroadcastFlags
+		// Duration to wait for the acknowledgements, in milliseconds.
+		// This is only set for the broadcasts with acknowledgements.
+		Timeout int64 `json:"timeout,omitempty"`
+	}
+)
+
+func newRawBroadcastOptions(opts *BroadcastOptions) *rawBroadcastOptions {
+	return &rawBroadcastOptions{
+		Rooms:  opts.Rooms.ToSlice(),
+		Except: opts.Except.ToSlice(),
+		Flags:  rawBroadcastFlags{BroadcastFlags: opts.Flags},
+	}
+}
+
+func newRawBroadcastOptionsWithTimeout(opts *BroadcastOptions, timeout time.Duration) *rawBroadcastOptions {
+	o := newRawBroadcastOptions(opts)
+	o.Flags.Timeout = timeout.Milliseconds()
+	return o
+}
+
+func (o *rawBroadcastOptions) toBroadcastOptions() *BroadcastOptions {
+	opts := NewBroadcastOptions()
+	if o == nil {
+		return opts
+	}
+	opts.Rooms.Append(o.Rooms...)
+	opts.Except.Append(o.Except...)
+	opts.Flags = o.Flags.BroadcastFlags
+	return opts
+}
+
+// Returns the timeout of a broadcast with acknowledgements, or
+// defaultTimeout if the sender didn't specify one.
+func (o *rawBroadcastOptions) timeout(defaultTimeout time.Duration) time.Duration {
+	if o == nil || o.Flags.Timeout <= 0 {
+		return defaultTimeout
+	}
+	return time.Duration(o.Flags.Timeout) * time.Millisecond
+}
+
+// Messages exchanged between the nodes by the adapters that don't
+// have to be compatible with the official Socket.IO adapters.
+type clusterMessage struct {
+	UID string `json:"uid"`
+	// Address of the sender, used by the TCP adapter to send the responses.
+	Addr string `json:"addr,omitempty"`
+	Nsp  string `json:"nsp,omitempty"`
+
+	Type       requestType `json:"type"`
+	RequestID  string      `json:"requestId,omitempty"`
+	IsResponse bool        `json:"isResponse,omitempty"`
+
+	Header  *parser.PacketHeader `json:"header,omitempty"`
+	Buffers [][]byte             `json:"buffers,omitempty"`
+	Opts    *rawBroadcastOptions `json:"opts,omitempty"`
+	Rooms   []Room               `json:"rooms,omitempty"`
+	Close   bool                 `json:"close,omitempty"`
+	Data    []any                `json:"data,omitempty"`
+
+	// The data of a broadcast with acknowledgements (see encodeBroadcastAckData).
+	Packet []byte `json:"packet,omitempty"`
+
+	Sockets     []SocketDetails `json:"sockets,omitempty"`
+	Response    any             `json:"response,omitempty"`
+	ClientCount int             `json:"clientCount,omitempty"`
+}
+
+// Details of a socket, sent to the other nodes of the cluster upon FetchSockets.
+type SocketDetails struct {
+	ID        SocketID    `json:"id"`
+	Handshake *Handshake  `json:"handshake"`
+	Rooms     []Room      `json:"rooms"`
+	Data      *SocketData `json:"data,omitempty"`
+}
+
+func newSocketDetails(a Adapter, socket Socket) SocketDetails {
+	details := SocketDetails{
+		ID:        socket.ID(),
+		Handshake: socket.Handshake(),
+		Data:      socket.Data(),
+	}
+	rooms, ok := a.SocketRooms(socket.ID())
+	if ok {
+		details.Rooms = rooms.ToSlice()
+	}
+	return details
+}
+
+// This is a socket connected to another node of the cluster.
+// Remote sockets are returned by FetchSockets.
+type RemoteSocket struct {
+	id        SocketID
+	handshake *Handshake
+	rooms     mapset.Set[Room]
+	data      *SocketData
+
+	nsp             string
+	adapter         Adapter
+	isEventReserved func(string) bool
+}
+
+var _ Socket = &RemoteSocket{}
+
+func NewRemoteSocket(adapter Adapter, nsp string, details SocketDetails) *RemoteSocket {
+	handshake := details.Handshake
+	if handshake == nil {
+		handshake = new(Handshake)
+	}
+	data := details.Data
+	if data == nil {
+		data = NewSocketData()
+	}
+	return &RemoteSocket{
+		id:        details.ID,
+		handshake: handshake,
+		rooms:     mapset.NewSet[Room](details.Rooms...),
+		data:      data,
+		nsp:       nsp,
+		adapter:   adapter,
+	}
+}
+
+func (s *RemoteSocket) ID() SocketID { return s.id }
+
+func (s *RemoteSocket) Handshake() *Handshake { return s.handshake }
+
+// Data of the socket at the time it was fetched. Changing it has no effect on the socket.
+func (s *RemoteSocket) Data() *SocketData { return s.data }
+
+// Rooms the socket was joined to at the time it was fetched.
+func (s *RemoteSocket) Rooms() mapset.Set[Room] { return s.rooms.Clone() }
+
+func (s *RemoteSocket) Join(room ...Room) {
+	s.newBroadcastOperator().To(Room(s.id)).SocketsJoin(room...)
+}
+
+func (s *RemoteSocket) Leave(room Room) {
+	s.newBroadcastOperator().To(Room(s.id)).SocketsLeave(room)
+}
+
+func (s *RemoteSocket) Emit(eventName string, v ...any) {
+	s.newBroadcastOperator().To(Room(s.id)).Emit(eventName, v...)
+}
+
+func (s *RemoteSocket) To(room ...Room) *BroadcastOperator {
+	return s.Broadcast().To(room...)
+}
+
+func (s *RemoteSocket) In(room ...Room) *BroadcastOperator {
+	return s.To(room...)
+}
+
+func (s *RemoteSocket) Except(room ...Room) *BroadcastOperator {
+	return s.Broadcast().Except(room...)
+}
+
+func (s *RemoteSocket) Broadcast() *BroadcastOperator {
+	return s.newBroadcastOperator().Except(Room(s.id))
+}
+
+func (s *RemoteSocket) Disconnect(close bool) {
+	s.newBroadcastOperator().To(Room(s.id)).DisconnectSockets(close)
+}
+
+func (s *RemoteSocket) newBroadcastOperator() *BroadcastOperator {
+	return NewBroadcastOperator(s.nsp, s.adapter, s.isEventReserved)
+}
+
+type (
+	pendingRequests struct {
+		requests map[string]*pendingRequest
+		mu       sync.Mutex
+	}
+
+	pendingRequest struct {
+		typ requestType
+		// Number of the expected responses.
+		// If this is 0, responses are collected until the timeout.
+		expected  int
+		responses []any
+		completed bool
+		done      chan struct{}
+		mu        sync.Mutex
+	}
+)
+
+func newPendingRequests() *pendingRequests {
+	return &pendingRequests{requests: make(map[string]*pendingRequest)}
+}
+
+func (p *pendingRequests) add(requestID string, typ requestType, expected int) *pendingRequest {
+	r := &pendingRequest{
+		typ:      typ,
+		expected: expected,
+		done:     make(chan struct{}),
+	}
+	p.mu.Lock()
+	p.requests[requestID] = r
+	p.mu.Unlock()
+	return r
+}
+
+func (p *pendingRequests) get(requestID string) (r *pendingRequest, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r, ok = p.requests[requestID]
+	return
+}
+
+func (p *pendingRequests) remove(requestID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.requests, requestID)
+}
+
+func (r *pendingRequest) addResponse(response any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.completed {
+		return
+	}
+	r.responses = append(r.responses, response)
+	if r.expected > 0 && len(r.responses) >= r.expected {
+		r.completed = true
+		close(r.done)
+	}
+}
+
+// Updates the number of the expected responses (such as when
+// the request could only be sent to some of the nodes).
+func (r *pendingRequest) setExpected(expected int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expected = expected
+	if !r.completed && r.expected > 0 && len(r.responses) >= r.expected {
+		r.completed = true
+		close(r.done)
+	}
+}
+
+// Wait for the responses. If the expected number of responses is known and
+// the timeout is reached before all responses are received,
+// the responses received so far are returned alongside ErrRequestTimeout.
+func (r *pendingRequest) wait(timeout time.Duration) (responses []any, err error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-r.done:
+	case <-timer.C:
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.completed {
+		r.completed = true
+		if r.expected > 0 {
+			err = ErrRequestTimeout
+		}
+	}
+	responses = r.responses
+	return
+}
+
+type (
+	// The broadcasts with acknowledgements sent by this node.
+	// Each node responds with the number of the sockets the packet was
+	// sent to, then with the acknowledgement of every socket.
+	broadcastAckRequests struct {
+		requests map[string]*broadcastAckRequest
+		mu       sync.Mutex
+	}
+
+	broadcastAckRequest struct {
+		clientCount BroadcastClientCountFunc
+		ack         BroadcastAckFunc
+	}
+)
+
+func newBroadcastAckRequests() *broadcastAckRequests {
+	return &broadcastAckRequests{requests: make(map[string]*broadcastAckRequest)}
+}
+
+// The request is removed after the timeout, since it cannot be known
+// whether every socket of the other nodes has acknowledged.
+func (b *broadcastAckRequests) add(requestID string, timeout time.Duration, clientCount BroadcastClientCountFunc, ack BroadcastAckFunc) {
+	b.mu.Lock()
+	b.requests[requestID] = &broadcastAckRequest{clientCount: clientCount, ack: ack}
+	b.mu.Unlock()
+
+	time.AfterFunc(timeout, func() {
+		b.mu.Lock()
+		delete(b.requests, requestID)
+		b.mu.Unlock()
+	})
+}
+
+func (b *broadcastAckRequests) get(requestID string) (r *broadcastAckRequest, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	r, ok = b.requests[requestID]
+	return
+}
+
+// Dispatches the response of a node to a broadcast with acknowledgements.
+// handled is false if the response is of another type.
+func (b *broadcastAckRequests) onResponse(typ requestType, requestID string, clientCount int, response any) (handled bool) {
+	switch typ {
+	case requestTypeBroadcastClientCount, requestTypeBroadcastAck:
+	default:
+		return false
+	}
+
+	r, ok := b.get(requestID)
+	if !ok {
+		return true
+	}
+	if typ == requestTypeBroadcastClientCount {
+		r.clientCount(clientCount)
+	} else {
+		r.ack(response)
+	}
+	return true
+}
+
+// The data of a broadcast with acknowledgements is sent to the other nodes as msgpack,
+// so that binary data is kept. Each node encodes the packet itself,
+// since the acknowledgement ID of the packet is specific to the node.
+func encodeBroadcastAckData(v []any) ([]byte, error) {
+	return msgpackMarshal(v)
+}
+
+func decodeBroadcastAckData(data []byte) ([]any, error) {
+	var v []any
+	err := msgpackUnmarshal(data, &v)
+	if err != nil {
+		return nil, err
+	}
+	for i := range v {
+		v[i] = restoreBinary(v[i])
+	}
+	return v, nil
+}
+
+// Keeps track of the other nodes of a cluster from their heartbeats.
+type nodeRegistry struct {
+	// Duration after which a node that hasn't sent a heartbeat is considered down.
+	timeout time.Duration
+	// The time the nodes were last seen.
+	nodes map[string]time.Time
+	mu    sync.Mutex
+}
+
+func newNodeRegistry(timeout time.Duration) *nodeRegistry {
+	return &nodeRegistry{
+		timeout: timeout,
+		nodes:   make(map[string]time.Time),
+	}
+}
+
+func (r *nodeRegistry) seen(uid string) {
+	r.mu.Lock()
+	r.nodes[uid] = time.Now()
+	r.mu.Unlock()
+}
+
+func (r *nodeRegistry) remove(uid string) {
+	r.mu.Lock()
+	delete(r.nodes, uid)
+	r.mu.Unlock()
+}
+
+// Returns the number of the nodes that have sent a heartbeat recently.
+func (r *nodeRegistry) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for uid, lastSeen := range r.nodes {
+		if time.Since(lastSeen) > r.timeout {
+			delete(r.nodes, uid)
+		}
+	}
+	return len(r.nodes)
+}
+
+func newUID() string {
+	b := make([]byte, 6)
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(fmt.Errorf("adapter: %w", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+// The msgpack encoding used by the official Socket.IO adapters.
+// JSON tags are used as the field names.
+func msgpackMarshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	e := msgpack.NewEncoder(&buf)
+	e.SetCustomStructTag("json")
+	e.UseCompactInts(true)
+	err := e.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func msgpackUnmarshal(data []byte, v any) error {
+	d := msgpack.NewDecoder(bytes.NewReader(data))
+	d.SetCustomStructTag("json")
+	return d.Decode(v)
+}
+
+// Binary data received from the other nodes is decoded as []byte.
+// Convert them back to a binary type so that they are sent as attachments.
+func restoreBinary(v any) any {
+	switch v := v.(type) {
+	case []byte:
+		return jsonparser.Binary(v)
+	case []any:
+		for i := range v {
+			v[i] = restoreBinary(v[i])
+		}
+	case map[string]any:
+		for k, e := range v {
+			v[k] = restoreBinary(e)
+		}
+	}
+	return v
+}
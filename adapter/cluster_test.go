@@ -0,0 +1,355 @@
+package adapter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/hhuuson97/socket.io-go/internal/sync"
+	"github.com/hhuuson97/socket.io-go/parser"
+	jsonparser "github.com/hhuuson97/socket.io-go/parser/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type clusterTestNode struct {
+	adapter Adapter
+	store   *TestSocketStore
+}
+
+type clusterTestOptions struct {
+	requestsTimeout time.Duration
+	errorHandler    func(err error)
+}
+
+// Creates n nodes of a cluster that share the same backend.
+type newClusterFunc func(t *testing.T, n int, opts *clusterTestOptions) []*clusterTestNode
+
+// The behaviour that is common to every adapter that connects the nodes of a cluster.
+func testClusterAdapter(t *testing.T, newCluster newClusterFunc) {
+	// Returns the nodes once they know about each other.
+	newNodes := func(t *testing.T, n int, opts *clusterTestOptions) []*clusterTestNode {
+		if opts == nil {
+			opts = &clusterTestOptions{}
+		}
+		if opts.requestsTimeout == 0 {
+			opts.requestsTimeout = 2 * time.Second
+		}
+		if opts.errorHandler == nil {
+			opts.errorHandler = func(err error) { t.Error(err) }
+		}
+		nodes := newCluster(t, n, opts)
+		require.Len(t, nodes, n)
+		for _, node := range nodes {
+			node := node
+			require.Eventually(t, func() bool {
+				return node.adapter.ServerCount() == n
+			}, 3*time.Second, 10*time.Millisecond)
+		}
+		return nodes
+	}
+
+	t.Run("should broadcast to the sockets of the other nodes", func(t *testing.T) {
+		nodes := newNodes(t, 2, nil)
+		n1, n2 := nodes[0], nodes[1]
+
+		n1.store.Set(NewTestSocket("s1"))
+		n1.adapter.AddAll("s1", []Room{"s1"})
+		n2.store.Set(NewTestSocket("s2"))
+		n2.adapter.AddAll("s2", []Room{"s2", "r1"})
+
+		n1.store.SetSendBuffers(func(sid SocketID, buffers [][]byte) (ok bool) {
+			t.Error("socket is not in the room")
+			return true
+		})
+		received := make(chan [][]byte, 1)
+		n2.store.SetSendBuffers(func(sid SocketID, buffers [][]byte) (ok bool) {
+			assert.Equal(t, SocketID("s2"), sid)
+			received <- buffers
+			return true
+		})
+
+		header := &parser.PacketHeader{Type: parser.PacketTypeEvent, Namespace: "/"}
+		opts := NewBroadcastOptions()
+		opts.Rooms.Add("r1")
+		n1.adapter.Broadcast(header, []any{"hello", 1, jsonparser.Binary{1, 2, 3}}, opts)
+
+		select {
+		case buffers := <-received:
+			require.Len(t, buffers, 2)
+			// The adapters that support the connection state recovery append the offset.
+			require.Regexp(t, `^51-\["hello",1,\{"_placeholder":true,"num":0\}(,"[^"]+")?\]$`, string(buffers[0]))
+			require.Equal(t, []byte{1, 2, 3}, buffers[1])
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout exceeded")
+		}
+	})
+
+	t.Run("should not broadcast to the other nodes with the local flag", func(t *testing.T) {
+		nodes := newNodes(t, 2, nil)
+		n1, n2 := nodes[0], nodes[1]
+
+		n1.store.Set(NewTestSocket("s1"))
+		n1.adapter.AddAll("s1", []Room{"s1"})
+		n2.store.Set(NewTestSocket("s2"))
+		n2.adapter.AddAll("s2", []Room{"s2"})
+
+		received := make(chan SocketID, 1)
+		n1.store.SetSendBuffers(func(sid SocketID, buffers [][]byte) (ok bool) {
+			received <- sid
+			return true
+		})
+		n2.store.SetSendBuffers(func(sid SocketID, buffers [][]byte) (ok bool) {
+			t.Error("local broadcast was received by another node")
+			return true
+		})
+
+		header := &parser.PacketHeader{Type: parser.PacketTypeEvent, Namespace: "/"}
+		opts := NewBroadcastOptions()
+		opts.Flags.Local = true
+		n1.adapter.Broadcast(header, []any{"hello"}, opts)
+
+		select {
+		case sid := <-received:
+			require.Equal(t, SocketID("s1"), sid)
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout exceeded")
+		}
+		time.Sleep(200 * time.Millisecond)
+	})
+
+	t.Run("should fetch the sockets of the other nodes", func(t *testing.T) {
+		nodes := newNodes(t, 2, nil)
+		n1, n2 := nodes[0], nodes[1]
+
+		n1.store.Set(NewTestSocket("s1"))
+		n1.adapter.AddAll("s1", []Room{"s1", "r1"})
+		s2 := NewTestSocket("s2")
+		handshake := s2.Handshake()
+		handshake.Headers = http.Header{"User-Agent": {"test"}}
+		handshake.Query = url.Values{"token": {"abc"}}
+		handshake.Address = "127.0.0.1"
+		handshake.Secure = true
+		handshake.Time = time.UnixMilli(time.Now().UnixMilli())
+		handshake.URL = "/socket.io/?EIO=4&transport=polling"
+		handshake.Auth = json.RawMessage(`{"user":"alice"}`)
+		s2.Data().Set("count", 42)
+		n2.store.Set(s2)
+		n2.adapter.AddAll("s2", []Room{"s2", "r1", "r2"})
+		n2.store.Set(NewTestSocket("s3"))
+		n2.adapter.AddAll("s3", []Room{"s3"})
+
+		opts := NewBroadcastOptions()
+		opts.Rooms.Add("r1")
+		sockets := n1.adapter.FetchSockets(opts)
+		require.Len(t, sockets, 2)
+
+		var remote *RemoteSocket
+		for _, socket := range sockets {
+			if s, ok := socket.(*RemoteSocket); ok {
+				remote = s
+			}
+		}
+		require.NotNil(t, remote)
+		require.Equal(t, SocketID("s2"), remote.ID())
+		require.True(t, remote.Rooms().Contains("s2", "r1", "r2"))
+
+		h := remote.Handshake()
+		require.NotNil(t, h)
+		assert.Equal(t, "test", h.Headers.Get("User-Agent"))
+		assert.Equal(t, "abc", h.Query.Get("token"))
+		assert.Equal(t, "127.0.0.1", h.Address)
+		assert.True(t, h.Secure)
+		assert.True(t, handshake.Time.Equal(h.Time))
+		assert.Equal(t, handshake.URL, h.URL)
+		assert.JSONEq(t, `{"user":"alice"}`, string(h.Auth))
+
+		count, ok := GetSocketData[int](remote.Data(), "count")
+		require.True(t, ok)
+		require.Equal(t, 42, count)
+	})
+
+	t.Run("should return the responses received before the timeout of FetchSockets", func(t *testing.T) {
+		errs := make(chan error, 1)
+		nodes := newNodes(t, 3, &clusterTestOptions{
+			requestsTimeout: 300 * time.Millisecond,
+			errorHandler: func(err error) {
+				select {
+				case errs <- err:
+				default:
+				}
+			},
+		})
+		n1, n2, n3 := nodes[0], nodes[1], nodes[2]
+
+		n1.store.Set(NewTestSocket("s1"))
+		n1.adapter.AddAll("s1", []Room{"s1"})
+		n2.store.Set(NewTestSocket("s2"))
+		n2.adapter.AddAll("s2", []Room{"s2"})
+
+		// The third node doesn't respond in time.
+		unblock := make(chan struct{})
+		defer close(unblock)
+		n3.store.Set(&stalledTestSocket{TestSocket: NewTestSocket("s3"), unblock: unblock})
+		n3.adapter.AddAll("s3", []Room{"s3"})
+
+		start := time.Now()
+		sockets := n1.adapter.FetchSockets(NewBroadcastOptions())
+		elapsed := time.Since(start)
+
+		ids := make([]SocketID, len(sockets))
+		for i, socket := range sockets {
+			ids[i] = socket.ID()
+		}
+		require.ElementsMatch(t, []SocketID{"s1", "s2"}, ids)
+		require.GreaterOrEqual(t, elapsed, 300*time.Millisecond)
+		require.Less(t, elapsed, 2*time.Second)
+
+		select {
+		case err := <-errs:
+			require.ErrorIs(t, err, ErrRequestTimeout)
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout exceeded")
+		}
+	})
+
+	t.Run("should make the sockets of the other nodes join and leave rooms", func(t *testing.T) {
+		nodes := newNodes(t, 2, nil)
+		n1, n2 := nodes[0], nodes[1]
+
+		socket := NewTestSocket("s1")
+		n2.store.Set(socket)
+		n2.adapter.AddAll("s1", []Room{"s1"})
+
+		opts := NewBroadcastOptions()
+		opts.Rooms.Add("s1")
+		n1.adapter.AddSockets(opts, "r1")
+		require.Eventually(t, func() bool {
+			return len(socket.CurrentRooms()) == 2
+		}, 3*time.Second, 10*time.Millisecond)
+
+		n1.adapter.DelSockets(opts, "r1")
+		require.Eventually(t, func() bool {
+			return len(socket.CurrentRooms()) == 1
+		}, 3*time.Second, 10*time.Millisecond)
+
+		n1.adapter.DisconnectSockets(opts, false)
+		require.Eventually(t, func() bool {
+			return !socket.IsConnected()
+		}, 3*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("should send server side events and receive their acknowledgements", func(t *testing.T) {
+		nodes := newNodes(t, 3, nil)
+
+		nodes[0].store.SetOnServerSideEmit(func(eventName string, v []any, ack func(response any)) {
+			t.Error("server side event was received by the sender")
+		})
+		onServerSideEmit := func(response string) func(eventName string, v []any, ack func(response any)) {
+			return func(eventName string, v []any, ack func(response any)) {
+				assert.Equal(t, "hello", eventName)
+				assert.Equal(t, []any{"world", float64(1)}, v)
+				require.NotNil(t, ack)
+				ack(response)
+			}
+		}
+		nodes[1].store.SetOnServerSideEmit(onServerSideEmit("hi from 2"))
+		nodes[2].store.SetOnServerSideEmit(onServerSideEmit("hi from 3"))
+
+		done := make(chan struct{})
+		header := &parser.PacketHeader{Type: parser.PacketTypeEvent, Namespace: "/"}
+		nodes[0].adapter.ServerSideEmitWithAck(header, []any{"hello", "world", 1}, 2*time.Second, func(err error, responses []any) {
+			defer close(done)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, []any{"hi from 2", "hi from 3"}, responses)
+		})
+
+		select {
+		case <-done:
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout exceeded")
+		}
+	})
+
+	t.Run("should time out when a node does not acknowledge a server side event", func(t *testing.T) {
+		nodes := newNodes(t, 3, nil)
+
+		nodes[1].store.SetOnServerSideEmit(func(eventName string, v []any, ack func(response any)) {
+			ack(1)
+		})
+
+		done := make(chan struct{})
+		start := time.Now()
+		header := &parser.PacketHeader{Type: parser.PacketTypeEvent, Namespace: "/"}
+		nodes[0].adapter.ServerSideEmitWithAck(header, []any{"hello"}, 300*time.Millisecond, func(err error, responses []any) {
+			defer close(done)
+			assert.ErrorIs(t, err, ErrRequestTimeout)
+			assert.Equal(t, []any{float64(1)}, responses)
+			assert.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond)
+		})
+
+		select {
+		case <-done:
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout exceeded")
+		}
+	})
+
+	t.Run("should collect the acknowledgements of the sockets of every node", func(t *testing.T) {
+		nodes := newNodes(t, 2, nil)
+		n1, n2 := nodes[0], nodes[1]
+
+		n1.store.Set(NewTestSocket("s1"))
+		n1.adapter.AddAll("s1", []Room{"s1", "r1"})
+		n2.store.Set(NewTestSocket("s2"))
+		n2.adapter.AddAll("s2", []Room{"s2", "r1"})
+		n2.store.Set(NewTestSocket("s3"))
+		n2.adapter.AddAll("s3", []Room{"s3"})
+
+		var (
+			mu  sync.Mutex
+			ids []SocketID
+		)
+		sendBuffers := func(store *TestSocketStore, response int) func(sid SocketID, buffers [][]byte) (ok bool) {
+			return func(sid SocketID, buffers [][]byte) (ok bool) {
+				mu.Lock()
+				ids = append(ids, sid)
+				mu.Unlock()
+				go store.Ack(sid, response)
+				return true
+			}
+		}
+		n1.store.SetSendBuffers(sendBuffers(n1.store, 1))
+		n2.store.SetSendBuffers(sendBuffers(n2.store, 2))
+
+		done := make(chan struct{})
+		b := NewBroadcastOperator("/", n1.adapter, nil)
+		b.To("r1").Timeout(2*time.Second).Emit("hello", func(err error, responses []int) {
+			defer close(done)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, []int{1, 2}, responses)
+		})
+
+		select {
+		case <-done:
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout exceeded")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		require.ElementsMatch(t, []SocketID{"s1", "s2"}, ids)
+	})
+}
+
+// A socket that delays the response of its node to FetchSockets until unblock is closed.
+type stalledTestSocket struct {
+	*TestSocket
+	unblock chan struct{}
+}
+
+func (s *stalledTestSocket) Handshake() *Handshake {
+	<-s.unblock
+	return s.TestSocket.Handshake()
+}
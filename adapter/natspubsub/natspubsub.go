@@ -0,0 +1,57 @@
+// Package natspubsub provides a NATS driver for the PubSub adapter.
+//
+// It is a separate module, so that only the applications that use it depend on NATS:
+//
+//	go get github.com/hhuuson97/socket.io-go/adapter/natspubsub
+//
+// Usage:
+//
+//	conn, err := nats.Connect(nats.DefaultURL)
+//	...
+//	io := sio.NewServer(&sio.ServerConfig{
+//		AdapterCreator: adapter.NewPubSubAdapterCreator(natspubsub.New(conn), nil),
+//	})
+package natspubsub
+
+import (
+	"github.com/hhuuson97/socket.io-go/adapter"
+	"github.com/nats-io/nats.go"
+)
+
+type pubSub struct {
+	conn *nats.Conn
+}
+
+var _ adapter.PubSub = &pubSub{}
+
+// The connection is not closed by the adapters.
+func New(conn *nats.Conn) adapter.PubSub {
+	return &pubSub{conn: conn}
+}
+
+func (p *pubSub) Publish(subject string, data []byte) error {
+	return p.conn.Publish(subject, data)
+}
+
+func (p *pubSub) PublishRequest(subject, reply string, data []byte) error {
+	return p.conn.PublishRequest(subject, reply, data)
+}
+
+// The messages of an asynchronous NATS subscription are delivered in order, by a single goroutine.
+func (p *pubSub) Subscribe(subject string, handler func(msg *adapter.PubSubMessage)) (adapter.PubSubSubscription, error) {
+	sub, err := p.conn.Subscribe(subject, func(msg *nats.Msg) {
+		handler(&adapter.PubSubMessage{
+			Subject: msg.Subject,
+			Reply:   msg.Reply,
+			Data:    msg.Data,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (p *pubSub) NewInbox() string {
+	return p.conn.NewRespInbox()
+}
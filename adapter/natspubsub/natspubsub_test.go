@@ -0,0 +1,91 @@
+package natspubsub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hhuuson97/socket.io-go/adapter"
+	"github.com/hhuuson97/socket.io-go/parser"
+	jsonparser "github.com/hhuuson97/socket.io-go/parser/json"
+	"github.com/hhuuson97/socket.io-go/parser/json/serializer/stdjson"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNATSPubSub(t *testing.T) {
+	t.Run("should broadcast to the sockets of the other nodes", func(t *testing.T) {
+		url := runTestServer(t)
+		a1, _ := newTestAdapter(t, url)
+		a2, store2 := newTestAdapter(t, url)
+
+		store2.Set(adapter.NewTestSocket("s1"))
+		a2.AddAll("s1", []adapter.Room{"s1", "r1"})
+
+		// The subscriptions are active once the heartbeats are received.
+		require.Eventually(t, func() bool {
+			return a1.ServerCount() == 2 && a2.ServerCount() == 2
+		}, 3*time.Second, 10*time.Millisecond)
+
+		received := make(chan string, 1)
+		store2.SetSendBuffers(func(sid adapter.SocketID, buffers [][]byte) (ok bool) {
+			received <- string(buffers[0])
+			return true
+		})
+
+		header := &parser.PacketHeader{Type: parser.PacketTypeEvent, Namespace: "/"}
+		opts := adapter.NewBroadcastOptions()
+		opts.Rooms.Add("r1")
+		a1.Broadcast(header, []any{"hello"}, opts)
+
+		select {
+		case buf := <-received:
+			require.Equal(t, `2["hello"]`, buf)
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout exceeded")
+		}
+	})
+
+	t.Run("should fetch the sockets of the other nodes", func(t *testing.T) {
+		url := runTestServer(t)
+		a1, _ := newTestAdapter(t, url)
+		a2, store2 := newTestAdapter(t, url)
+
+		store2.Set(adapter.NewTestSocket("s1"))
+		a2.AddAll("s1", []adapter.Room{"s1"})
+
+		require.Eventually(t, func() bool {
+			return a1.ServerCount() == 2
+		}, 3*time.Second, 10*time.Millisecond)
+
+		sockets := a1.FetchSockets(adapter.NewBroadcastOptions())
+		require.Len(t, sockets, 1)
+		assert.Equal(t, adapter.SocketID("s1"), sockets[0].ID())
+	})
+}
+
+// Runs an embedded NATS server and returns its URL.
+func runTestServer(t *testing.T) string {
+	s, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: server.RANDOM_PORT, NoSigs: true})
+	require.NoError(t, err)
+	go s.Start()
+	require.True(t, s.ReadyForConnections(5*time.Second))
+	t.Cleanup(s.Shutdown)
+	return s.ClientURL()
+}
+
+func newTestAdapter(t *testing.T, url string) (adapter.Adapter, *adapter.TestSocketStore) {
+	conn, err := nats.Connect(url)
+	require.NoError(t, err)
+	t.Cleanup(conn.Close)
+
+	store := adapter.NewTestSocketStore()
+	creator := adapter.NewPubSubAdapterCreator(New(conn), &adapter.PubSubAdapterOptions{
+		RequestsTimeout: 2 * time.Second,
+		ErrorHandler:    func(err error) { t.Error(err) },
+	})
+	a := creator(store, jsonparser.NewCreator(0, stdjson.New()))
+	t.Cleanup(a.Close)
+	return a, store
+}
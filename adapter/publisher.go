@@ -0,0 +1,52 @@
+package adapter
+
+import (
+	"time"
+
+	"github.com/hhuuson97/socket.io-go/parser"
+)
+
+// Creates the adapter used to publish to the nodes of a cluster
+// from a process that is not a node itself (see the emitter package).
+//
+// The adapter has no sockets and receives nothing. Only the methods that send
+// to the other nodes are of use: Broadcast, AddSockets, DelSockets,
+// DisconnectSockets and ServerSideEmit.
+type PublisherCreator func(nsp string, parserCreator parser.Creator) Adapter
+
+// The socket store of a publisher adapter. It is always empty.
+type publisherSocketStore struct {
+	nsp string
+}
+
+var _ SocketStore = newPublisherSocketStore("/")
+
+func newPublisherSocketStore(nsp string) *publisherSocketStore {
+	return &publisherSocketStore{nsp: nsp}
+}
+
+func (s *publisherSocketStore) SendBuffers(sid SocketID, buffers [][]byte) (ok bool) { return false }
+
+func (s *publisherSocketStore) Get(sid SocketID) (so Socket, ok bool) { return nil, false }
+
+func (s *publisherSocketStore) GetAll() []Socket { return nil }
+
+func (s *publisherSocketStore) Remove(sid SocketID) {}
+
+func (s *publisherSocketStore) Namespace() string { return s.nsp }
+
+func (s *publisherSocketStore) OnServerSideEmit(eventName string, v []any, ack func(response any)) {}
+
+func (s *publisherSocketStore) NextAckID() uint64 { return 0 }
+
+func (s *publisherSocketStore) RegisterAck(sid SocketID, id uint64, timeout time.Duration, ack func(response any)) (ok bool) {
+	return false
+}
+
+func (s *publisherSocketStore) OnCreateRoom(room Room) {}
+
+func (s *publisherSocketStore) OnDeleteRoom(room Room) {}
+
+func (s *publisherSocketStore) OnJoinRoom(room Room, sid SocketID) {}
+
+func (s *publisherSocketStore) OnLeaveRoom(room Room, sid SocketID) {}
@@ -0,0 +1,155 @@
+package adapter
+
+import (
+	"github.com/hhuuson97/socket.io-go/internal/sync"
+)
+
+type (
+	// PubSub is the message bus used by the adapter created by NewPubSubAdapterCreator.
+	//
+	// Have a look at NewInProcessPubSub, and at the natspubsub package for a NATS driver.
+	PubSub interface {
+		// Sends the data to the subscribers of the subject.
+		Publish(subject string, data []byte) error
+
+		// Sends the data to the subscribers of the subject.
+		// The subscribers can respond by publishing to the reply subject.
+		PublishRequest(subject, reply string, data []byte) error
+
+		// handler is called for every message published to the subject.
+		// Messages of a subscription must be handled in the order they were published.
+		Subscribe(subject string, handler func(msg *PubSubMessage)) (PubSubSubscription, error)
+
+		// Returns a unique subject that can be used as the reply subject of the requests.
+		NewInbox() string
+	}
+
+	PubSubSubscription interface {
+		Unsubscribe() error
+	}
+
+	PubSubMessage struct {
+		Subject string
+		// The subject to respond to. It is empty if no response is expected.
+		Reply string
+		Data  []byte
+	}
+)
+
+type (
+	inProcessPubSub struct {
+		subscriptions map[string]map[*inProcessSubscription]struct{}
+		mu            sync.Mutex
+	}
+
+	inProcessSubscription struct {
+		pubSub  *inProcessPubSub
+		subject string
+		handler func(msg *PubSubMessage)
+
+		queue  []*PubSubMessage
+		mu     sync.Mutex
+		signal chan struct{}
+		done   chan struct{}
+		once   sync.Once
+	}
+)
+
+// Returns a PubSub that delivers the messages within the process.
+// Adapters that share it behave like the nodes of a cluster,
+// which is handy for testing without a message broker.
+func NewInProcessPubSub() PubSub {
+	return &inProcessPubSub{
+		subscriptions: make(map[string]map[*inProcessSubscription]struct{}),
+	}
+}
+
+func (p *inProcessPubSub) Publish(subject string, data []byte) error {
+	return p.PublishRequest(subject, "", data)
+}
+
+func (p *inProcessPubSub) PublishRequest(subject, reply string, data []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for sub := range p.subscriptions[subject] {
+		// Every subscriber gets its own copy.
+		b := make([]byte, len(data))
+		copy(b, data)
+		sub.push(&PubSubMessage{Subject: subject, Reply: reply, Data: b})
+	}
+	return nil
+}
+
+func (p *inProcessPubSub) Subscribe(subject string, handler func(msg *PubSubMessage)) (PubSubSubscription, error) {
+	sub := &inProcessSubscription{
+		pubSub:  p,
+		subject: subject,
+		handler: handler,
+		signal:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+
+	p.mu.Lock()
+	subs, ok := p.subscriptions[subject]
+	if !ok {
+		subs = make(map[*inProcessSubscription]struct{})
+		p.subscriptions[subject] = subs
+	}
+	subs[sub] = struct{}{}
+	p.mu.Unlock()
+
+	go sub.run()
+	return sub, nil
+}
+
+func (p *inProcessPubSub) NewInbox() string {
+	return "_INBOX." + newUID()
+}
+
+// The queue is unbounded, so that publishing never blocks.
+func (s *inProcessSubscription) push(msg *PubSubMessage) {
+	s.mu.Lock()
+	s.queue = append(s.queue, msg)
+	s.mu.Unlock()
+
+	select {
+	case s.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (s *inProcessSubscription) run() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.signal:
+		}
+
+		s.mu.Lock()
+		queue := s.queue
+		s.queue = nil
+		s.mu.Unlock()
+
+		for _, msg := range queue {
+			select {
+			case <-s.done:
+				return
+			default:
+			}
+			s.handler(msg)
+		}
+	}
+}
+
+func (s *inProcessSubscription) Unsubscribe() error {
+	s.pubSub.mu.Lock()
+	delete(s.pubSub.subscriptions[s.subject], s)
+	if len(s.pubSub.subscriptions[s.subject]) == 0 {
+		delete(s.pubSub.subscriptions, s.subject)
+	}
+	s.pubSub.mu.Unlock()
+
+	s.once.Do(func() { close(s.done) })
+	return nil
+}
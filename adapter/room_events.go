@@ -0,0 +1,42 @@
+package adapter
+
+type roomEventType int
+
+const (
+	roomEventCreate roomEventType = iota
+	roomEventDelete
+	roomEventJoin
+	roomEventLeave
+)
+
+type (
+	roomEvent struct {
+		typ  roomEventType
+		room Room
+		sid  SocketID
+	}
+
+	// The changes of the rooms are collected while the adapter is locked,
+	// and they are dispatched to the SocketStore after it is unlocked,
+	// so that the handlers can use the adapter.
+	roomEvents []roomEvent
+)
+
+func (e *roomEvents) add(typ roomEventType, room Room, sid SocketID) {
+	*e = append(*e, roomEvent{typ: typ, room: room, sid: sid})
+}
+
+func (e *roomEvents) emit(store SocketStore) {
+	for _, event := range *e {
+		switch event.typ {
+		case roomEventCreate:
+			store.OnCreateRoom(event.room)
+		case roomEventDelete:
+			store.OnDeleteRoom(event.room)
+		case roomEventJoin:
+			store.OnJoinRoom(event.room, event.sid)
+		case roomEventLeave:
+			store.OnLeaveRoom(event.room, event.sid)
+		}
+	}
+}
@@ -1,8 +1,23 @@
 package adapter
 
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
 type Socket interface {
 	ID() SocketID
 
+	// Handshake details of the socket.
+	Handshake() *Handshake
+
+	// Arbitrary data attached to the socket.
+	Data() *SocketData
+
 	// Join room(s)
 	Join(room ...Room)
 	// Leave a room
@@ -38,3 +53,147 @@ type Socket interface {
 	// and the underlying Engine.IO connection will be kept open.
 	Disconnect(close bool)
 }
+
+type Handshake struct {
+	// Headers of the HTTP request that initiated the connection (the Engine.IO handshake).
+	Headers http.Header
+
+	// Query parameters of the handshake request.
+	Query url.Values
+
+	// IP address of the client.
+	Address string
+
+	// Whether the connection is cross-domain (the Origin header is set).
+	XDomain bool
+
+	// Whether the connection is secure (TLS).
+	Secure bool
+
+	// Date of creation
+	Time time.Time
+
+	// Request URI (path and query string) of the handshake request.
+	URL string
+
+	// TLS state of the connection. It is nil if the connection is not secure.
+	// It is local to the current node and it is not sent to the other nodes.
+	TLS *tls.ConnectionState
+
+	// Authentication data
+	Auth json.RawMessage
+}
+
+// The JSON representation of the handshake is compatible with
+// the one used by the official Socket.IO adapters, so that
+// the handshake can be exchanged with Node.js servers.
+type handshakeJSON struct {
+	Headers map[string]any  `json:"headers,omitempty"`
+	Time    string          `json:"time,omitempty"`
+	Address string          `json:"address,omitempty"`
+	XDomain bool            `json:"xdomain"`
+	Secure  bool            `json:"secure"`
+	Issued  int64           `json:"issued,omitempty"`
+	URL     string          `json:"url,omitempty"`
+	Query   map[string]any  `json:"query,omitempty"`
+	Auth    json.RawMessage `json:"auth,omitempty"`
+}
+
+func (h Handshake) MarshalJSON() ([]byte, error) {
+	j := handshakeJSON{
+		Headers: headersToJSON(h.Headers),
+		Address: h.Address,
+		XDomain: h.XDomain,
+		Secure:  h.Secure,
+		URL:     h.URL,
+		Query:   valuesToJSON(h.Query),
+		Auth:    h.Auth,
+	}
+	if !h.Time.IsZero() {
+		j.Time = h.Time.Format(time.RFC1123)
+		j.Issued = h.Time.UnixMilli()
+	}
+	return json.Marshal(&j)
+}
+
+func (h *Handshake) UnmarshalJSON(data []byte) error {
+	var j handshakeJSON
+	err := json.Unmarshal(data, &j)
+	if err != nil {
+		return err
+	}
+	h.Time = time.Time{}
+	if j.Issued != 0 {
+		h.Time = time.UnixMilli(j.Issued)
+	}
+	h.Headers = nil
+	if j.Headers != nil {
+		h.Headers = http.Header(valuesFromJSON(j.Headers, http.CanonicalHeaderKey))
+	}
+	h.Query = nil
+	if j.Query != nil {
+		h.Query = valuesFromJSON(j.Query, func(key string) string { return key })
+	}
+	h.Address = j.Address
+	h.XDomain = j.XDomain
+	h.Secure = j.Secure
+	h.URL = j.URL
+	h.TLS = nil
+	h.Auth = j.Auth
+	return nil
+}
+
+// Node.js lowercases the names of the headers and joins the values of
+// a repeated header into a single string, except for the Set-Cookie header.
+func headersToJSON(headers http.Header) map[string]any {
+	if headers == nil {
+		return nil
+	}
+	m := make(map[string]any, len(headers))
+	for key, values := range headers {
+		key = strings.ToLower(key)
+		switch {
+		case key == "set-cookie":
+			m[key] = values
+		case key == "cookie":
+			m[key] = strings.Join(values, "; ")
+		default:
+			m[key] = strings.Join(values, ", ")
+		}
+	}
+	return m
+}
+
+// A single value is a string, and a repeated value is an array of strings (as in Node.js).
+func valuesToJSON(values url.Values) map[string]any {
+	if values == nil {
+		return nil
+	}
+	m := make(map[string]any, len(values))
+	for key, v := range values {
+		if len(v) == 1 {
+			m[key] = v[0]
+		} else {
+			m[key] = v
+		}
+	}
+	return m
+}
+
+func valuesFromJSON(m map[string]any, canonicalKey func(key string) string) url.Values {
+	values := make(url.Values, len(m))
+	for key, v := range m {
+		key = canonicalKey(key)
+		switch v := v.(type) {
+		case string:
+			values[key] = append(values[key], v)
+		case []any:
+			for _, s := range v {
+				if s, ok := s.(string); ok {
+					values[key] = append(values[key], s)
+				}
+			}
+		}
+	}
+	return values
+}
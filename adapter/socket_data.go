@@ -0,0 +1,117 @@
+package adapter
+
+import (
+	"encoding/json"
+
+	"github.com/hhuuson97/socket.io-go/internal/sync"
+)
+
+// Arbitrary data attached to a socket (the equivalent of `socket.data` of Socket.IO for Node.js).
+// It is safe for concurrent use.
+//
+// The values must be serializable to JSON. The data is persisted with the session
+// when connection state recovery is enabled, and it is sent to the other nodes of the cluster
+// upon FetchSockets. The values that went through JSON have the types of encoding/json
+// (map[string]any, []any, float64, and so on), use GetSocketData to get a typed value.
+type SocketData struct {
+	values map[string]any
+	mu     sync.RWMutex
+}
+
+func NewSocketData() *SocketData {
+	return &SocketData{values: make(map[string]any)}
+}
+
+func (d *SocketData) Get(key string) (value any, ok bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	value, ok = d.values[key]
+	return
+}
+
+func (d *SocketData) Set(key string, value any) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.values[key] = value
+}
+
+// Sets the value only if the key is not set yet. ok is false if the key was already set.
+func (d *SocketData) SetIfAbsent(key string, value any) (ok bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, exists := d.values[key]
+	if exists {
+		return false
+	}
+	d.values[key] = value
+	return true
+}
+
+func (d *SocketData) Delete(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.values, key)
+}
+
+func (d *SocketData) Keys() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	keys := make([]string, 0, len(d.values))
+	for key := range d.values {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (d *SocketData) Len() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.values)
+}
+
+func (d *SocketData) MarshalJSON() ([]byte, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return json.Marshal(d.values)
+}
+
+func (d *SocketData) UnmarshalJSON(data []byte) error {
+	var values map[string]any
+	err := json.Unmarshal(data, &values)
+	if err != nil {
+		return err
+	}
+	if values == nil {
+		values = make(map[string]any)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.values = values
+	return nil
+}
+
+// Returns the value of the key as T.
+//
+// If the value is not of type T (for example, when it was restored from a session,
+// or when the socket is a remote socket), it is converted through JSON.
+// ok is false if the key is not set or if the value cannot be converted.
+func GetSocketData[T any](d *SocketData, key string) (value T, ok bool) {
+	v, ok := d.Get(key)
+	if !ok {
+		return value, false
+	}
+	value, ok = v.(T)
+	if ok {
+		return value, true
+	}
+	var zero T
+	data, err := json.Marshal(v)
+	if err != nil {
+		return zero, false
+	}
+	err = json.Unmarshal(data, &value)
+	if err != nil {
+		return zero, false
+	}
+	return value, true
+}
@@ -0,0 +1,103 @@
+package adapter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandshakeJSON(t *testing.T) {
+	t.Run("should be compatible with the handshake of Node.js", func(t *testing.T) {
+		handshake := Handshake{
+			Headers: http.Header{
+				"Accept":     {"text/html", "application/json"},
+				"Cookie":     {"a=1", "b=2"},
+				"Set-Cookie": {"c=3"},
+			},
+			Query:   url.Values{"EIO": {"4"}, "foo": {"bar", "baz"}},
+			Address: "127.0.0.1",
+			XDomain: true,
+			URL:     "/socket.io/?EIO=4&transport=polling",
+			Time:    time.UnixMilli(1700000000000),
+			Auth:    json.RawMessage(`{"token":"123"}`),
+		}
+
+		data, err := json.Marshal(handshake)
+		require.NoError(t, err)
+
+		var m map[string]any
+		require.NoError(t, json.Unmarshal(data, &m))
+		assert.Equal(t, map[string]any{
+			"accept":     "text/html, application/json",
+			"cookie":     "a=1; b=2",
+			"set-cookie": []any{"c=3"},
+		}, m["headers"])
+		assert.Equal(t, map[string]any{"EIO": "4", "foo": []any{"bar", "baz"}}, m["query"])
+		assert.Equal(t, "127.0.0.1", m["address"])
+		assert.Equal(t, true, m["xdomain"])
+		assert.Equal(t, false, m["secure"])
+		assert.Equal(t, float64(1700000000000), m["issued"])
+		assert.Equal(t, "/socket.io/?EIO=4&transport=polling", m["url"])
+
+		var decoded Handshake
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, "text/html, application/json", decoded.Headers.Get("Accept"))
+		assert.Equal(t, []string{"c=3"}, decoded.Headers["Set-Cookie"])
+		assert.Equal(t, handshake.Query, decoded.Query)
+		assert.Equal(t, handshake.Address, decoded.Address)
+		assert.True(t, decoded.XDomain)
+		assert.Equal(t, handshake.URL, decoded.URL)
+		assert.True(t, handshake.Time.Equal(decoded.Time))
+		assert.JSONEq(t, string(handshake.Auth), string(decoded.Auth))
+	})
+}
+
+func TestSocketData(t *testing.T) {
+	t.Run("should get the typed values", func(t *testing.T) {
+		type user struct {
+			Name string `json:"name"`
+		}
+		data := NewSocketData()
+		data.Set("user", user{Name: "alice"})
+		require.True(t, data.SetIfAbsent("count", 1))
+		require.False(t, data.SetIfAbsent("count", 2))
+
+		u, ok := GetSocketData[user](data, "user")
+		require.True(t, ok)
+		assert.Equal(t, "alice", u.Name)
+		count, ok := GetSocketData[int](data, "count")
+		require.True(t, ok)
+		assert.Equal(t, 1, count)
+		_, ok = GetSocketData[int](data, "user")
+		assert.False(t, ok)
+		_, ok = GetSocketData[int](data, "unknown")
+		assert.False(t, ok)
+
+		data.Delete("count")
+		assert.Equal(t, []string{"user"}, data.Keys())
+	})
+
+	t.Run("should be persisted with the session", func(t *testing.T) {
+		data := NewSocketData()
+		data.Set("user", map[string]any{"name": "alice"})
+		session := &SessionToPersist{SID: "s1", PID: "p1", Rooms: []Room{"r1"}, Data: data}
+
+		b, err := session.MarshalBinary()
+		require.NoError(t, err)
+		var restored SessionToPersist
+		require.NoError(t, restored.UnmarshalBinary(b))
+
+		require.NotNil(t, restored.Data)
+		type user struct {
+			Name string `json:"name"`
+		}
+		u, ok := GetSocketData[user](restored.Data, "user")
+		require.True(t, ok)
+		assert.Equal(t, "alice", u.Name)
+	})
+}
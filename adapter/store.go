@@ -1,5 +1,7 @@
 package adapter
 
+import "time"
+
 type SocketStore interface {
 	// Send Engine.IO packets to a specific socket.
 	SendBuffers(sid SocketID, buffers [][]byte) (ok bool)
@@ -8,4 +10,34 @@ type SocketStore interface {
 	GetAll() []Socket
 
 	Remove(sid SocketID)
+
+	// Name of the namespace the sockets belong to.
+	Namespace() string
+
+	// Dispatch a server side event received from another node of the cluster
+	// to the event handlers of the namespace.
+	//
+	// If the sender expects an acknowledgement, ack is non-nil
+	// and it should be called with the response of this node.
+	OnServerSideEmit(eventName string, v []any, ack func(response any))
+
+	// Returns a new acknowledgement ID. IDs are unique within the namespace.
+	NextAckID() uint64
+
+	// Register the handler of the acknowledgement of the packet with the given ID, sent to a specific socket.
+	// ack is called with the first argument of the acknowledgement, unless the timeout is reached first
+	// (0 means there is no timeout).
+	RegisterAck(sid SocketID, id uint64, timeout time.Duration, ack func(response any)) (ok bool)
+
+	// Called when a room is created, that is, when the first socket joins it.
+	OnCreateRoom(room Room)
+
+	// Called when a room is deleted, that is, when the last socket leaves it.
+	OnDeleteRoom(room Room)
+
+	// Called when a socket joins a room.
+	OnJoinRoom(room Room, sid SocketID)
+
+	// Called when a socket leaves a room.
+	OnLeaveRoom(room Room, sid SocketID)
 }
@@ -1,6 +1,8 @@
 package adapter
 
 import (
+	"time"
+
 	"github.com/hhuuson97/socket.io-go/internal/sync"
 )
 
@@ -8,14 +10,26 @@ type TestSocketStore struct {
 	sockets     map[SocketID]Socket
 	mu          sync.Mutex
 	sendBuffers func(sid SocketID, buffers [][]byte) (ok bool)
+
+	ackID uint64
+	acks  map[SocketID][]func(response any)
+
+	nsp              string
+	onServerSideEmit func(eventName string, v []any, ack func(response any))
+	// eventName is one of: "create-room", "delete-room", "join-room" and "leave-room".
+	onRoomEvent func(eventName string, room Room, sid SocketID)
 }
 
 var _ SocketStore = NewTestSocketStore()
 
 func NewTestSocketStore() *TestSocketStore {
 	return &TestSocketStore{
-		sockets:     make(map[SocketID]Socket),
-		sendBuffers: func(sid SocketID, buffers [][]byte) (ok bool) { return true },
+		sockets:          make(map[SocketID]Socket),
+		acks:             make(map[SocketID][]func(response any)),
+		sendBuffers:      func(sid SocketID, buffers [][]byte) (ok bool) { return true },
+		nsp:              "/",
+		onServerSideEmit: func(eventName string, v []any, ack func(response any)) {},
+		onRoomEvent:      func(eventName string, room Room, sid SocketID) {},
 	}
 }
 
@@ -27,6 +41,70 @@ func (s *TestSocketStore) SetSendBuffers(sendBuffers func(sid SocketID, buffers
 	s.sendBuffers = sendBuffers
 }
 
+func (s *TestSocketStore) Namespace() string { return s.nsp }
+
+func (s *TestSocketStore) SetNamespace(nsp string) { s.nsp = nsp }
+
+func (s *TestSocketStore) OnServerSideEmit(eventName string, v []any, ack func(response any)) {
+	s.onServerSideEmit(eventName, v, ack)
+}
+
+func (s *TestSocketStore) SetOnServerSideEmit(onServerSideEmit func(eventName string, v []any, ack func(response any))) {
+	s.onServerSideEmit = onServerSideEmit
+}
+
+func (s *TestSocketStore) NextAckID() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.ackID
+	s.ackID++
+	return id
+}
+
+// The timeout is ignored. Use Ack to acknowledge.
+func (s *TestSocketStore) RegisterAck(sid SocketID, id uint64, timeout time.Duration, ack func(response any)) (ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sockets[sid]; !ok {
+		return false
+	}
+	s.acks[sid] = append(s.acks[sid], ack)
+	return true
+}
+
+// Calls the acknowledgement handlers registered for the socket, as if the client acknowledged with response.
+func (s *TestSocketStore) Ack(sid SocketID, response any) (ok bool) {
+	s.mu.Lock()
+	acks := s.acks[sid]
+	delete(s.acks, sid)
+	s.mu.Unlock()
+
+	for _, ack := range acks {
+		ack(response)
+	}
+	return len(acks) > 0
+}
+
+func (s *TestSocketStore) OnCreateRoom(room Room) {
+	s.onRoomEvent("create-room", room, "")
+}
+
+func (s *TestSocketStore) OnDeleteRoom(room Room) {
+	s.onRoomEvent("delete-room", room, "")
+}
+
+func (s *TestSocketStore) OnJoinRoom(room Room, sid SocketID) {
+	s.onRoomEvent("join-room", room, sid)
+}
+
+func (s *TestSocketStore) OnLeaveRoom(room Room, sid SocketID) {
+	s.onRoomEvent("leave-room", room, sid)
+}
+
+func (s *TestSocketStore) SetOnRoomEvent(onRoomEvent func(eventName string, room Room, sid SocketID)) {
+	s.onRoomEvent = onRoomEvent
+}
+
 func (s *TestSocketStore) Get(sid SocketID) (so Socket, ok bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -60,10 +138,15 @@ func (s *TestSocketStore) Remove(sid SocketID) {
 }
 
 type TestSocket struct {
-	id SocketID
+	id        SocketID
+	handshake *Handshake
+	data      *SocketData
 
 	Rooms     []Room
 	Connected bool
+
+	// Guards Rooms and Connected, which the cluster adapters change from their own goroutines.
+	mu sync.Mutex
 }
 
 var _ Socket = NewTestSocket("")
@@ -71,6 +154,8 @@ var _ Socket = NewTestSocket("")
 func NewTestSocket(id SocketID) *TestSocket {
 	return &TestSocket{
 		id:        id,
+		handshake: &Handshake{},
+		data:      NewSocketData(),
 		Connected: true,
 		Rooms:     []Room{Room(id)},
 	}
@@ -78,11 +163,35 @@ func NewTestSocket(id SocketID) *TestSocket {
 
 func (s *TestSocket) ID() SocketID { return s.id }
 
+func (s *TestSocket) Handshake() *Handshake { return s.handshake }
+
+func (s *TestSocket) Data() *SocketData { return s.data }
+
+// Same as Rooms, safe to call while an adapter changes the rooms from another goroutine.
+func (s *TestSocket) CurrentRooms() []Room {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rooms := make([]Room, len(s.Rooms))
+	copy(rooms, s.Rooms)
+	return rooms
+}
+
+// Same as Connected, safe to call while an adapter disconnects the socket from another goroutine.
+func (s *TestSocket) IsConnected() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Connected
+}
+
 func (s *TestSocket) Join(room ...Room) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.Rooms = append(s.Rooms, room...)
 }
 
 func (s *TestSocket) Leave(room Room) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	remove := func(slice []Room, s int) []Room {
 		return append(slice[:s], slice[s+1:]...)
 	}
@@ -104,5 +213,7 @@ func (s *TestSocket) Except(room ...Room) *BroadcastOperator { return nil }
 func (s *TestSocket) Broadcast() *BroadcastOperator { return nil }
 
 func (s *TestSocket) Disconnect(close bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.Connected = false
 }
@@ -95,7 +95,7 @@ func (pq *clientPacketQueue) addToQueue(header *parser.PacketHeader, v []any) {
 		}
 		in, variadic := dismantleAckFunc(rt)
 		ackF := reflect.MakeFunc(reflect.FuncOf(in, nil, variadic), replacementAck)
-		f = ackF.Interface()
+		f = ackF.Convert(rt).Interface()
 	} else {
 		in := []reflect.Type{reflectError}
 		ackF := reflect.MakeFunc(reflect.FuncOf(in, nil, false), replacementAck)
@@ -1,6 +1,7 @@
 package sio
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"reflect"
@@ -62,6 +63,8 @@ type (
 		connectHandlers      *handlerStore[*ClientSocketConnectFunc]
 		connectErrorHandlers *handlerStore[*ClientSocketConnectErrorFunc]
 		disconnectHandlers   *handlerStore[*ClientSocketDisconnectFunc]
+		anyEventHandlers     *handlerStore[*AnyEventFunc]
+		anyOutgoingHandlers  *handlerStore[*AnyEventFunc]
 
 		acks   map[uint64]*ackHandler
 		ackID  uint64
@@ -107,6 +110,8 @@ func newClientSocket(
 		connectHandlers:      newHandlerStore[*ClientSocketConnectFunc](),
 		connectErrorHandlers: newHandlerStore[*ClientSocketConnectErrorFunc](),
 		disconnectHandlers:   newHandlerStore[*ClientSocketDisconnectFunc](),
+		anyEventHandlers:     newHandlerStore[*AnyEventFunc](),
+		anyOutgoingHandlers:  newHandlerStore[*AnyEventFunc](),
 	}
 	s.sendBuffers = s._sendBuffers
 	s.debug = manager.debug.WithContext("[sio/client] Socket (nsp: `" + namespace + "`)")
@@ -354,8 +359,16 @@ func (s *clientSocket) onPacket(header *parser.PacketHeader, eventName string, d
 			s.sendAckPacket(ackID, values)
 		}
 
+		err := callAnyEventHandlers(s.anyEventHandlers, eventName, decode)
+		if err != nil {
+			s.onError(wrapInternalError(err))
+		}
+
 		for _, handler := range s.eventHandlers.getAll(eventName) {
-			s.onEvent(handler, header, decode, sendAck)
+			s.onEvent(handler, header, eventName, decode, sendAck)
+		}
+		for _, handler := range s.eventHandlers.getAllPatterns(eventName) {
+			s.onEvent(handler, header, eventName, decode, sendAck)
 		}
 	case parser.PacketTypeAck, parser.PacketTypeBinaryAck:
 		s.onAck(header, decode)
@@ -456,7 +469,7 @@ func (s *clientSocket) emitBuffered() {
 			s.sendAckPacket(ackID, values)
 		}
 
-		hasAckFunc := s.callEvent(event.handler, event.header, event.values, sendAck)
+		hasAckFunc := s.callEvent(event, sendAck)
 
 		if event.header.ID != nil {
 			mu.Lock()
@@ -535,6 +548,8 @@ type clientEvent struct {
 	handler *eventHandler
 	header  *parser.PacketHeader
 	values  []reflect.Value
+	// Set instead of values for the handlers registered with On and OnAck.
+	typed typedEvent
 }
 
 type ackSendFunc = func(id uint64, values []reflect.Value)
@@ -542,49 +557,63 @@ type ackSendFunc = func(id uint64, values []reflect.Value)
 func (s *clientSocket) onEvent(
 	handler *eventHandler,
 	header *parser.PacketHeader,
+	eventName string,
 	decode parser.Decode,
 	sendAck ackSendFunc,
 ) (hasAckFunc bool) {
-	values, err := decode(handler.inputArgs...)
-	if err != nil {
-		s.onError(wrapInternalError(err))
-		return
+	event := &clientEvent{
+		handler: handler,
+		header:  header,
 	}
-
-	if len(values) == len(handler.inputArgs) {
-		for i, v := range values {
-			if handler.inputArgs[i].Kind() != reflect.Ptr && v.Kind() == reflect.Ptr {
-				values[i] = v.Elem()
-			}
+	if handler.decodeTyped != nil {
+		typed, err := handler.decodeTyped(decode)
+		if err != nil {
+			s.onError(wrapInternalError(err))
+			return
 		}
+		event.typed = typed
 	} else {
-		s.onError(fmt.Errorf("sio: onEvent: invalid number of arguments"))
-		return
+		values, err := handler.decodeArgs(decode)
+		if err != nil {
+			s.onError(wrapInternalError(err))
+			return
+		}
+
+		if handler.hasEventName {
+			values = append([]reflect.Value{reflect.ValueOf(eventName).Convert(handler.inputArgs[0])}, values...)
+		}
+		event.values = values
 	}
 
 	s.stateMu.RLock()
 	connected := s.state == clientSocketConnStateConnected
 	s.stateMu.RUnlock()
 	if connected {
-		return s.callEvent(handler, header, values, sendAck)
+		return s.callEvent(event, sendAck)
 	} else {
 		s.receiveBufferMu.Lock()
 		defer s.receiveBufferMu.Unlock()
-		s.receiveBuffer = append(s.receiveBuffer, &clientEvent{
-			handler: handler,
-			header:  header,
-			values:  values,
-		})
+		s.receiveBuffer = append(s.receiveBuffer, event)
 	}
 	return
 }
 
-func (s *clientSocket) callEvent(
-	handler *eventHandler,
-	header *parser.PacketHeader,
-	values []reflect.Value,
-	sendAck ackSendFunc,
-) (hasAckFunc bool) {
+func (s *clientSocket) callEvent(event *clientEvent, sendAck ackSendFunc) (hasAckFunc bool) {
+	handler, header, values := event.handler, event.header, event.values
+	ack, _ := handler.ack()
+	if event.typed != nil {
+		var typedSendAck func(args []reflect.Value)
+		if header.ID != nil && ack {
+			hasAckFunc = true
+			typedSendAck = func(args []reflect.Value) { sendAck(*header.ID, args) }
+		}
+		err := handler.callTyped(event.typed, typedSendAck)
+		if err != nil {
+			s.onError(wrapInternalError(err))
+		}
+		return
+	}
+
 	// Set the lastOffset before calling the handler.
 	// An error can occur when the handler gets called,
 	// and we can miss setting the lastOffset.
@@ -594,7 +623,6 @@ func (s *clientSocket) callEvent(
 		values = values[:len(values)-1] // Remove offset
 	}
 
-	ack, _ := handler.ack()
 	if header.ID != nil && ack {
 		hasAckFunc = true
 
@@ -605,6 +633,9 @@ func (s *clientSocket) callEvent(
 		rt := reflect.FuncOf(in, nil, variadic)
 
 		f = reflect.MakeFunc(rt, func(args []reflect.Value) (results []reflect.Value) {
+			if variadic {
+				args = expandVariadicArgs(args)
+			}
 			sendAck(*header.ID, args)
 			return nil
 		})
@@ -639,24 +670,17 @@ func (s *clientSocket) onAck(header *parser.PacketHeader, decode parser.Decode)
 		return
 	}
 
-	inputArgs := ack.inputArgs
-	if ack.hasError {
-		inputArgs = ack.inputArgs[1:]
-	}
-	values, err := decode(inputArgs...)
-	if err != nil {
-		s.onError(wrapInternalError(err))
+	if ack.decodeLater {
+		err := ack.call(reflect.ValueOf(decode))
+		if err != nil {
+			s.onError(wrapInternalError(err))
+		}
 		return
 	}
 
-	if len(values) == len(inputArgs) {
-		for i, v := range values {
-			if inputArgs[i].Kind() != reflect.Ptr && v.Kind() == reflect.Ptr {
-				values[i] = v.Elem()
-			}
-		}
-	} else {
-		s.onError(fmt.Errorf("sio: onAck: invalid number of arguments"))
+	values, err := ack.decodeArgs(decode)
+	if err != nil {
+		s.onError(wrapInternalError(err))
 		return
 	}
 
@@ -676,7 +700,7 @@ func (s *clientSocket) emit(
 	timeout time.Duration,
 	volatile, fromQueue bool,
 	v ...any,
-) {
+) (ackID *uint64) {
 	header := parser.PacketHeader{
 		Type:      parser.PacketTypeEvent,
 		Namespace: s.namespace,
@@ -692,7 +716,7 @@ func (s *clientSocket) emit(
 
 	if s.config.Retries > 0 && !fromQueue && !volatile {
 		s.packetQueue.addToQueue(&header, v)
-		return
+		return nil
 	}
 
 	f := v[len(v)-1]
@@ -703,13 +727,19 @@ func (s *clientSocket) emit(
 		v = v[:len(v)-1]
 	}
 
+	// When the packet comes from the queue, eventName is empty and the event name is the first value.
+	if name, ok := v[0].(string); ok {
+		callAnyOutgoingHandlers(s.anyOutgoingHandlers, name, v[1:])
+	}
+
 	buffers, err := s.parser.Encode(&header, &v)
 	if err != nil {
 		s.onError(wrapInternalError(err))
-		return
+		return header.ID
 	}
 
 	s.sendBuffers(volatile, false, header.ID, buffers...)
+	return header.ID
 }
 
 // 0 as the timeout argument means there is no timeout.
@@ -732,22 +762,7 @@ func (s *clientSocket) registerAckHandler(f any, timeout time.Duration) (id uint
 
 	h, err := newAckHandlerWithTimeout(f, timeout, func() {
 		s.debug.Log("Timeout occured for ack with ID", id, "timeout", timeout)
-		s.acksMu.Lock()
-		delete(s.acks, id)
-		s.acksMu.Unlock()
-
-		remove := func(slice []sendBufferItem, s int) []sendBufferItem {
-			return append(slice[:s], slice[s+1:]...)
-		}
-
-		s.sendBufferMu.Lock()
-		for i, packet := range s.sendBuffer {
-			if packet.ackID != nil && *packet.ackID == id {
-				s.debug.Log("Removing packet with ack ID", id)
-				s.sendBuffer = remove(s.sendBuffer, i)
-			}
-		}
-		s.sendBufferMu.Unlock()
+		s.removeAck(id)
 	})
 	if err != nil {
 		panic(err)
@@ -759,6 +774,26 @@ func (s *clientSocket) registerAckHandler(f any, timeout time.Duration) (id uint
 	return
 }
 
+// Removes the acknowledgement with the given ID, and its packet if it hasn't been sent yet.
+func (s *clientSocket) removeAck(id uint64) {
+	s.acksMu.Lock()
+	delete(s.acks, id)
+	s.acksMu.Unlock()
+
+	remove := func(slice []sendBufferItem, s int) []sendBufferItem {
+		return append(slice[:s], slice[s+1:]...)
+	}
+
+	s.sendBufferMu.Lock()
+	for i, packet := range s.sendBuffer {
+		if packet.ackID != nil && *packet.ackID == id {
+			s.debug.Log("Removing packet with ack ID", id)
+			s.sendBuffer = remove(s.sendBuffer, i)
+		}
+	}
+	s.sendBufferMu.Unlock()
+}
+
 func (s *clientSocket) nextAckID() uint64 {
 	s.acksMu.Lock()
 	defer s.acksMu.Unlock()
@@ -767,6 +802,10 @@ func (s *clientSocket) nextAckID() uint64 {
 	return id
 }
 
+func (s *clientSocket) EmitWithAck(ctx context.Context, eventName string, v ...any) (AckResponse, error) {
+	return Emitter{socket: s}.EmitWithAck(ctx, eventName, v...)
+}
+
 func (s *clientSocket) Timeout(timeout time.Duration) Emitter {
 	return Emitter{
 		socket:  s,
@@ -834,7 +873,7 @@ func (s *clientSocket) _sendBuffers(volatile, forceSend bool, ackID *uint64, buf
 		buffers = buffers[1:]
 
 		var err error
-		packets[0], err = eioparser.NewPacket(eioparser.PacketTypeMessage, false, buf)
+		packets[0], err = eioparser.NewPacket(eioparser.PacketTypeMessage, parser.EncodesBinary(s.parser), buf)
 		if err != nil {
 			s.onError(wrapInternalError(err))
 			return
@@ -887,6 +926,11 @@ func (s *clientSocket) onClose(reason Reason) {
 	s.debug.Log("Going to close the socket. Reason", reason)
 
 	s.stateMu.Lock()
+	if s.state == clientSocketConnStateDisconnected {
+		// The transport might close right after the server disconnects the socket.
+		s.stateMu.Unlock()
+		return
+	}
 	s.state = clientSocketConnStateDisconnected
 	s.stateMu.Unlock()
 	s.setID("")
@@ -27,6 +27,38 @@ func (s *clientSocket) OnceEvent(eventName string, handler any) {
 	s.eventHandlers.once(eventName, h)
 }
 
+// Used by On, Once, OnAck and OnceAck.
+func (s *clientSocket) addEventHandler(eventName string, handler *eventHandler, once bool) {
+	if IsEventReservedForClient(eventName) {
+		panic(fmt.Errorf("sio: attempted to register a reserved event: `%s`", eventName))
+	}
+	if once {
+		s.eventHandlers.once(eventName, handler)
+	} else {
+		s.eventHandlers.on(eventName, handler)
+	}
+}
+
+func (s *clientSocket) OnPatternEvent(pattern string, handler any) (off func()) {
+	h, err := newPatternEventHandler(pattern, handler)
+	if err != nil {
+		panic(err)
+	}
+	return s.eventHandlers.onPattern(pattern, h, false)
+}
+
+func (s *clientSocket) OncePatternEvent(pattern string, handler any) (off func()) {
+	h, err := newPatternEventHandler(pattern, handler)
+	if err != nil {
+		panic(err)
+	}
+	return s.eventHandlers.onPattern(pattern, h, true)
+}
+
+func (s *clientSocket) OffPatternEvent(pattern string) {
+	s.eventHandlers.offPattern(pattern)
+}
+
 func (s *clientSocket) OffEvent(eventName string, handler ...any) {
 	values := make([]reflect.Value, len(handler))
 	for i := range values {
@@ -35,8 +67,34 @@ func (s *clientSocket) OffEvent(eventName string, handler ...any) {
 	s.eventHandlers.off(eventName, values...)
 }
 
+func (s *clientSocket) OnAnyEvent(f AnyEventFunc) (off func()) {
+	return addAnyEventHandler(s.anyEventHandlers, f, false)
+}
+
+func (s *clientSocket) PrependAnyEvent(f AnyEventFunc) (off func()) {
+	return addAnyEventHandler(s.anyEventHandlers, f, true)
+}
+
+func (s *clientSocket) OffAnyEvent() {
+	s.anyEventHandlers.offAll()
+}
+
+func (s *clientSocket) OnAnyOutgoing(f AnyEventFunc) (off func()) {
+	return addAnyEventHandler(s.anyOutgoingHandlers, f, false)
+}
+
+func (s *clientSocket) PrependAnyOutgoing(f AnyEventFunc) (off func()) {
+	return addAnyEventHandler(s.anyOutgoingHandlers, f, true)
+}
+
+func (s *clientSocket) OffAnyOutgoing() {
+	s.anyOutgoingHandlers.offAll()
+}
+
 func (s *clientSocket) OffAll() {
 	s.eventHandlers.offAll()
+	s.anyEventHandlers.offAll()
+	s.anyOutgoingHandlers.offAll()
 	s.connectHandlers.offAll()
 	s.connectErrorHandlers.offAll()
 	s.disconnectHandlers.offAll()
@@ -1,6 +1,7 @@
 package sio
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"testing"
@@ -1087,6 +1088,117 @@ func TestClient(t *testing.T) {
 		close()
 	})
 
+	t.Run("should emit an event and wait for its acknowledgement", func(t *testing.T) {
+		io, _, manager, close := newTestServerAndClient(t, nil, nil)
+		tw := utils.NewTestWaiter(1)
+		socket := manager.Socket("/", nil)
+
+		io.OnConnection(func(socket ServerSocket) {
+			socket.OnEvent("echo", func(n int, s string, ack func(n int, s string)) {
+				ack(n, s)
+			})
+		})
+		socket.OnConnect(func() {
+			go func() {
+				defer tw.Done()
+				res, err := socket.Timeout(3*time.Second).EmitWithAck(context.Background(), "echo", 42, "hello")
+				if !assert.NoError(t, err) {
+					return
+				}
+				var (
+					n int
+					s string
+				)
+				assert.NoError(t, res.Decode(&n, &s))
+				assert.Equal(t, 42, n)
+				assert.Equal(t, "hello", s)
+			}()
+		})
+		socket.Connect()
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		close()
+	})
+
+	t.Run("should return ErrAckTimeout when the server does not acknowledge the event in time", func(t *testing.T) {
+		_, _, manager, close := newTestServerAndClient(t, nil, nil)
+		socket := manager.Socket("/", nil)
+		socket.Connect()
+
+		_, err := socket.Timeout(50*time.Millisecond).EmitWithAck(context.Background(), "event")
+		assert.Equal(t, ErrAckTimeout, err)
+		close()
+	})
+
+	t.Run("should return the error of the context when it is done", func(t *testing.T) {
+		_, _, manager, close := newTestServerAndClient(t, nil, nil)
+		socket := manager.Socket("/", nil)
+		socket.Connect()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := socket.EmitWithAck(ctx, "event")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.NotErrorIs(t, err, ErrAckTimeout)
+
+		// The acknowledgement is not waited for anymore.
+		s := socket.(*clientSocket)
+		s.acksMu.Lock()
+		assert.Empty(t, s.acks)
+		s.acksMu.Unlock()
+		close()
+	})
+
+	t.Run("should wait for the acknowledgement when the packet is queued", func(t *testing.T) {
+		io, _, manager, close := newTestServerAndClient(t, nil, nil)
+		socket := manager.Socket("/", &ClientSocketConfig{
+			Retries:    1,
+			AckTimeout: 3 * time.Second,
+		})
+
+		io.OnConnection(func(socket ServerSocket) {
+			socket.OnEvent("echo", func(s string, ack func(s string)) {
+				ack(s)
+			})
+		})
+		socket.Connect()
+
+		res, err := socket.EmitWithAck(context.Background(), "echo", "hello")
+		assert.NoError(t, err)
+		var s string
+		assert.NoError(t, res.Decode(&s))
+		assert.Equal(t, "hello", s)
+		close()
+	})
+
+	t.Run("should receive the acknowledgement of the client with EmitWithAck on the server", func(t *testing.T) {
+		io, _, manager, close := newTestServerAndClient(t, nil, nil)
+		tw := utils.NewTestWaiter(1)
+		socket := manager.Socket("/", nil)
+
+		socket.OnEvent("hello", func(ack func(reply string)) {
+			ack("world")
+		})
+		io.OnConnection(func(socket ServerSocket) {
+			go func() {
+				defer tw.Done()
+				ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+				defer cancel()
+				res, err := socket.EmitWithAck(ctx, "hello")
+				if !assert.NoError(t, err) {
+					return
+				}
+				var reply string
+				assert.NoError(t, res.Decode(&reply))
+				assert.Equal(t, "world", reply)
+			}()
+		})
+		socket.Connect()
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		close()
+	})
+
 	t.Run("should not fire events more than once after manually reconnecting", func(t *testing.T) {
 		_, _, manager, close := newTestServerAndClient(
 			t,
@@ -1,8 +1,12 @@
 package sio
 
 import (
+	"context"
+	"fmt"
 	"reflect"
 	"time"
+
+	"github.com/hhuuson97/socket.io-go/parser"
 )
 
 type (
@@ -14,7 +18,14 @@ type (
 
 	emitter interface {
 		Socket
-		emit(eventName string, timeout time.Duration, volatile, fromQueue bool, v ...any)
+		// Returns the ID of the acknowledgement, if one was registered.
+		emit(eventName string, timeout time.Duration, volatile, fromQueue bool, v ...any) (ackID *uint64)
+		removeAck(id uint64)
+	}
+
+	// The acknowledgement received by EmitWithAck.
+	AckResponse struct {
+		decode parser.Decode
 	}
 )
 
@@ -34,6 +45,86 @@ func (e Emitter) Emit(eventName string, v ...any) {
 	e.socket.emit(eventName, e.timeout, e.volatile, false, v...)
 }
 
+// Emits an event and blocks until its acknowledgement is received.
+//
+// It returns an error if the timeout of the emitter (see Timeout) is reached,
+// in which case the error is ErrAckTimeout, or if ctx is done,
+// in which case the error is ctx.Err() and the acknowledgement is not waited for anymore.
+//
+// Unlike Emit, the last argument must not be an acknowledgement function.
+func (e Emitter) EmitWithAck(ctx context.Context, eventName string, v ...any) (AckResponse, error) {
+	if len(v) != 0 {
+		f := v[len(v)-1]
+		if f != nil && reflect.TypeOf(f).Kind() == reflect.Func {
+			panic(fmt.Errorf("sio: EmitWithAck: an acknowledgement function cannot be given"))
+		}
+	}
+
+	type result struct {
+		decode parser.Decode
+		err    error
+	}
+	ch := make(chan result, 1)
+	ack := decodeAckFunc(func(err error, decode parser.Decode) {
+		select {
+		case ch <- result{decode: decode, err: err}:
+		default:
+		}
+	})
+	v = append(v[:len(v):len(v)], ack)
+	ackID := e.socket.emit(eventName, e.timeout, e.volatile, false, v...)
+
+	select {
+	case r := <-ch:
+		if r.err != nil {
+			return AckResponse{}, r.err
+		}
+		return AckResponse{decode: r.decode}, nil
+	case <-ctx.Done():
+		// The packet of a client might be in the queue (see ClientConfig.Retries),
+		// in which case it has no ID yet, and is removed from the queue once
+		// it is acknowledged or its retries are exhausted.
+		if ackID != nil {
+			e.socket.removeAck(*ackID)
+		}
+		return AckResponse{}, ctx.Err()
+	}
+}
+
+// Decodes the arguments of the acknowledgement into v.
+// The elements of v must be non-nil pointers, one per argument.
+func (r AckResponse) Decode(v ...any) error {
+	if r.decode == nil {
+		return fmt.Errorf("sio: AckResponse.Decode: there is no acknowledgement to decode")
+	}
+
+	types := make([]reflect.Type, len(v))
+	for i, ptr := range v {
+		rv := reflect.ValueOf(ptr)
+		if ptr == nil || rv.Kind() != reflect.Ptr || rv.IsNil() {
+			return fmt.Errorf("sio: AckResponse.Decode: non-nil pointer expected")
+		}
+		types[i] = rv.Type()
+	}
+
+	values, err := r.decode(types...)
+	if err != nil {
+		return err
+	}
+	if len(values) != len(v) {
+		return fmt.Errorf("sio: AckResponse.Decode: invalid number of arguments")
+	}
+
+	for i, value := range values {
+		dst := reflect.ValueOf(v[i]).Elem()
+		if value.Type() != dst.Type() && value.Kind() == reflect.Ptr {
+			value = value.Elem()
+		}
+		dst.Set(value)
+	}
+	return nil
+}
+
 func (e Emitter) Timeout(timeout time.Duration) Emitter {
 	e.timeout = timeout
 	return e
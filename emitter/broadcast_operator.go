@@ -0,0 +1,64 @@
+package emitter
+
+import (
+	"github.com/hhuuson97/socket.io-go/adapter"
+	"github.com/hhuuson97/socket.io-go/internal/reserved"
+)
+
+// The emitter counterpart of the BroadcastOperator of the server.
+// The methods that need a response from the servers (such as FetchSockets) are not available.
+type BroadcastOperator struct {
+	b *adapter.BroadcastOperator
+}
+
+func newBroadcastOperator(nsp string, adp adapter.Adapter) *BroadcastOperator {
+	return &BroadcastOperator{
+		b: adapter.NewBroadcastOperator(nsp, adp, reserved.IsServerEvent),
+	}
+}
+
+// Emits an event to all choosen clients.
+func (b *BroadcastOperator) Emit(eventName string, v ...any) {
+	b.b.Emit(eventName, v...)
+}
+
+// Sets a modifier for a subsequent event emission that the event
+// will only be broadcast to clients that have joined the given room.
+//
+// To emit to multiple rooms, you can call To several times.
+func (b *BroadcastOperator) To(room ...adapter.Room) *BroadcastOperator {
+	return &BroadcastOperator{b: b.b.To(room...)}
+}
+
+// Alias of To(...)
+func (b *BroadcastOperator) In(room ...adapter.Room) *BroadcastOperator {
+	return b.To(room...)
+}
+
+// Sets a modifier for a subsequent event emission that the event
+// will only be broadcast to clients that have not joined the given rooms.
+func (b *BroadcastOperator) Except(room ...adapter.Room) *BroadcastOperator {
+	return &BroadcastOperator{b: b.b.Except(room...)}
+}
+
+// Compression flag is unused at the moment, thus setting this will have no effect on compression.
+func (b *BroadcastOperator) Compress(compress bool) *BroadcastOperator {
+	return &BroadcastOperator{b: b.b.Compress(compress)}
+}
+
+// Makes the matching socket instances join the specified rooms.
+func (b *BroadcastOperator) SocketsJoin(room ...adapter.Room) {
+	b.b.SocketsJoin(room...)
+}
+
+// Makes the matching socket instances leave the specified rooms.
+func (b *BroadcastOperator) SocketsLeave(room ...adapter.Room) {
+	b.b.SocketsLeave(room...)
+}
+
+// Makes the matching socket instances disconnect from the namespace.
+//
+// If value of close is true, closes the underlying connection. Otherwise, it just disconnects the namespace.
+func (b *BroadcastOperator) DisconnectSockets(close bool) {
+	b.b.DisconnectSockets(close)
+}
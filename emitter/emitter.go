@@ -0,0 +1,178 @@
+// Package emitter sends events to the Socket.IO servers of a cluster
+// from a process that is not a Socket.IO server itself (such as a background worker).
+//
+// It writes in the same format as the adapter of the servers, thus the servers
+// handle the events exactly as if they were emitted by one of them.
+//
+// This is the equivalent of: https://github.com/socketio/socket.io-redis-emitter
+package emitter
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/hhuuson97/socket.io-go/adapter"
+	"github.com/hhuuson97/socket.io-go/internal/reserved"
+	"github.com/hhuuson97/socket.io-go/internal/sync"
+	"github.com/hhuuson97/socket.io-go/parser"
+	jsonparser "github.com/hhuuson97/socket.io-go/parser/json"
+	"github.com/hhuuson97/socket.io-go/parser/json/serializer/stdjson"
+	"github.com/redis/go-redis/v9"
+)
+
+type (
+	Config struct {
+		// The parser must be the same as the one of the servers.
+		//
+		// Default: the JSON parser (the default parser of the servers).
+		ParserCreator parser.Creator
+	}
+
+	Emitter struct {
+		nsp      string
+		adapters *adapters
+	}
+
+	// The adapters of the namespaces, shared by an emitter and
+	// the emitters returned by its Of method.
+	adapters struct {
+		creator       adapter.PublisherCreator
+		parserCreator parser.Creator
+
+		adapters map[string]adapter.Adapter
+		mu       sync.Mutex
+	}
+)
+
+// Returns an emitter of the main namespace ("/").
+//
+// creator must write to the nodes in the format of the adapter of the servers.
+// Have a look at NewRedisStreamEmitter and NewRedisEmitter.
+func New(creator adapter.PublisherCreator, config *Config) *Emitter {
+	if config == nil {
+		config = new(Config)
+	}
+	parserCreator := config.ParserCreator
+	if parserCreator == nil {
+		json := stdjson.New()
+		parserCreator = jsonparser.NewCreator(0, json)
+	}
+
+	return &Emitter{
+		nsp: "/",
+		adapters: &adapters{
+			creator:       creator,
+			parserCreator: parserCreator,
+			adapters:      make(map[string]adapter.Adapter),
+		},
+	}
+}
+
+// Returns an emitter for the servers that use the RedisStreamAdapter.
+// opts must match the options of the adapter of the servers.
+func NewRedisStreamEmitter(client redis.Cmdable, opts *adapter.RedisStreamsAdapterOptions, config *Config) *Emitter {
+	return New(adapter.NewRedisStreamPublisherCreator(client, opts), config)
+}
+
+// Returns an emitter for the servers that use the Redis adapter (see adapter.NewRedisAdapterCreator).
+// opts must match the options of the adapter of the servers.
+func NewRedisEmitter(client redis.UniversalClient, opts *adapter.RedisAdapterOptions, config *Config) *Emitter {
+	return New(adapter.NewRedisPublisherCreator(client, opts), config)
+}
+
+func (a *adapters) get(nsp string) adapter.Adapter {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	adp, ok := a.adapters[nsp]
+	if !ok {
+		adp = a.creator(nsp, a.parserCreator)
+		a.adapters[nsp] = adp
+	}
+	return adp
+}
+
+// Returns an emitter for the given namespace.
+func (e *Emitter) Of(nsp string) *Emitter {
+	if len(nsp) == 0 || nsp[0] != '/' {
+		nsp = "/" + nsp
+	}
+	return &Emitter{
+		nsp:      nsp,
+		adapters: e.adapters,
+	}
+}
+
+func (e *Emitter) newBroadcastOperator() *BroadcastOperator {
+	return newBroadcastOperator(e.nsp, e.adapters.get(e.nsp))
+}
+
+// Emits an event to all the connected clients of the namespace.
+func (e *Emitter) Emit(eventName string, v ...any) {
+	e.newBroadcastOperator().Emit(eventName, v...)
+}
+
+// Sets a modifier for a subsequent event emission that the event
+// will only be broadcast to clients that have joined the given room.
+//
+// To emit to multiple rooms, you can call To several times.
+func (e *Emitter) To(room ...adapter.Room) *BroadcastOperator {
+	return e.newBroadcastOperator().To(room...)
+}
+
+// Alias of To(...)
+func (e *Emitter) In(room ...adapter.Room) *BroadcastOperator {
+	return e.newBroadcastOperator().In(room...)
+}
+
+// Sets a modifier for a subsequent event emission that the event
+// will only be broadcast to clients that have not joined the given rooms.
+func (e *Emitter) Except(room ...adapter.Room) *BroadcastOperator {
+	return e.newBroadcastOperator().Except(room...)
+}
+
+// Makes the matching socket instances join the specified rooms.
+func (e *Emitter) SocketsJoin(room ...adapter.Room) {
+	e.newBroadcastOperator().SocketsJoin(room...)
+}
+
+// Makes the matching socket instances leave the specified rooms.
+func (e *Emitter) SocketsLeave(room ...adapter.Room) {
+	e.newBroadcastOperator().SocketsLeave(room...)
+}
+
+// Makes the matching socket instances disconnect from the namespace.
+//
+// If value of close is true, closes the underlying connection. Otherwise, it just disconnects the namespace.
+func (e *Emitter) DisconnectSockets(close bool) {
+	e.newBroadcastOperator().DisconnectSockets(close)
+}
+
+// Sends a message to the Socket.IO servers of the cluster.
+// It is received by the OnServerSideEmit handlers of the namespace.
+//
+// Acknowledgements are not supported.
+func (e *Emitter) ServerSideEmit(eventName string, _v ...any) {
+	if reserved.IsNspEvent(eventName) {
+		panic(fmt.Errorf("sio: Emitter.ServerSideEmit: attempted to emit a reserved event: `%s`", eventName))
+	}
+	if isAckFunc(_v) {
+		panic(fmt.Errorf("sio: Emitter.ServerSideEmit: acknowledgements are not supported"))
+	}
+
+	header := &parser.PacketHeader{
+		Type:      parser.PacketTypeEvent,
+		Namespace: e.nsp,
+	}
+	v := make([]any, 0, len(_v)+1)
+	v = append(v, eventName)
+	v = append(v, _v...)
+	e.adapters.get(e.nsp).ServerSideEmit(header, v)
+}
+
+func isAckFunc(v []any) bool {
+	if len(v) == 0 {
+		return false
+	}
+	f := v[len(v)-1]
+	return f != nil && reflect.TypeOf(f).Kind() == reflect.Func
+}
@@ -0,0 +1,195 @@
+package emitter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hhuuson97/socket.io-go/adapter"
+	jsonparser "github.com/hhuuson97/socket.io-go/parser/json"
+	"github.com/hhuuson97/socket.io-go/parser/json/serializer/stdjson"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmitter(t *testing.T) {
+	t.Run("should emit to the sockets of the RedisStreamAdapter", func(t *testing.T) {
+		client := newTestRedisClient(t)
+		a, store := newTestRedisStreamAdapter(t, client, "/")
+		e := NewRedisStreamEmitter(client, nil, nil)
+
+		store.Set(adapter.NewTestSocket("s1"))
+		a.AddAll("s1", []adapter.Room{"s1", "r1"})
+		store.Set(adapter.NewTestSocket("s2"))
+		a.AddAll("s2", []adapter.Room{"s2"})
+
+		received := make(chan adapter.SocketID, 2)
+		store.SetSendBuffers(func(sid adapter.SocketID, buffers [][]byte) (ok bool) {
+			received <- sid
+			return true
+		})
+
+		e.To("r1").Emit("hello", "world")
+
+		select {
+		case sid := <-received:
+			require.Equal(t, adapter.SocketID("s1"), sid)
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout exceeded")
+		}
+		time.Sleep(100 * time.Millisecond)
+		require.Len(t, received, 0)
+	})
+
+	t.Run("should not be a node of the cluster", func(t *testing.T) {
+		client := newTestRedisClient(t)
+		a, _ := newTestRedisStreamAdapter(t, client, "/")
+		e := NewRedisStreamEmitter(client, nil, nil)
+
+		e.Emit("hello")
+		time.Sleep(100 * time.Millisecond)
+		require.Equal(t, 1, a.ServerCount())
+	})
+
+	t.Run("should only emit to the given namespace", func(t *testing.T) {
+		client := newTestRedisClient(t)
+		a1, store1 := newTestRedisStreamAdapter(t, client, "/")
+		a2, store2 := newTestRedisStreamAdapter(t, client, "/custom")
+		e := NewRedisStreamEmitter(client, nil, nil)
+
+		store1.Set(adapter.NewTestSocket("s1"))
+		a1.AddAll("s1", []adapter.Room{"s1"})
+		store2.Set(adapter.NewTestSocket("s2"))
+		a2.AddAll("s2", []adapter.Room{"s2"})
+
+		store1.SetSendBuffers(func(sid adapter.SocketID, buffers [][]byte) (ok bool) {
+			t.Error("socket is not in the namespace")
+			return true
+		})
+		received := make(chan string, 1)
+		store2.SetSendBuffers(func(sid adapter.SocketID, buffers [][]byte) (ok bool) {
+			received <- string(buffers[0])
+			return true
+		})
+
+		e.Of("custom").Emit("hello")
+
+		select {
+		case buf := <-received:
+			// The session ID of the packet is appended by the adapter.
+			require.Regexp(t, `^2/custom,\["hello","[^"]+"\]$`, buf)
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout exceeded")
+		}
+	})
+
+	t.Run("should make the sockets join and leave rooms, and disconnect them", func(t *testing.T) {
+		client := newTestRedisClient(t)
+		a, store := newTestRedisStreamAdapter(t, client, "/")
+		e := NewRedisStreamEmitter(client, nil, nil)
+
+		socket := adapter.NewTestSocket("s1")
+		store.Set(socket)
+		a.AddAll("s1", []adapter.Room{"s1"})
+
+		e.In("s1").SocketsJoin("r1")
+		require.Eventually(t, func() bool {
+			return len(socket.CurrentRooms()) == 2
+		}, 3*time.Second, 10*time.Millisecond)
+
+		e.SocketsLeave("r1")
+		require.Eventually(t, func() bool {
+			return len(socket.CurrentRooms()) == 1
+		}, 3*time.Second, 10*time.Millisecond)
+
+		e.Except("s1").DisconnectSockets(false)
+		e.DisconnectSockets(true)
+		require.Eventually(t, func() bool {
+			return !socket.IsConnected()
+		}, 3*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("should send server side events", func(t *testing.T) {
+		client := newTestRedisClient(t)
+		_, store := newTestRedisStreamAdapter(t, client, "/")
+		e := NewRedisStreamEmitter(client, nil, nil)
+
+		received := make(chan []any, 1)
+		store.SetOnServerSideEmit(func(eventName string, v []any, ack func(response any)) {
+			assert.Equal(t, "hello", eventName)
+			assert.Nil(t, ack)
+			received <- v
+		})
+
+		e.ServerSideEmit("hello", "world", 1)
+
+		select {
+		case v := <-received:
+			require.Equal(t, []any{"world", float64(1)}, v)
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout exceeded")
+		}
+	})
+
+	t.Run("should panic when emitting a reserved event or an acknowledgement", func(t *testing.T) {
+		e := NewRedisStreamEmitter(newTestRedisClient(t), nil, nil)
+
+		require.Panics(t, func() { e.Emit("connect") })
+		require.Panics(t, func() { e.To("r1").Emit("hello", func() {}) })
+		require.Panics(t, func() { e.ServerSideEmit("new_namespace") })
+		require.Panics(t, func() { e.ServerSideEmit("hello", func() {}) })
+	})
+
+	t.Run("should emit to the sockets of the Redis adapter", func(t *testing.T) {
+		client := newTestRedisClient(t)
+		store := adapter.NewTestSocketStore()
+		creator := adapter.NewRedisAdapterCreator(client, &adapter.RedisAdapterOptions{
+			ErrorHandler: func(err error) { t.Error(err) },
+		})
+		a := creator(store, jsonparser.NewCreator(0, stdjson.New()))
+		t.Cleanup(a.Close)
+		e := NewRedisEmitter(client, nil, nil)
+
+		store.Set(adapter.NewTestSocket("s1"))
+		a.AddAll("s1", []adapter.Room{"s1", "r1"})
+		store.Set(adapter.NewTestSocket("s2"))
+		a.AddAll("s2", []adapter.Room{"s2"})
+
+		received := make(chan string, 2)
+		store.SetSendBuffers(func(sid adapter.SocketID, buffers [][]byte) (ok bool) {
+			assert.Equal(t, adapter.SocketID("s1"), sid)
+			received <- string(buffers[0])
+			return true
+		})
+
+		e.To("r1").Emit("hello", "world")
+
+		select {
+		case buf := <-received:
+			require.Equal(t, `2["hello","world"]`, buf)
+		case <-time.After(3 * time.Second):
+			t.Fatal("timeout exceeded")
+		}
+	})
+}
+
+func newTestRedisClient(t *testing.T) *redis.Client {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func newTestRedisStreamAdapter(t *testing.T, client *redis.Client, nsp string) (adapter.Adapter, *adapter.TestSocketStore) {
+	store := adapter.NewTestSocketStore()
+	store.SetNamespace(nsp)
+	creator := adapter.NewRedisStreamAdapterCreator(client, &adapter.RedisStreamsAdapterOptions{
+		ErrorHandler: func(err error) { t.Error(err) },
+	})
+	a := creator(store, jsonparser.NewCreator(0, stdjson.New()))
+	t.Cleanup(a.Close)
+	// Wait for the adapter to start reading the stream.
+	time.Sleep(50 * time.Millisecond)
+	return a, store
+}
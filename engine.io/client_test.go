@@ -86,7 +86,7 @@ func testSendReceive(t *testing.T, transports []string) {
 		return false
 	}
 
-	send := func(socket ServerSocket) {
+	send := func(socket Socket) {
 		for _, p := range test {
 			if p.Type == parser.PacketTypeMessage {
 				socket.Send(p)
@@ -111,6 +111,81 @@ func (p *Packet) Encode(w io.Writer, supportsBinary bool) error {
 	return err
 }
 
+// Encodes the packet in the format of the protocol revision 3 (Socket.IO v2 clients).
+// Unlike the revision 4, a binary packet starts with its type: it is a byte if supportsBinary is set,
+// otherwise the packet is encoded as "b4" followed by the data in base64.
+func (p *Packet) EncodeV3(w io.Writer, supportsBinary bool) error {
+	if !p.IsBinary {
+		return p.Encode(w, supportsBinary)
+	}
+
+	bw, ok := w.(io.ByteWriter)
+	if !ok {
+		bw = byteWriter{w: w}
+	}
+
+	if supportsBinary {
+		err := bw.WriteByte(byte(p.Type))
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(p.Data)
+		return err
+	}
+
+	err := bw.WriteByte(base64Prefix)
+	if err != nil {
+		return err
+	}
+	err = bw.WriteByte(p.Type.ToChar())
+	if err != nil {
+		return err
+	}
+
+	encoder := base64.NewEncoder(base64.StdEncoding, w)
+	defer encoder.Close()
+
+	_, err = encoder.Write(p.Data)
+	return err
+}
+
+// Decodes a packet in the format of the protocol revision 3. See EncodeV3.
+func DecodeV3(r io.Reader, binaryFrame bool) (*Packet, error) {
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return decodeV3(buf, binaryFrame)
+}
+
+func decodeV3(data []byte, binaryFrame bool) (*Packet, error) {
+	if binaryFrame {
+		if len(data) < 1 {
+			return nil, errInvalidPacketSize
+		}
+		if PacketType(data[0]) != PacketTypeMessage {
+			return nil, errInvalidPacketType
+		}
+		return decode(data[1:], true)
+	}
+
+	if len(data) > 0 && data[0] == base64Prefix {
+		if len(data) < 2 {
+			return nil, errInvalidPacketSize
+		}
+		var packetType PacketType
+		err := packetType.FromChar(data[1])
+		if err != nil {
+			return nil, err
+		}
+		if packetType != PacketTypeMessage {
+			return nil, errInvalidPacketType
+		}
+		return decodeBase64(data[2:])
+	}
+	return decode(data, false)
+}
+
 func Decode(r io.Reader, binaryFrame bool) (*Packet, error) {
 	buf, err := io.ReadAll(r)
 	if err != nil {
@@ -145,20 +220,7 @@ func decode(data []byte, binaryFrame bool) (*Packet, error) {
 	packetType := data[0]
 
 	if packetType == base64Prefix {
-		packet.IsBinary = true
-		packet.Type = PacketTypeMessage
-
-		data = data[1:]
-		dl := base64.StdEncoding.DecodedLen(len(data))
-		packet.Data = make([]byte, dl)
-
-		n, err := base64.StdEncoding.Decode(packet.Data, data)
-		if err != nil {
-			return nil, err
-		}
-
-		packet.Data = packet.Data[:n]
-		return packet, nil
+		return decodeBase64(data[1:])
 	}
 
 	packet.IsBinary = false
@@ -166,3 +228,22 @@ func decode(data []byte, binaryFrame bool) (*Packet, error) {
 	packet.Data = data[1:]
 	return packet, err
 }
+
+// Decodes a binary packet from base64.
+func decodeBase64(data []byte) (*Packet, error) {
+	packet := &Packet{
+		IsBinary: true,
+		Type:     PacketTypeMessage,
+	}
+
+	dl := base64.StdEncoding.DecodedLen(len(data))
+	packet.Data = make([]byte, dl)
+
+	n, err := base64.StdEncoding.Decode(packet.Data, data)
+	if err != nil {
+		return nil, err
+	}
+
+	packet.Data = packet.Data[:n]
+	return packet, nil
+}
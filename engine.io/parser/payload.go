@@ -1,11 +1,17 @@
 package parser
 
 import (
+	"bytes"
+	"fmt"
 	"io"
+	"strconv"
+	"unicode/utf8"
 )
 
 const payloadDelimiter byte = 30
 
+var errInvalidPayload = fmt.Errorf("parser: invalid payload")
+
 // `packets` must not be nil.
 func EncodedPayloadsLen(packets ...*Packet) int {
 	l := 0
@@ -86,3 +92,167 @@ func DecodePayloads(r io.Reader) ([]*Packet, error) {
 	}
 	return packets, nil
 }
+
+// In the protocol revision 3, the packets of a payload are prefixed with their length
+// (instead of being separated by a delimiter). There are two formats:
+//
+//   - The text format (text/plain): <length>:<packet> where the length is in UTF-16 code units,
+//     and the binary packets are encoded in base64 (see Packet.EncodeV3).
+//   - The binary format (application/octet-stream): <0 for text | 1 for binary><length as decimal digits, a byte per digit><255><packet>
+//     where the length is in bytes.
+
+// Whether the payload is to be encoded in the binary format of the protocol revision 3.
+// The binary format is only used if there is a binary packet and the client supports binary data.
+func IsBinaryPayloadV3(supportsBinary bool, packets ...*Packet) bool {
+	if !supportsBinary {
+		return false
+	}
+	for _, packet := range packets {
+		if packet.IsBinary {
+			return true
+		}
+	}
+	return false
+}
+
+// Encodes the packets in the format of the protocol revision 3. See IsBinaryPayloadV3 for the format used.
+func EncodePayloadsV3(w io.Writer, supportsBinary bool, packets ...*Packet) error {
+	binaryPayload := IsBinaryPayloadV3(supportsBinary, packets...)
+
+	buf := new(bytes.Buffer)
+	for _, packet := range packets {
+		buf.Reset()
+		err := packet.EncodeV3(buf, binaryPayload)
+		if err != nil {
+			return err
+		}
+
+		var header []byte
+		if binaryPayload {
+			if packet.IsBinary {
+				header = append(header, 1)
+			} else {
+				header = append(header, 0)
+			}
+			for _, c := range strconv.Itoa(buf.Len()) {
+				header = append(header, byte(c-'0'))
+			}
+			header = append(header, 255)
+		} else {
+			header = append(strconv.AppendInt(header, int64(utf16Len(buf.Bytes())), 10), ':')
+		}
+
+		_, err = w.Write(header)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(buf.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Decodes a payload in the format of the protocol revision 3.
+// binaryPayload is set if the content type of the payload is application/octet-stream.
+func DecodePayloadsV3(r io.Reader, binaryPayload bool) ([]*Packet, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errInvalidPayload
+	}
+
+	packets := make([]*Packet, 0, 1)
+	for len(data) > 0 {
+		var (
+			packet *Packet
+			err    error
+		)
+		if binaryPayload {
+			packet, data, err = decodeBinaryPayloadPacketV3(data)
+		} else {
+			packet, data, err = decodeTextPayloadPacketV3(data)
+		}
+		if err != nil {
+			return nil, err
+		}
+		packets = append(packets, packet)
+	}
+	return packets, nil
+}
+
+// The length of the length prefix is limited, so that it cannot overflow.
+const maxLengthDigitsV3 = 10
+
+func decodeTextPayloadPacketV3(data []byte) (packet *Packet, rest []byte, err error) {
+	i := bytes.IndexByte(data, ':')
+	if i < 1 || i > maxLengthDigitsV3 {
+		return nil, nil, errInvalidPayload
+	}
+	n, err := strconv.Atoi(string(data[:i]))
+	if err != nil || n < 1 {
+		return nil, nil, errInvalidPayload
+	}
+	data = data[i+1:]
+
+	// The length is in UTF-16 code units.
+	end := 0
+	for units := 0; units < n; {
+		if end >= len(data) {
+			return nil, nil, errInvalidPayload
+		}
+		r, size := utf8.DecodeRune(data[end:])
+		end += size
+		units += utf16RuneLen(r)
+	}
+
+	packet, err = decodeV3(data[:end], false)
+	return packet, data[end:], err
+}
+
+func decodeBinaryPayloadPacketV3(data []byte) (packet *Packet, rest []byte, err error) {
+	isBinary := data[0] == 1
+	if data[0] > 1 {
+		return nil, nil, errInvalidPayload
+	}
+
+	i := 1
+	n := 0
+	for ; i < len(data) && data[i] != 255; i++ {
+		if data[i] > 9 || i > maxLengthDigitsV3 {
+			return nil, nil, errInvalidPayload
+		}
+		n = n*10 + int(data[i])
+	}
+	if i == 1 || i == len(data) {
+		return nil, nil, errInvalidPayload
+	}
+	data = data[i+1:]
+	if n > len(data) {
+		return nil, nil, errInvalidPayload
+	}
+
+	packet, err = decodeV3(data[:n], isBinary)
+	return packet, data[n:], err
+}
+
+// The length of a UTF-8 encoded string in UTF-16 code units (the length of a JavaScript string).
+func utf16Len(b []byte) int {
+	n := 0
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		b = b[size:]
+		n += utf16RuneLen(r)
+	}
+	return n
+}
+
+func utf16RuneLen(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
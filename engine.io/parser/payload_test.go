@@ -161,3 +161,57 @@ func TestDecodeInvalidPayload(t *testing.T) {
 		t.Fatal("errInvalidPacketType expected")
 	}
 }
+
+func TestEncodeDecodePayloadsV3(t *testing.T) {
+	tests := []struct {
+		packets        []*Packet
+		supportsBinary bool
+		expected       []byte
+	}{
+		{
+			packets: []*Packet{
+				mustCreatePacket(t, PacketTypeMessage, false, []byte("hello")),
+				mustCreatePacket(t, PacketTypePing, false, []byte("probe")),
+				mustCreatePacket(t, PacketTypeMessage, false, []byte("€😀")),
+			},
+			supportsBinary: true,
+			expected:       []byte("6:4hello6:2probe4:4€😀"),
+		},
+		{
+			packets: []*Packet{
+				mustCreatePacket(t, PacketTypeMessage, false, []byte("hello")),
+				mustCreatePacket(t, PacketTypeMessage, true, []byte{1, 2, 3}),
+			},
+			supportsBinary: false,
+			expected:       []byte("6:4hello6:b4AQID"),
+		},
+		{
+			packets: []*Packet{
+				mustCreatePacket(t, PacketTypeMessage, false, []byte("hello")),
+				mustCreatePacket(t, PacketTypeMessage, true, []byte{1, 2, 3}),
+			},
+			supportsBinary: true,
+			expected:       []byte{0, 6, 255, '4', 'h', 'e', 'l', 'l', 'o', 1, 4, 255, 4, 1, 2, 3},
+		},
+	}
+
+	for _, test := range tests {
+		buf := bytes.NewBuffer(nil)
+		err := EncodePayloadsV3(buf, test.supportsBinary, test.packets...)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, buf.Bytes())
+
+		packets, err := DecodePayloadsV3(buf, IsBinaryPayloadV3(test.supportsBinary, test.packets...))
+		require.NoError(t, err)
+		require.Equal(t, test.packets, packets)
+	}
+
+	for _, invalid := range []string{"", "6:4hell", "x:4hello", "0:", "6-4hello", "2:9x"} {
+		_, err := DecodePayloadsV3(bytes.NewBufferString(invalid), false)
+		require.Error(t, err, invalid)
+	}
+	for _, invalid := range [][]byte{{2, 1, 255, '4'}, {0, 255, '4'}, {0, 9, 255, '4'}, {1, 2, 255, 3, 1}} {
+		_, err := DecodePayloadsV3(bytes.NewBuffer(invalid), true)
+		require.Error(t, err, invalid)
+	}
+}
@@ -1,10 +1,12 @@
 package eio
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/hhuuson97/socket.io-go/internal/sync"
@@ -44,6 +46,10 @@ type (
 		// For accepting WebTransport connections
 		WebTransportServer *webtransport.Server
 
+		// Accept the clients that use the protocol revision 3 (Socket.IO v2 clients).
+		// This is the equivalent of `allowEIO3` in original Engine.IO.
+		AllowEIO3 bool
+
 		// Custom WebSocket options to use.
 		WebSocketAcceptOptions *websocket.AcceptOptions
 
@@ -66,6 +72,7 @@ type (
 		disableMaxBufferSize bool
 
 		webTransportServer *webtransport.Server
+		allowEIO3          bool
 
 		wsAcceptOptions *websocket.AcceptOptions
 
@@ -75,6 +82,7 @@ type (
 
 		closed          chan struct{}
 		closeOnce       sync.Once
+		handshakesOff   atomic.Bool
 		debug           Debugger
 		testWaitUpgrade bool
 	}
@@ -104,6 +112,7 @@ func newServer(onSocket NewSocketCallback, config *ServerConfig, testWaitUpgrade
 		disableMaxBufferSize: config.DisableMaxBufferSize,
 
 		webTransportServer: config.WebTransportServer,
+		allowEIO3:          config.AllowEIO3,
 
 		wsAcceptOptions: config.WebSocketAcceptOptions,
 
@@ -188,13 +197,15 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 
 	// Skip protocol version check for WebTransport
+	version := ProtocolVersion
 	if r.ProtoMajor != 3 {
-		version, err := strconv.Atoi(q.Get("EIO"))
+		var err error
+		version, err = strconv.Atoi(q.Get("EIO"))
 		if err != nil {
 			writeServerError(w, ErrorUnsupportedProtocolVersion)
 			return
 		}
-		if version != ProtocolVersion {
+		if version != ProtocolVersion && !(version == 3 && s.allowEIO3) {
 			writeServerError(w, ErrorUnsupportedProtocolVersion)
 			return
 		}
@@ -202,7 +213,12 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	sid := q.Get("sid")
 	if sid == "" {
-		s.handleHandshake(w, r)
+		if s.handshakesOff.Load() {
+			s.debug.Log("Handshake received after handshakes were stopped")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		s.handleHandshake(w, r, version)
 	} else {
 		socket, ok := s.store.get(sid)
 		if !ok {
@@ -222,7 +238,7 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s *Server) handleHandshake(w http.ResponseWriter, r *http.Request) {
+func (s *Server) handleHandshake(w http.ResponseWriter, r *http.Request, protocolVersion int) {
 	q := r.URL.Query()
 	n := q.Get("transport")
 	supportsBinary := q.Get("b64") == ""
@@ -255,14 +271,15 @@ func (s *Server) handleHandshake(w http.ResponseWriter, r *http.Request) {
 	)
 	switch n {
 	case "polling":
-		t = polling.NewServerTransport(c, s.maxBufferSize, s.PollTimeout())
+		t = polling.NewServerTransport(c, protocolVersion, s.maxBufferSize, supportsBinary, s.PollTimeout())
 		upgrades = []string{"websocket"}
-		if s.webTransportServer != nil {
+		// WebTransport is not a part of the protocol revision 3.
+		if s.webTransportServer != nil && protocolVersion == ProtocolVersion {
 			upgrades = append(upgrades, "webtransport")
 		}
 	case "websocket":
-		t = _websocket.NewServerTransport(c, s.maxBufferSize, supportsBinary, s.wsAcceptOptions)
-		if s.webTransportServer != nil {
+		t = _websocket.NewServerTransport(c, protocolVersion, s.maxBufferSize, supportsBinary, s.wsAcceptOptions)
+		if s.webTransportServer != nil && protocolVersion == ProtocolVersion {
 			upgrades = []string{"webtransport"}
 		}
 	default:
@@ -284,7 +301,7 @@ func (s *Server) handleHandshake(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	socket := s.newSocket(w, sid, upgrades, c, t)
+	socket := s.newSocket(w, r, sid, protocolVersion, upgrades, c, t)
 	if socket == nil {
 		return
 	}
@@ -318,7 +335,7 @@ func (s *Server) onWebTransport(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		socket := s.newSocket(w, sid, nil, c, t)
+		socket := s.newSocket(w, r, sid, ProtocolVersion, nil, c, t)
 		if socket == nil {
 			t.Close()
 			return
@@ -351,12 +368,15 @@ func (s *Server) onWebTransport(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) newSocket(
 	w http.ResponseWriter,
+	r *http.Request,
 	sid string,
+	protocolVersion int,
 	upgrades []string,
 	c *transport.Callbacks,
 	t ServerTransport,
 ) *serverSocket {
-	socket := newServerSocket(sid, upgrades, t, c, s.pingInterval, s.pingTimeout, s.debug, s.store.delete)
+	socket := newServerSocket(sid, protocolVersion, upgrades, t, c, s.pingInterval, s.pingTimeout, s.debug, s.store.delete)
+	socket.handshakeRequest = newHandshakeRequest(r)
 
 	callbacks := s.onSocket(socket)
 	socket.setCallbacks(callbacks)
@@ -442,7 +462,7 @@ func (s *Server) maybeUpgrade(
 
 	switch upgradeTo {
 	case "websocket":
-		t = _websocket.NewServerTransport(c, s.maxBufferSize, supportsBinary, s.wsAcceptOptions)
+		t = _websocket.NewServerTransport(c, socket.protocolVersion, s.maxBufferSize, supportsBinary, s.wsAcceptOptions)
 		_, err := t.Handshake(nil, w, r)
 		if err != nil {
 			s.debug.Log("Handshake error", err)
@@ -494,6 +514,25 @@ func (s *Server) IsClosed() bool {
 	}
 }
 
+// Rejects the new connections, while the sockets that are already connected keep working.
+// Use Close to close them.
+func (s *Server) StopHandshakes() {
+	s.debug.Log("Stopping handshakes")
+	s.handshakesOff.Store(true)
+}
+
+// Waits until the packets that are queued for the clients are sent, or until ctx is done.
+// Use it before Close in order not to lose the packets sent just before closing the server.
+func (s *Server) WaitForDrain(ctx context.Context) error {
+	for _, socket := range s.store.getAll() {
+		err := socket.waitForDrain(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *Server) Close() error {
 	s.debug.Log("Closing")
 
@@ -1,6 +1,7 @@
 package eio
 
 import (
+	"context"
 	"sync/atomic"
 	"time"
 
@@ -11,17 +12,22 @@ import (
 )
 
 type serverSocket struct {
-	id           string
-	upgrades     []string
-	pingInterval time.Duration
-	pingTimeout  time.Duration
+	id              string
+	protocolVersion int
+	upgrades        []string
+	pingInterval    time.Duration
+	pingTimeout     time.Duration
 
 	transport   ServerTransport
 	transportMu sync.RWMutex
 
+	handshakeRequest *HandshakeRequest
+
 	callbacks atomic.Value
 
 	pongChan chan struct{}
+	// Only used with the protocol revision 3, see waitForPings.
+	pingChan chan struct{}
 
 	onClose   func(sid string)
 	closeChan chan struct{}
@@ -32,6 +38,7 @@ type serverSocket struct {
 
 func newServerSocket(
 	id string,
+	protocolVersion int,
 	upgrades []string,
 	transport ServerTransport,
 	callbacks *transport.Callbacks,
@@ -46,14 +53,16 @@ func newServerSocket(
 	}
 
 	s := &serverSocket{
-		id:           id,
-		upgrades:     upgrades,
-		pingInterval: pingInterval,
-		pingTimeout:  pingTimeout,
+		id:              id,
+		protocolVersion: protocolVersion,
+		upgrades:        upgrades,
+		pingInterval:    pingInterval,
+		pingTimeout:     pingTimeout,
 
 		transport: transport,
 
 		pongChan: make(chan struct{}, 1),
+		pingChan: make(chan struct{}, 1),
 
 		closeChan: make(chan struct{}),
 		onClose:   onClose,
@@ -66,6 +75,8 @@ func newServerSocket(
 	return s
 }
 
+func (s *serverSocket) HandshakeRequest() *HandshakeRequest { return s.handshakeRequest }
+
 func (s *serverSocket) getCallbacks() *Callbacks {
 	callbacks, _ := s.callbacks.Load().(*Callbacks)
 	return callbacks
@@ -96,6 +107,8 @@ func (s *serverSocket) TransportName() string {
 	return s.transport.Name()
 }
 
+func (s *serverSocket) ProtocolVersion() int { return s.protocolVersion }
+
 func (s *serverSocket) Upgrades() []string { return s.upgrades }
 
 func (s *serverSocket) PingInterval() time.Duration { return s.pingInterval }
@@ -124,6 +137,11 @@ func (s *serverSocket) upgradeTo(t ServerTransport, c *transport.Callbacks) {
 }
 
 func (s *serverSocket) pingPong(pingInterval time.Duration, pingTimeout time.Duration) {
+	if s.protocolVersion == 3 {
+		s.waitForPings(pingInterval + pingTimeout)
+		return
+	}
+
 	for {
 		time.Sleep(pingInterval)
 
@@ -155,6 +173,24 @@ func (s *serverSocket) pingPong(pingInterval time.Duration, pingTimeout time.Dur
 	}
 }
 
+// In the protocol revision 3, the client sends the PING packets and the server replies with PONG.
+// The socket is closed if no PING is received in time.
+func (s *serverSocket) waitForPings(timeout time.Duration) {
+	for {
+		select {
+		case <-s.pingChan:
+			s.debug.Log("waitForPings", "ping received")
+		case <-time.After(timeout):
+			s.debug.Log("waitForPings", "pingTimeout exceeded")
+			s.close(ReasonPingTimeout, nil)
+			return
+		case <-s.closeChan:
+			s.debug.Log("waitForPings", "`closeChan` was closed")
+			return
+		}
+	}
+}
+
 func (s *serverSocket) onPacket(packets ...*parser.Packet) {
 	s.getCallbacks().OnPacket(packets...)
 	for _, packet := range packets {
@@ -164,6 +200,10 @@ func (s *serverSocket) onPacket(packets ...*parser.Packet) {
 
 func (s *serverSocket) handlePacket(packet *parser.Packet) {
 	switch packet.Type {
+	case parser.PacketTypePing:
+		if s.protocolVersion == 3 {
+			s.onPing()
+		}
 	case parser.PacketTypePong:
 		s.onPong()
 	case parser.PacketTypeClose:
@@ -173,6 +213,20 @@ func (s *serverSocket) handlePacket(packet *parser.Packet) {
 	}
 }
 
+func (s *serverSocket) onPing() {
+	pong, err := parser.NewPacket(parser.PacketTypePong, false, nil)
+	if err != nil {
+		s.onError(err)
+		return
+	}
+	s.Send(pong)
+
+	select {
+	case s.pingChan <- struct{}{}:
+	default:
+	}
+}
+
 func (s *serverSocket) onPong() {
 	select {
 	case s.pongChan <- struct{}{}:
@@ -192,6 +246,22 @@ func (s *serverSocket) Send(packets ...*parser.Packet) {
 	s.transport.Send(packets...)
 }
 
+// Implemented by the transports that queue the packets until the client asks for them (polling).
+type drainableTransport interface {
+	WaitForDrain(ctx context.Context) error
+}
+
+// Waits until the packets queued by the transport are sent, or until ctx is done.
+func (s *serverSocket) waitForDrain(ctx context.Context) error {
+	s.transportMu.RLock()
+	t, ok := s.transport.(drainableTransport)
+	s.transportMu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return t.WaitForDrain(ctx)
+}
+
 func (s *serverSocket) onTransportClose(name string, err error) {
 	go func() { // <- To prevent s.TransportName() from blocking (locks transportMu).
 		if err == nil {
@@ -2,6 +2,7 @@ package eio
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -16,6 +17,7 @@ import (
 	"github.com/hhuuson97/socket.io-go/internal/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"nhooyr.io/websocket"
 )
 
 func TestServer(t *testing.T) {
@@ -620,6 +622,230 @@ func TestServer(t *testing.T) {
 		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
 		ts.Close()
 	})
+
+	t.Run("should reject handshakes after StopHandshakes while keeping the sockets", func(t *testing.T) {
+		tw := utils.NewTestWaiter(1)
+		io, close := newTestServer(t, func(socket ServerSocket) *Callbacks {
+			return &Callbacks{
+				OnPacket: func(packets ...*parser.Packet) {
+					for _, packet := range packets {
+						if packet.Type == parser.PacketTypeMessage {
+							assert.Equal(t, "hello", string(packet.Data))
+							tw.Done()
+						}
+					}
+				},
+			}
+		}, nil, nil)
+		ts := httptest.NewServer(io)
+		socket := testDial(t, ts.URL, nil, &ClientConfig{Transports: []string{"polling"}}, nil)
+
+		io.StopHandshakes()
+		assert.False(t, io.IsClosed())
+
+		resp, err := ts.Client().Get(ts.URL + "?EIO=4&transport=polling")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+		packet := mustCreatePacket(t, parser.PacketTypeMessage, false, []byte("hello"))
+		socket.Send(packet)
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+
+		close()
+		ts.Close()
+	})
+
+	t.Run("should capture the details of the handshake request", func(t *testing.T) {
+		requests := make(chan *HandshakeRequest, 1)
+		io, close := newTestServer(t, func(socket ServerSocket) *Callbacks {
+			requests <- socket.HandshakeRequest()
+			return nil
+		}, nil, nil)
+		ts := httptest.NewServer(io)
+
+		req, err := http.NewRequest("GET", ts.URL+"/engine.io/?EIO=4&transport=polling&foo=bar&foo=baz", nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Custom", "value")
+		resp, err := ts.Client().Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		select {
+		case r := <-requests:
+			require.NotNil(t, r)
+			assert.Equal(t, "value", r.Header.Get("X-Custom"))
+			assert.Equal(t, []string{"bar", "baz"}, r.Query["foo"])
+			assert.Equal(t, "/engine.io/?EIO=4&transport=polling&foo=bar&foo=baz", r.URL)
+			assert.Regexp(t, `^127\.0\.0\.1:\d+$`, r.RemoteAddr)
+			assert.Nil(t, r.TLS)
+		case <-time.After(utils.DefaultTestWaitTimeout):
+			t.Fatal("timeout exceeded")
+		}
+
+		close()
+		ts.Close()
+	})
+
+	t.Run("should accept the protocol revision 3 when AllowEIO3 is set", func(t *testing.T) {
+		var (
+			sockets = make(chan ServerSocket, 1)
+			packets = make(chan *parser.Packet, 10)
+		)
+		io, close := newTestServer(t, func(socket ServerSocket) *Callbacks {
+			sockets <- socket
+			return &Callbacks{
+				OnPacket: func(p ...*parser.Packet) {
+					for _, packet := range p {
+						packets <- packet
+					}
+				},
+			}
+		}, &ServerConfig{AllowEIO3: true}, nil)
+		ts := httptest.NewServer(io)
+
+		request := func(method, query, contentType string, body []byte) (*http.Response, []byte) {
+			req, err := http.NewRequest(method, ts.URL+"/engine.io/?EIO=3&transport=polling"+query, bytes.NewReader(body))
+			require.NoError(t, err)
+			if contentType != "" {
+				req.Header.Set("Content-Type", contentType)
+			}
+			resp, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			var respBody bytes.Buffer
+			_, err = respBody.ReadFrom(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			return resp, respBody.Bytes()
+		}
+
+		_, body := request("GET", "", "", nil)
+		p, err := parser.DecodePayloadsV3(bytes.NewReader(body), false)
+		require.NoError(t, err)
+		require.Len(t, p, 1)
+		hr, err := parser.ParseHandshakeResponse(p[0])
+		require.NoError(t, err)
+
+		var socket ServerSocket
+		select {
+		case socket = <-sockets:
+		case <-time.After(utils.DefaultTestWaitTimeout):
+			t.Fatal("timeout exceeded")
+		}
+		assert.Equal(t, 3, socket.ProtocolVersion())
+		sid := "&sid=" + hr.SID
+
+		// The client sends PING, the server replies with PONG.
+		request("POST", sid, "text/plain;charset=UTF-8", []byte("6:4hello1:2"))
+		request("POST", sid, "application/octet-stream", []byte{1, 4, 255, 4, 1, 2, 3})
+		for _, expected := range []*parser.Packet{
+			{Type: parser.PacketTypeMessage, Data: []byte("hello")},
+			{Type: parser.PacketTypePing, Data: []byte{}},
+			{Type: parser.PacketTypeMessage, IsBinary: true, Data: []byte{1, 2, 3}},
+		} {
+			select {
+			case packet := <-packets:
+				assert.Equal(t, expected, packet)
+			case <-time.After(utils.DefaultTestWaitTimeout):
+				t.Fatal("timeout exceeded")
+			}
+		}
+		_, body = request("GET", sid, "", nil)
+		assert.Equal(t, "1:3", string(body))
+
+		binary, err := parser.NewPacket(parser.PacketTypeMessage, true, []byte{1, 2, 3})
+		require.NoError(t, err)
+		socket.Send(binary)
+		resp, body := request("GET", sid, "", nil)
+		assert.Equal(t, "application/octet-stream", resp.Header.Get("Content-Type"))
+		assert.Equal(t, []byte{1, 4, 255, 4, 1, 2, 3}, body)
+
+		// The binary packets are encoded in base64 for the clients that don't support binary data.
+		_, body = request("GET", "&b64=1", "", nil)
+		p, err = parser.DecodePayloadsV3(bytes.NewReader(body), false)
+		require.NoError(t, err)
+		hr, err = parser.ParseHandshakeResponse(p[0])
+		require.NoError(t, err)
+		select {
+		case socket = <-sockets:
+		case <-time.After(utils.DefaultTestWaitTimeout):
+			t.Fatal("timeout exceeded")
+		}
+		socket.Send(binary)
+		_, body = request("GET", "&b64=1&sid="+hr.SID, "", nil)
+		assert.Equal(t, "6:b4AQID", string(body))
+
+		close()
+		ts.Close()
+	})
+
+	t.Run("should prefix the binary frames with the packet type in the protocol revision 3", func(t *testing.T) {
+		var (
+			sockets = make(chan ServerSocket, 1)
+			packets = make(chan *parser.Packet, 1)
+		)
+		io, close := newTestServer(t, func(socket ServerSocket) *Callbacks {
+			sockets <- socket
+			return &Callbacks{
+				OnPacket: func(p ...*parser.Packet) {
+					for _, packet := range p {
+						packets <- packet
+					}
+				},
+			}
+		}, &ServerConfig{AllowEIO3: true}, nil)
+		ts := httptest.NewServer(io)
+
+		ctx, cancel := context.WithTimeout(context.Background(), utils.DefaultTestWaitTimeout)
+		defer cancel()
+		conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(ts.URL, "http")+"/engine.io/?EIO=3&transport=websocket", nil)
+		require.NoError(t, err)
+
+		mt, data, err := conn.Read(ctx)
+		require.NoError(t, err)
+		require.Equal(t, websocket.MessageText, mt)
+		require.Equal(t, byte('0'), data[0])
+
+		var socket ServerSocket
+		select {
+		case socket = <-sockets:
+		case <-time.After(utils.DefaultTestWaitTimeout):
+			t.Fatal("timeout exceeded")
+		}
+
+		require.NoError(t, conn.Write(ctx, websocket.MessageBinary, []byte{4, 1, 2, 3}))
+		select {
+		case packet := <-packets:
+			assert.Equal(t, &parser.Packet{Type: parser.PacketTypeMessage, IsBinary: true, Data: []byte{1, 2, 3}}, packet)
+		case <-time.After(utils.DefaultTestWaitTimeout):
+			t.Fatal("timeout exceeded")
+		}
+
+		binary, err := parser.NewPacket(parser.PacketTypeMessage, true, []byte{1, 2, 3})
+		require.NoError(t, err)
+		socket.Send(binary)
+		mt, data, err = conn.Read(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, websocket.MessageBinary, mt)
+		assert.Equal(t, []byte{4, 1, 2, 3}, data)
+
+		conn.Close(websocket.StatusNormalClosure, "")
+		close()
+		ts.Close()
+	})
+
+	t.Run("should reject the protocol revision 3 by default", func(t *testing.T) {
+		io, close := newTestServer(t, nil, nil, nil)
+
+		rec := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/engine.io/?EIO=3&transport=polling", nil)
+		require.NoError(t, err)
+		io.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		close()
+	})
 }
 
 type testServerOptions struct {
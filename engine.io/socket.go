@@ -1,6 +1,9 @@
 package eio
 
 import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/hhuuson97/socket.io-go/engine.io/parser"
@@ -24,6 +27,13 @@ type (
 
 	ServerSocket interface {
 		Socket
+
+		// The details of the HTTP request that initiated the connection.
+		HandshakeRequest() *HandshakeRequest
+
+		// The protocol revision of the connection: ProtocolVersion, or 3 if
+		// the client is an Engine.IO v3 client (see ServerConfig.AllowEIO3).
+		ProtocolVersion() int
 	}
 
 	ClientSocket interface {
@@ -33,3 +43,33 @@ type (
 		Upgrades() []string
 	}
 )
+
+// The details of the HTTP request that initiated the connection (the handshake).
+// It is captured before the transport takes over the request, and it is the same for
+// every transport, including WebTransport.
+type HandshakeRequest struct {
+	// The headers of the request.
+	Header http.Header
+
+	// The parsed query string of the request.
+	Query url.Values
+
+	// The network address of the client, usually in the form of "IP:port".
+	RemoteAddr string
+
+	// The request URI (path and query string) of the request.
+	URL string
+
+	// The TLS state of the connection. It is nil if the connection is not encrypted.
+	TLS *tls.ConnectionState
+}
+
+func newHandshakeRequest(r *http.Request) *HandshakeRequest {
+	return &HandshakeRequest{
+		Header:     r.Header.Clone(),
+		Query:      r.URL.Query(),
+		RemoteAddr: r.RemoteAddr,
+		URL:        r.URL.RequestURI(),
+		TLS:        r.TLS,
+	}
+}
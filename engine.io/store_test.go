@@ -27,7 +27,7 @@ func TestSocketStore(t *testing.T) {
 		sid := strconv.Itoa(i)
 		ft := utils.NewTestServerTransport()
 		c := ft.Callbacks
-		socket := newServerSocket(sid, nil, ft, c, 0, 0, NewNoopDebugger(), onClose)
+		socket := newServerSocket(sid, ProtocolVersion, nil, ft, c, 0, 0, NewNoopDebugger(), onClose)
 
 		ok := store.set(socket.ID(), socket)
 		require.True(t, ok)
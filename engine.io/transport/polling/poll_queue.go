@@ -1,6 +1,7 @@
 package polling
 
 import (
+	"context"
 	"time"
 
 	"github.com/hhuuson97/socket.io-go/internal/sync"
@@ -11,12 +12,15 @@ import (
 type pollQueue struct {
 	packets []*parser.Packet
 	ready   chan struct{}
-	mu      sync.Mutex
+	// Closed (and replaced) when the queued packets are retrieved.
+	drain chan struct{}
+	mu    sync.Mutex
 }
 
 func newPollQueue() *pollQueue {
 	return &pollQueue{
 		ready: make(chan struct{}),
+		drain: make(chan struct{}),
 	}
 }
 
@@ -60,10 +64,32 @@ func (pq *pollQueue) get() []*parser.Packet {
 	pq.mu.Lock()
 	packets := pq.packets
 	pq.packets = nil
+	if len(packets) != 0 {
+		close(pq.drain)
+		pq.drain = make(chan struct{})
+	}
 	pq.mu.Unlock()
 	return packets
 }
 
+// Wait until the queued packets are retrieved, or until ctx is done.
+func (pq *pollQueue) waitForDrain(ctx context.Context) error {
+	pq.mu.Lock()
+	alreadyDrained := len(pq.packets) == 0
+	drain := pq.drain
+	pq.mu.Unlock()
+	if alreadyDrained {
+		return nil
+	}
+
+	select {
+	case <-drain:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (pq *pollQueue) len() int {
 	pq.mu.Lock()
 	l := len(pq.packets)
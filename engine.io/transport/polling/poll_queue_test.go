@@ -2,6 +2,7 @@ package polling
 
 import (
 	"bytes"
+	"context"
 	"testing"
 	"time"
 
@@ -92,6 +93,22 @@ func TestPollTimeout(t *testing.T) {
 	require.Equal(t, 0, len(packets), "expected 0 packet (because of the timeout)")
 }
 
+func TestPollQueueWaitForDrain(t *testing.T) {
+	pq := newPollQueue()
+	require.NoError(t, pq.waitForDrain(context.Background()))
+
+	pq.add(mustCreatePacket(t, parser.PacketTypeMessage, false, nil))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, pq.waitForDrain(ctx), context.DeadlineExceeded)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		pq.get()
+	}()
+	require.NoError(t, pq.waitForDrain(context.Background()))
+}
+
 func mustCreatePacket(t *testing.T, packetType parser.PacketType, isBinary bool, data []byte) *parser.Packet {
 	p, err := parser.NewPacket(packetType, isBinary, data)
 	if err != nil {
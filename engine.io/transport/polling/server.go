@@ -2,6 +2,7 @@ package polling
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -17,6 +18,8 @@ import (
 )
 
 type ServerTransport struct {
+	protocolVersion   int
+	supportsBinary    bool
 	maxHTTPBufferSize int64
 
 	pq          *pollQueue
@@ -26,8 +29,18 @@ type ServerTransport struct {
 	once      sync.Once
 }
 
-func NewServerTransport(callbacks *transport.Callbacks, maxBufferSize int64, pollTimeout time.Duration) *ServerTransport {
+// supportsBinary is only used with the protocol revision 3 (it decides the payload format),
+// the revision 4 always sends the binary packets in base64.
+func NewServerTransport(
+	callbacks *transport.Callbacks,
+	protocolVersion int,
+	maxBufferSize int64,
+	supportsBinary bool,
+	pollTimeout time.Duration,
+) *ServerTransport {
 	return &ServerTransport{
+		protocolVersion:   protocolVersion,
+		supportsBinary:    supportsBinary,
 		maxHTTPBufferSize: maxBufferSize,
 		pq:                newPollQueue(),
 		pollTimeout:       pollTimeout,
@@ -45,6 +58,11 @@ func (t *ServerTransport) QueuedPackets() []*parser.Packet {
 	return t.pq.get()
 }
 
+// Waits until the client polls the queued packets, or until ctx is done.
+func (t *ServerTransport) WaitForDrain(ctx context.Context) error {
+	return t.pq.waitForDrain(ctx)
+}
+
 func (t *ServerTransport) Handshake(handshakePacket *parser.Packet, w http.ResponseWriter, r *http.Request) (sid string, err error) {
 	if handshakePacket != nil {
 		t.Send(handshakePacket)
@@ -85,9 +103,13 @@ func (t *ServerTransport) writeJSONPBody(w io.Writer, jsonp string, packets []*p
 	}
 
 	buf := bytes.Buffer{}
-	buf.Grow(parser.EncodedPayloadsLen(packets...))
-
-	err = parser.EncodePayloads(&buf, packets...)
+	if t.protocolVersion == 3 {
+		// JSON-P only supports the text format.
+		err = parser.EncodePayloadsV3(&buf, false, packets...)
+	} else {
+		buf.Grow(parser.EncodedPayloadsLen(packets...))
+		err = parser.EncodePayloads(&buf, packets...)
+	}
 	if err != nil {
 		return err
 	}
@@ -104,8 +126,30 @@ func (t *ServerTransport) handlePollRequest(w http.ResponseWriter, r *http.Reque
 	wh := w.Header()
 	t.setHeaders(w, r)
 
-	// If this is not a JSON-P request
-	if jsonp == "" {
+	if jsonp == "" && t.protocolVersion == 3 {
+		buf := bytes.Buffer{}
+		err := parser.EncodePayloadsV3(&buf, t.supportsBinary, packets...)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			t.close(err)
+			return
+		}
+
+		if parser.IsBinaryPayloadV3(t.supportsBinary, packets...) {
+			wh.Set("Content-Type", "application/octet-stream")
+		} else {
+			wh.Set("Content-Type", "text/plain; charset=UTF-8")
+		}
+		wh.Set("Content-Length", strconv.Itoa(buf.Len()))
+		w.WriteHeader(200)
+
+		_, err = w.Write(buf.Bytes())
+		if err != nil {
+			t.close(err)
+			return
+		}
+	} else if jsonp == "" {
+		// If this is not a JSON-P request
 		wh.Set("Content-Type", "text/plain; charset=UTF-8")
 		wh.Set("Content-Length", strconv.Itoa(parser.EncodedPayloadsLen(packets...)))
 		w.WriteHeader(200)
@@ -158,7 +202,7 @@ func (t *ServerTransport) handleDataRequest(w http.ResponseWriter, r *http.Reque
 
 	// If this is not a JSON-P request
 	if jsonp == "" {
-		packets, err = parser.DecodePayloads(r.Body)
+		packets, err = t.decodePayloads(r.Body, r.Header.Get("Content-Type") == "application/octet-stream")
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
 			t.close(err)
@@ -176,7 +220,7 @@ func (t *ServerTransport) handleDataRequest(w http.ResponseWriter, r *http.Reque
 		d = slashReplacer.Replace(d)
 		buf := bytes.NewBuffer([]byte(d))
 
-		packets, err = parser.DecodePayloads(buf)
+		packets, err = t.decodePayloads(buf, false)
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
 			t.close(err)
@@ -197,6 +241,13 @@ func (t *ServerTransport) handleDataRequest(w http.ResponseWriter, r *http.Reque
 	w.Write(ok)
 }
 
+func (t *ServerTransport) decodePayloads(r io.Reader, binaryPayload bool) ([]*parser.Packet, error) {
+	if t.protocolVersion == 3 {
+		return parser.DecodePayloadsV3(r, binaryPayload)
+	}
+	return parser.DecodePayloads(r)
+}
+
 func (t *ServerTransport) Discard() {
 	t.once.Do(func() {
 		// Send a NOOP packet to force a poll cycle.
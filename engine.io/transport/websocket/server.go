@@ -12,9 +12,10 @@ import (
 )
 
 type ServerTransport struct {
-	readLimit      int64
-	supportsBinary bool
-	acceptOptions  *websocket.AcceptOptions
+	protocolVersion int
+	readLimit       int64
+	supportsBinary  bool
+	acceptOptions   *websocket.AcceptOptions
 
 	ctx  context.Context
 	conn *websocket.Conn
@@ -25,15 +26,17 @@ type ServerTransport struct {
 
 func NewServerTransport(
 	callbacks *transport.Callbacks,
+	protocolVersion int,
 	maxBufferSize int64,
 	supportsBinary bool,
 	acceptOptions *websocket.AcceptOptions,
 ) *ServerTransport {
 	return &ServerTransport{
-		readLimit:      maxBufferSize,
-		supportsBinary: supportsBinary,
-		callbacks:      callbacks,
-		acceptOptions:  acceptOptions,
+		protocolVersion: protocolVersion,
+		readLimit:       maxBufferSize,
+		supportsBinary:  supportsBinary,
+		callbacks:       callbacks,
+		acceptOptions:   acceptOptions,
 	}
 }
 
@@ -67,6 +70,9 @@ func (t *ServerTransport) send(packet *parser.Packet) error {
 		return err
 	}
 	defer w.Close()
+	if t.protocolVersion == 3 {
+		return packet.EncodeV3(w, true)
+	}
 	return packet.Encode(w, true)
 }
 
@@ -117,6 +123,9 @@ func (t *ServerTransport) nextPacket() (*parser.Packet, error) {
 	if err != nil {
 		return nil, err
 	}
+	if t.protocolVersion == 3 {
+		return parser.DecodeV3(r, mt == websocket.MessageBinary)
+	}
 	return parser.Decode(r, mt == websocket.MessageBinary)
 }
 
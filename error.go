@@ -1,8 +1,8 @@
 package sio
 
-import "fmt"
+import "github.com/hhuuson97/socket.io-go/adapter"
 
-var ErrAckTimeout = fmt.Errorf("ack timeout")
+var ErrAckTimeout = adapter.ErrAckTimeout
 
 // This is a wrapper for the errors internal to socket.io.
 //
@@ -7,18 +7,16 @@ import (
 
 	"github.com/gookit/color"
 	sio "github.com/hhuuson97/socket.io-go"
+	"github.com/hhuuson97/socket.io-go/adapter"
 )
 
 type api struct {
 	numUsers   int
 	numUsersMu sync.Mutex
-
-	usernames   map[sio.ServerSocket]string
-	usernamesMu sync.Mutex
 }
 
 func newAPI() *api {
-	return &api{usernames: make(map[sio.ServerSocket]string)}
+	return &api{}
 }
 
 func (a *api) setup(root *sio.Namespace) {
@@ -34,13 +32,10 @@ func (a *api) setup(root *sio.Namespace) {
 		})
 
 		socket.OnEvent("add user", func(username string) {
-			a.usernamesMu.Lock()
-			defer a.usernamesMu.Unlock()
-			_, ok := a.usernames[socket]
-			if ok {
+			ok := socket.Data().SetIfAbsent("username", username)
+			if !ok {
 				return
 			}
-			a.usernames[socket] = username
 			a.numUsersMu.Lock()
 			a.numUsers++
 			numUsers := a.numUsers
@@ -79,14 +74,11 @@ func (a *api) setup(root *sio.Namespace) {
 		})
 
 		socket.OnDisconnect(func(reason sio.Reason) {
-			a.usernamesMu.Lock()
-			defer a.usernamesMu.Unlock()
-			username, ok := a.usernames[socket]
+			username, ok := adapter.GetSocketData[string](socket.Data(), "username")
 			if !ok {
 				return
 			}
 
-			delete(a.usernames, socket)
 			a.numUsersMu.Lock()
 			a.numUsers--
 			numUsers := a.numUsers
@@ -104,9 +96,7 @@ func (a *api) setup(root *sio.Namespace) {
 }
 
 func (a *api) username(socket sio.ServerSocket) (username string) {
-	a.usernamesMu.Lock()
-	username = a.usernames[socket]
-	a.usernamesMu.Unlock()
+	username, _ = adapter.GetSocketData[string](socket.Data(), "username")
 	return
 }
 
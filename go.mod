@@ -4,6 +4,7 @@ go 1.22
 
 require (
 	github.com/NYTimes/gziphandler v1.1.1
+	github.com/alicebob/miniredis/v2 v2.33.0
 	github.com/bytedance/sonic v1.7.1
 	github.com/cristalhq/jsn v0.2.0
 	github.com/deckarep/golang-set/v2 v2.6.0
@@ -15,15 +16,18 @@ require (
 	github.com/mitchellh/mapstructure v1.5.0
 	github.com/quic-go/quic-go v0.45.2
 	github.com/quic-go/webtransport-go v0.8.0
+	github.com/redis/go-redis/v9 v9.6.1
 	github.com/sasha-s/go-deadlock v0.3.1
 	github.com/spf13/pflag v1.0.5
 	github.com/stretchr/testify v1.8.4
+	github.com/vmihailenco/msgpack/v5 v5.4.1
 	github.com/xiegeo/coloredgoroutine v0.1.1
 	golang.org/x/term v0.22.0
 	nhooyr.io/websocket v1.8.11
 )
 
 require (
+	github.com/alicebob/gopher-json v0.0.0-20200520072559-a9ecdc9d1d3a // indirect
 	github.com/cespare/xxhash/v2 v2.2.0 // indirect
 	github.com/chenzhuoyu/base64x v0.0.0-20221115062448-fe3a3abad311 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
@@ -39,9 +43,10 @@ require (
 	github.com/petermattis/goid v0.0.0-20240716203034-badd1c0974d6 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/quic-go/qpack v0.4.0 // indirect
-	github.com/redis/go-redis/v9 v9.6.1 // indirect
 	github.com/twitchyliquid64/golang-asm v0.15.1 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
 	github.com/xo/terminfo v0.0.0-20210125001918-ca9a967f8778 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
 	go.uber.org/mock v0.4.0 // indirect
 	golang.org/x/arch v0.0.0-20210923205945-b76863e36670 // indirect
 	golang.org/x/crypto v0.25.0 // indirect
@@ -6,11 +6,21 @@ import (
 	"time"
 
 	"github.com/hhuuson97/socket.io-go/internal/sync"
+	"github.com/hhuuson97/socket.io-go/parser"
 )
 
 type eventHandler struct {
 	rv        reflect.Value
 	inputArgs []reflect.Type
+
+	// Set for the handlers registered with OnPatternEvent.
+	// The 1st argument of the handler is the event name, which is not a part of the packet.
+	hasEventName bool
+
+	// Set for the handlers registered with On and OnAck.
+	// It decodes the argument without a reflect.Value (see parser.DecodeInto),
+	// and the returned event calls the handler without reflect.Value.Call.
+	decodeTyped func(decode parser.Decode) (typedEvent, error)
 }
 
 func newEventHandler(f any) (*eventHandler, error) {
@@ -38,6 +48,53 @@ func newEventHandler(f any) (*eventHandler, error) {
 	return s, err
 }
 
+func newPatternEventHandler(pattern string, f any) (*eventHandler, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("sio: pattern cannot be empty")
+	}
+	h, err := newEventHandler(f)
+	if err != nil {
+		return nil, err
+	}
+	if len(h.inputArgs) == 0 || h.inputArgs[0].Kind() != reflect.String {
+		return nil, fmt.Errorf("sio: the 1st parameter of a pattern event handler must be the event name (string)")
+	}
+	h.hasEventName = true
+	return h, nil
+}
+
+// Reports whether the event name matches the pattern.
+// In the pattern, '*' matches any sequence of characters (including the empty one)
+// and '?' matches any single character.
+func matchEventPattern(pattern, eventName string) bool {
+	// Backtracking on the last '*' is enough, since '*' matches any sequence.
+	var (
+		p, e         = 0, 0
+		starP, starE = -1, 0
+		pr, nr       = []rune(pattern), []rune(eventName)
+	)
+	for e < len(nr) {
+		switch {
+		case p < len(pr) && (pr[p] == '?' || pr[p] == nr[e]):
+			p++
+			e++
+		case p < len(pr) && pr[p] == '*':
+			starP, starE = p, e
+			p++
+		case starP != -1:
+			p = starP + 1
+			starE++
+			e = starE
+		default:
+			return false
+		}
+	}
+	for p < len(pr) && pr[p] == '*' {
+		p++
+	}
+	return p == len(pr)
+}
+
 func (s *eventHandler) ack() (ok bool, err error) {
 	if len(s.inputArgs) > 0 && s.inputArgs[len(s.inputArgs)-1].Kind() == reflect.Func {
 		if s.inputArgs[len(s.inputArgs)-1].NumOut() > 0 {
@@ -49,6 +106,28 @@ func (s *eventHandler) ack() (ok bool, err error) {
 	return
 }
 
+// Decodes the arguments of an event for the handler. The event name of a pattern
+// event handler is not included, and the acknowledgement function (if the handler has one) is a nil function.
+func (f *eventHandler) decodeArgs(decode parser.Decode) ([]reflect.Value, error) {
+	types := f.inputArgs
+	if f.hasEventName {
+		types = types[1:]
+	}
+	ack, _ := f.ack()
+	if ack {
+		types = types[:len(types)-1]
+	}
+
+	values, err := decodeHandlerArgs(decode, types, f.rv.Type().IsVariadic())
+	if err != nil {
+		return nil, err
+	}
+	if ack {
+		values = append(values, reflect.Zero(f.inputArgs[len(f.inputArgs)-1]))
+	}
+	return values, nil
+}
+
 func (f *eventHandler) call(args ...reflect.Value) (ret []reflect.Value, err error) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -64,11 +143,29 @@ func (f *eventHandler) call(args ...reflect.Value) (ret []reflect.Value, err err
 	return
 }
 
+// sendAck is nil if the event doesn't require an acknowledgement.
+func (f *eventHandler) callTyped(event typedEvent, sendAck func(args []reflect.Value)) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			var ok bool
+			err, ok = r.(error)
+			if !ok {
+				err = fmt.Errorf("sio: handler error: %v", r)
+			}
+		}
+	}()
+
+	event.call(sendAck)
+	return
+}
+
 type ackHandler struct {
 	rv        reflect.Value
 	inputArgs []reflect.Type
 
 	hasError bool
+	// The handler is a decodeAckFunc.
+	decodeLater bool
 
 	called   bool
 	timedOut bool
@@ -88,15 +185,21 @@ func newAckHandler(f any, hasError bool) (*ackHandler, error) {
 		inputArgs[i] = rt.In(i)
 	}
 
+	decodeLater := rt == reflectDecodeAckFunc
+	if decodeLater {
+		hasError = true
+	}
+
 	err := checkAckFunc(f, hasError)
 	if err != nil {
 		return nil, err
 	}
 
 	return &ackHandler{
-		rv:        rv,
-		inputArgs: inputArgs,
-		hasError:  hasError,
+		rv:          rv,
+		inputArgs:   inputArgs,
+		hasError:    hasError,
+		decodeLater: decodeLater,
 	}, nil
 }
 
@@ -126,11 +229,24 @@ func newAckHandlerWithTimeout(f any, timeout time.Duration, timeoutFunc func())
 		for i := 1; i < len(args); i++ {
 			args[i] = reflect.New(h.inputArgs[i]).Elem()
 		}
-		h.rv.Call(args)
+		if h.rv.Type().IsVariadic() {
+			h.rv.CallSlice(args)
+		} else {
+			h.rv.Call(args)
+		}
 	}()
 	return h, nil
 }
 
+// Decodes the arguments of an acknowledgement for the handler (without the error).
+func (f *ackHandler) decodeArgs(decode parser.Decode) ([]reflect.Value, error) {
+	types := f.inputArgs
+	if f.hasError {
+		types = types[1:]
+	}
+	return decodeHandlerArgs(decode, types, f.rv.Type().IsVariadic())
+}
+
 func (f *ackHandler) call(args ...reflect.Value) (err error) {
 	f.mu.Lock()
 	if f.timedOut {
@@ -161,6 +277,73 @@ func (f *ackHandler) call(args ...reflect.Value) (err error) {
 	return
 }
 
+// Decodes the arguments of a packet into the parameter types of a handler.
+//
+// If there are fewer arguments than parameters, the missing pointer parameters are nil
+// and the others are zero values. The extra arguments are decoded into the element type
+// of the variadic parameter (e.g. func(args ...json.RawMessage) receives the arguments undecoded),
+// or ignored if the handler is not variadic. For a variadic handler, the values are to be
+// passed to reflect.Value.Call one by one.
+func decodeHandlerArgs(decode parser.Decode, types []reflect.Type, variadic bool) ([]reflect.Value, error) {
+	fixed := types
+	if variadic {
+		fixed = types[:len(types)-1]
+	}
+
+	// The number of arguments is only needed to fill the variadic parameter
+	// and to tell the missing pointer arguments apart, so it is not counted otherwise.
+	// The arguments are counted without being decoded.
+	n := -1
+	if variadic || hasPointerType(fixed) {
+		values, err := decode(reflectArgsCount)
+		if err != nil {
+			return nil, err
+		}
+		if len(values) != 1 {
+			return nil, fmt.Errorf("sio: invalid number of arguments")
+		}
+		n = int(values[0].Interface().(parser.ArgsCount))
+	}
+
+	decodeTypes := fixed
+	if variadic && n > len(fixed) {
+		decodeTypes = make([]reflect.Type, n)
+		copy(decodeTypes, fixed)
+		for i := len(fixed); i < n; i++ {
+			decodeTypes[i] = types[len(types)-1].Elem()
+		}
+	}
+
+	values, err := decode(decodeTypes...)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != len(decodeTypes) {
+		return nil, fmt.Errorf("sio: invalid number of arguments")
+	}
+
+	for i, v := range values {
+		typ := decodeTypes[i]
+		if typ.Kind() == reflect.Ptr {
+			if n >= 0 && i >= n {
+				values[i] = reflect.Zero(typ)
+			}
+		} else if v.Kind() == reflect.Ptr {
+			values[i] = v.Elem()
+		}
+	}
+	return values, nil
+}
+
+func hasPointerType(types []reflect.Type) bool {
+	for _, typ := range types {
+		if typ.Kind() == reflect.Ptr {
+			return true
+		}
+	}
+	return false
+}
+
 func dismantleAckFunc(rt reflect.Type) (in []reflect.Type, variadic bool) {
 	in = make([]reflect.Type, rt.NumIn())
 	for i := range in {
@@ -170,11 +353,32 @@ func dismantleAckFunc(rt reflect.Type) (in []reflect.Type, variadic bool) {
 	return
 }
 
+// The function created by reflect.MakeFunc receives the variadic arguments as a slice,
+// they are sent as separate arguments.
+func expandVariadicArgs(args []reflect.Value) []reflect.Value {
+	last := args[len(args)-1]
+	expanded := make([]reflect.Value, 0, len(args)-1+last.Len())
+	expanded = append(expanded, args[:len(args)-1]...)
+	for i := 0; i < last.Len(); i++ {
+		expanded = append(expanded, last.Index(i))
+	}
+	return expanded
+}
+
 var (
-	_emptyError  error
-	reflectError = reflect.TypeOf(&_emptyError).Elem()
+	_emptyError          error
+	reflectError         = reflect.TypeOf(&_emptyError).Elem()
+	reflectDecodeAckFunc = reflect.TypeOf(decodeAckFunc(nil))
 )
 
+// The acknowledgement function of Emitter.EmitWithAck. It takes the decode
+// function of the packet instead of the decoded values.
+//
+// It has its own type, so that an acknowledgement function of the user
+// with the same signature is not mistaken for it. The type is kept when
+// the function is wrapped (see clientPacketQueue.addToQueue).
+type decodeAckFunc func(err error, decode parser.Decode)
+
 func checkAckFunc(f any, mustHaveError bool) error {
 	rt := reflect.TypeOf(f)
 
@@ -1,8 +1,12 @@
 package sio
 
 import (
+	"reflect"
 	"testing"
 
+	"github.com/hhuuson97/socket.io-go/parser"
+	jsonparser "github.com/hhuuson97/socket.io-go/parser/json"
+	"github.com/hhuuson97/socket.io-go/parser/json/serializer/stdjson"
 	"github.com/stretchr/testify/require"
 )
 
@@ -27,3 +31,157 @@ func TestAckCheckAckFunc(t *testing.T) {
 	err = checkAckFunc(ackWithReturn, false)
 	require.Error(t, err)
 }
+
+func TestNewAckHandlerDecodeLater(t *testing.T) {
+	h, err := newAckHandler(func(err error, decode parser.Decode) {}, false)
+	require.NoError(t, err)
+	require.False(t, h.decodeLater)
+
+	h, err = newAckHandler(decodeAckFunc(func(err error, decode parser.Decode) {}), false)
+	require.NoError(t, err)
+	require.True(t, h.decodeLater)
+	require.True(t, h.hasError)
+}
+
+func TestTypedEventHandler(t *testing.T) {
+	type message struct {
+		Text string
+	}
+	var received message
+	h := newTypedEventHandler(func(msg message) { received = msg })
+	require.Equal(t, []reflect.Type{reflect.TypeFor[message]()}, h.inputArgs)
+
+	event, err := h.decodeTyped(newTestDecode(t, "message", message{Text: "hello"}))
+	require.NoError(t, err)
+	require.Equal(t, []any{message{Text: "hello"}}, []any{event.values()[0].Interface()})
+	err = h.callTyped(event, nil)
+	require.NoError(t, err)
+	require.Equal(t, message{Text: "hello"}, received)
+
+	// A missing argument is passed as the zero value.
+	var v any = "not nil"
+	hv := newTypedEventHandler(func(arg any) { v = arg })
+	event, err = hv.decodeTyped(newTestDecode(t, "message"))
+	require.NoError(t, err)
+	err = hv.callTyped(event, nil)
+	require.NoError(t, err)
+	require.Nil(t, v)
+
+	// The attachments are decoded too.
+	var data Binary
+	hb := newTypedEventHandler(func(b Binary) { data = b })
+	event, err = hb.decodeTyped(newTestDecode(t, "message", Binary{1, 2}))
+	require.NoError(t, err)
+	err = hb.callTyped(event, nil)
+	require.NoError(t, err)
+	require.Equal(t, Binary{1, 2}, data)
+
+	var sent []reflect.Value
+	h = newTypedAckEventHandler(func(n int, ack func(reply string)) { ack("reply") })
+	ok, err := h.ack()
+	require.True(t, ok)
+	require.NoError(t, err)
+	event, err = h.decodeTyped(newTestDecode(t, "message", 1))
+	require.NoError(t, err)
+	require.Len(t, event.values(), 2)
+	err = h.callTyped(event, func(args []reflect.Value) { sent = args })
+	require.NoError(t, err)
+	require.Len(t, sent, 1)
+	require.Equal(t, "reply", sent[0].Interface())
+
+	// The acknowledgement is a no-op when it is not required.
+	err = h.callTyped(event, nil)
+	require.NoError(t, err)
+
+	// The decoding errors are returned.
+	_, err = h.decodeTyped(newTestDecode(t, "message", "not a number"))
+	require.Error(t, err)
+
+	h = newTypedEventHandler(func(n int) { panic("oops") })
+	event, err = h.decodeTyped(newTestDecode(t, "message", 1))
+	require.NoError(t, err)
+	err = h.callTyped(event, nil)
+	require.Error(t, err)
+}
+
+// The arguments are decoded as they are when a packet is received, so that the cost of the decoding is included.
+func BenchmarkEventHandlerCall(b *testing.B) {
+	type message struct {
+		Text string
+	}
+	decode := newTestDecode(b, "message", message{Text: "hello"})
+
+	b.Run("reflective", func(b *testing.B) {
+		h, err := newEventHandler(func(msg message) {})
+		require.NoError(b, err)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			values, _ := h.decodeArgs(decode)
+			_, _ = h.call(values...)
+		}
+	})
+
+	b.Run("typed", func(b *testing.B) {
+		h := newTypedEventHandler(func(msg message) {})
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			event, _ := h.decodeTyped(decode)
+			_ = h.callTyped(event, nil)
+		}
+	})
+}
+
+// Returns the decode function of an event packet encoded by the JSON parser.
+func newTestDecode(t testing.TB, eventName string, v ...any) parser.Decode {
+	p := jsonparser.NewCreator(0, stdjson.New())()
+	header := &parser.PacketHeader{Type: parser.PacketTypeEvent, Namespace: "/"}
+	args := append([]any{eventName}, v...)
+	buffers, err := p.Encode(header, &args)
+	require.NoError(t, err)
+
+	var decode parser.Decode
+	for _, buf := range buffers {
+		err = p.Add(buf, func(header *parser.PacketHeader, eventName string, d parser.Decode) {
+			decode = d
+		})
+		require.NoError(t, err)
+	}
+	require.NotNil(t, decode)
+	return decode
+}
+
+func TestMatchEventPattern(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		eventName string
+		match     bool
+	}{
+		{"chat:*", "chat:message", true},
+		{"chat:*", "chat:", true},
+		{"chat:*", "chat", false},
+		{"chat:*", "room:message", false},
+		{"*", "anything", true},
+		{"*:typing", "chat:typing", true},
+		{"*:typing", "chat:typing:stop", false},
+		{"chat:?", "chat:a", true},
+		{"chat:?", "chat:ab", false},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+		{"chat:message", "chat:message", true},
+		{"çhat:*", "çhat:ü", true},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.match, matchEventPattern(test.pattern, test.eventName), "pattern: %s, event name: %s", test.pattern, test.eventName)
+	}
+}
+
+func TestNewPatternEventHandler(t *testing.T) {
+	_, err := newPatternEventHandler("chat:*", func(eventName string, msg string) {})
+	require.NoError(t, err)
+
+	_, err = newPatternEventHandler("chat:*", func(msg int) {})
+	require.Error(t, err)
+
+	_, err = newPatternEventHandler("", func(eventName string) {})
+	require.Error(t, err)
+}
@@ -0,0 +1,183 @@
+package sio
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/hhuuson97/socket.io-go/parser"
+)
+
+// Implemented by the server and client sockets.
+type eventHandlerAdder interface {
+	addEventHandler(eventName string, handler *eventHandler, once bool)
+}
+
+// Registers an event handler with a single argument.
+//
+// Unlike OnEvent, the argument type is checked at compile time, the argument is decoded
+// directly into a value of type Req (see parser.DecodeInto) and the handler is called without reflection.
+// The handler can be removed with OffEvent.
+//
+// The argument is only wrapped into a reflect.Value if there are event middlewares (see ServerSocket.Use).
+//
+// Example:
+//
+//	sio.On(socket, "message", func(msg Message) { ... })
+func On[Req any](socket Socket, eventName string, handler func(req Req)) {
+	addTypedEventHandler(socket, eventName, newTypedEventHandler(handler), false)
+}
+
+// Registers a one-time event handler with a single argument.
+// The handler will run once and will be removed afterwards.
+//
+// See On.
+func Once[Req any](socket Socket, eventName string, handler func(req Req)) {
+	addTypedEventHandler(socket, eventName, newTypedEventHandler(handler), true)
+}
+
+// Registers an event handler with a single argument and an acknowledgement
+// function that sends a single value.
+//
+// See On.
+//
+// Example:
+//
+//	sio.OnAck(socket, "echo", func(msg string, ack func(reply string)) { ack(msg) })
+func OnAck[Req, Resp any](socket Socket, eventName string, handler func(req Req, ack func(resp Resp))) {
+	addTypedEventHandler(socket, eventName, newTypedAckEventHandler(handler), false)
+}
+
+// Registers a one-time event handler with a single argument and an acknowledgement function.
+// The handler will run once and will be removed afterwards.
+//
+// See OnAck.
+func OnceAck[Req, Resp any](socket Socket, eventName string, handler func(req Req, ack func(resp Resp))) {
+	addTypedEventHandler(socket, eventName, newTypedAckEventHandler(handler), true)
+}
+
+// Implemented by the sockets and Emitter.
+type typedEmitter interface {
+	Emit(eventName string, v ...any)
+	EmitWithAck(ctx context.Context, eventName string, v ...any) (AckResponse, error)
+}
+
+// Emits an event with a single argument, whose type is checked at compile time.
+// It is the counterpart of On.
+//
+// Example:
+//
+//	sio.Emit(socket, "message", Message{Text: "hello"})
+func Emit[Req any](emitter typedEmitter, eventName string, req Req) {
+	emitter.Emit(eventName, req)
+}
+
+// Emits an event with a single argument, and blocks until its acknowledgement is received.
+// The acknowledgement is decoded into a single value of type Resp.
+// It is the counterpart of OnAck.
+//
+// The errors are the ones of Emitter.EmitWithAck, and the error of the decoding of the acknowledgement.
+//
+// Example:
+//
+//	reply, err := sio.EmitWithAck[string, string](ctx, socket.Timeout(5*time.Second), "echo", "hello")
+func EmitWithAck[Req, Resp any](ctx context.Context, emitter typedEmitter, eventName string, req Req) (resp Resp, err error) {
+	res, err := emitter.EmitWithAck(ctx, eventName, req)
+	if err != nil {
+		return resp, err
+	}
+	err = res.Decode(&resp)
+	return resp, err
+}
+
+func addTypedEventHandler(socket Socket, eventName string, handler *eventHandler, once bool) {
+	adder, ok := socket.(eventHandlerAdder)
+	if !ok {
+		panic(fmt.Errorf("sio: typed event handlers are not supported by %T", socket))
+	}
+	adder.addEventHandler(eventName, handler, once)
+}
+
+func newTypedEventHandler[Req any](handler func(req Req)) *eventHandler {
+	if handler == nil {
+		panic(fmt.Errorf("sio: function expected"))
+	}
+	return &eventHandler{
+		rv:        reflect.ValueOf(handler),
+		inputArgs: []reflect.Type{reflect.TypeFor[Req]()},
+		decodeTyped: func(decode parser.Decode) (typedEvent, error) {
+			e := &typedEventOf[Req]{handler: handler}
+			return e, decodeInto(decode, &e.req)
+		},
+	}
+}
+
+func newTypedAckEventHandler[Req, Resp any](handler func(req Req, ack func(resp Resp))) *eventHandler {
+	if handler == nil {
+		panic(fmt.Errorf("sio: function expected"))
+	}
+	return &eventHandler{
+		rv:        reflect.ValueOf(handler),
+		inputArgs: []reflect.Type{reflect.TypeFor[Req](), reflect.TypeFor[func(Resp)]()},
+		decodeTyped: func(decode parser.Decode) (typedEvent, error) {
+			e := &typedAckEventOf[Req, Resp]{handler: handler}
+			return e, decodeInto(decode, &e.req)
+		},
+	}
+}
+
+var reflectDecodeInto = reflect.TypeOf(parser.DecodeInto(nil))
+
+// Decodes the arguments of a packet into the values that ptrs point to.
+func decodeInto(decode parser.Decode, ptrs ...any) error {
+	values, err := decode(reflectDecodeInto)
+	if err != nil {
+		return err
+	}
+	if len(values) != 1 {
+		return fmt.Errorf("sio: invalid number of arguments")
+	}
+	into, ok := values[0].Interface().(parser.DecodeInto)
+	if !ok {
+		return fmt.Errorf("sio: the parser doesn't support parser.DecodeInto")
+	}
+	return into(ptrs...)
+}
+
+// An event decoded for a typed handler.
+type typedEvent interface {
+	// Returns the arguments of the handler, for the event middlewares.
+	values() []reflect.Value
+
+	// sendAck is nil if the event doesn't require an acknowledgement.
+	call(sendAck func(args []reflect.Value))
+}
+
+type typedEventOf[Req any] struct {
+	req     Req
+	handler func(req Req)
+}
+
+func (e *typedEventOf[Req]) values() []reflect.Value {
+	return []reflect.Value{reflect.ValueOf(&e.req).Elem()}
+}
+
+func (e *typedEventOf[Req]) call(_ func(args []reflect.Value)) { e.handler(e.req) }
+
+type typedAckEventOf[Req, Resp any] struct {
+	req     Req
+	handler func(req Req, ack func(resp Resp))
+}
+
+func (e *typedAckEventOf[Req, Resp]) values() []reflect.Value {
+	return []reflect.Value{reflect.ValueOf(&e.req).Elem(), reflect.Zero(reflect.TypeFor[func(Resp)]())}
+}
+
+func (e *typedAckEventOf[Req, Resp]) call(sendAck func(args []reflect.Value)) {
+	e.handler(e.req, func(resp Resp) {
+		if sendAck != nil {
+			// Take the address so that a nil interface keeps its type.
+			sendAck([]reflect.Value{reflect.ValueOf(&resp).Elem()})
+		}
+	})
+}
@@ -0,0 +1,56 @@
+// Package reserved lists the events that are reserved by Socket.IO and cannot be emitted.
+// It is shared by the server and the emitter.
+package reserved
+
+var clientReservedEvents = map[string]bool{
+	"connect":        true,
+	"connect_error":  true,
+	"disconnect":     true,
+	"disconnecting":  true,
+	"newListener":    true,
+	"removeListener": true,
+}
+
+// Reports whether the event cannot be emitted by a client socket.
+func IsClientEvent(eventName string) bool {
+	isReserved, ok := clientReservedEvents[eventName]
+	if ok && isReserved {
+		return true
+	}
+	return false
+}
+
+var serverReservedEvents = map[string]bool{
+	"connect":        true,
+	"connect_error":  true,
+	"disconnect":     true,
+	"disconnecting":  true,
+	"newListener":    true,
+	"removeListener": true,
+	"connection":     true,
+	"error":          true,
+}
+
+// Reports whether the event cannot be emitted by a server socket.
+func IsServerEvent(eventName string) bool {
+	isReserved, ok := serverReservedEvents[eventName]
+	if ok && isReserved {
+		return true
+	}
+	return false
+}
+
+var nspReservedEvents = map[string]bool{
+	"connect":       true,
+	"connection":    true,
+	"new_namespace": true,
+}
+
+// Reports whether the event cannot be emitted to a namespace.
+func IsNspEvent(eventName string) bool {
+	isReserved, ok := nspReservedEvents[eventName]
+	if ok && isReserved {
+		return true
+	}
+	return false
+}
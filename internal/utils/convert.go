@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Converts a value that was decoded without knowing its type
+// (e.g. a struct becomes a map[string]any) to the given type.
+// If the value is not assignable to the type, it is converted through JSON.
+func ConvertValue(typ reflect.Type, v any) (reflect.Value, error) {
+	if v == nil {
+		return reflect.New(typ).Elem(), nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if typ.Kind() != reflect.Ptr && rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+
+	switch {
+	case rv.Type().AssignableTo(typ):
+		return rv, nil
+	case isNumberKind(rv.Kind()) && isNumberKind(typ.Kind()):
+		return rv.Convert(typ), nil
+	default:
+		data, err := json.Marshal(rv.Interface())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr := reflect.New(typ)
+		err = json.Unmarshal(data, ptr.Interface())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return ptr.Elem(), nil
+	}
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	return (k >= reflect.Int && k <= reflect.Uint64) || k == reflect.Float32 || k == reflect.Float64
+}
@@ -3,18 +3,39 @@ package sio
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"reflect"
 	"time"
+
+	"github.com/hhuuson97/socket.io-go/adapter"
+	eio "github.com/hhuuson97/socket.io-go/engine.io"
 )
 
 type NspMiddlewareFunc func(socket ServerSocket, handshake *Handshake) any
 
-type Handshake struct {
-	// Date of creation
-	Time time.Time
+type Handshake = adapter.Handshake
+
+func newHandshake(req *eio.HandshakeRequest, auth json.RawMessage) *Handshake {
+	handshake := &Handshake{
+		Time: time.Now(),
+		Auth: auth,
+	}
+	if req == nil {
+		return handshake
+	}
 
-	// Authentication data
-	Auth json.RawMessage
+	address, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		address = req.RemoteAddr
+	}
+	handshake.Headers = req.Header
+	handshake.Query = req.Query
+	handshake.Address = address
+	handshake.XDomain = req.Header.Get("Origin") != ""
+	handshake.Secure = req.TLS != nil
+	handshake.URL = req.URL
+	handshake.TLS = req.TLS
+	return handshake
 }
 
 func (n *Namespace) Use(f NspMiddlewareFunc) {
@@ -24,6 +45,13 @@ func (n *Namespace) Use(f NspMiddlewareFunc) {
 }
 
 func (n *Namespace) runMiddlewares(socket *serverSocket, handshake *Handshake) error {
+	if n.parent != nil {
+		err := n.parent.runMiddlewares(socket, handshake)
+		if err != nil {
+			return err
+		}
+	}
+
 	n.middlewareFuncsMu.RLock()
 	defer n.middlewareFuncsMu.RUnlock()
 
@@ -70,6 +98,12 @@ func (s *serverSocket) checkMiddlewareFunc(rv reflect.Value) error {
 	return nil
 }
 
+func (s *serverSocket) hasMiddlewares() bool {
+	s.middlewareFuncsMu.RLock()
+	defer s.middlewareFuncsMu.RUnlock()
+	return len(s.middlewareFuncs) > 0
+}
+
 func (s *serverSocket) callMiddlewares(values []reflect.Value) error {
 	s.middlewareFuncsMu.RLock()
 	defer s.middlewareFuncsMu.RUnlock()
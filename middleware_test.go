@@ -2,9 +2,11 @@ package sio
 
 import (
 	"fmt"
+	"net/http"
 	"sync/atomic"
 	"testing"
 
+	"github.com/hhuuson97/socket.io-go/engine.io/transport"
 	"github.com/hhuuson97/socket.io-go/internal/sync"
 	"github.com/hhuuson97/socket.io-go/internal/utils"
 	"github.com/mitchellh/mapstructure"
@@ -239,4 +241,39 @@ func TestMiddleware(t *testing.T) {
 		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
 		close()
 	})
+
+	t.Run("should pass the details of the handshake request", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("X-Custom", "value")
+		managerConfig := &ManagerConfig{}
+		managerConfig.EIO.RequestHeader = transport.NewRequestHeader(header)
+		io, _, manager, close := newTestServerAndClient(
+			t,
+			nil,
+			managerConfig,
+		)
+		tw := utils.NewTestWaiter(2)
+
+		check := func(handshake *Handshake) {
+			assert.Equal(t, "value", handshake.Headers.Get("X-Custom"))
+			assert.Equal(t, "4", handshake.Query.Get("EIO"))
+			assert.Equal(t, "127.0.0.1", handshake.Address)
+			assert.False(t, handshake.Secure)
+			assert.Nil(t, handshake.TLS)
+			assert.Contains(t, handshake.URL, "EIO=4")
+		}
+		io.Use(func(socket ServerSocket, handshake *Handshake) any {
+			check(handshake)
+			tw.Done()
+			return nil
+		})
+		io.OnConnection(func(socket ServerSocket) {
+			check(socket.Handshake())
+			tw.Done()
+		})
+		manager.Socket("/", nil).Connect()
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		close()
+	})
 }
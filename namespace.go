@@ -2,11 +2,13 @@ package sio
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 	"time"
 
 	"github.com/hhuuson97/socket.io-go/internal/sync"
+	"github.com/hhuuson97/socket.io-go/internal/utils"
 
 	"github.com/hhuuson97/socket.io-go/adapter"
 	"github.com/hhuuson97/socket.io-go/parser"
@@ -15,6 +17,8 @@ import (
 type Namespace struct {
 	name   string
 	server *Server
+	// Non-nil if the namespace was created by a ParentNamespace.
+	parent *ParentNamespace
 
 	debug Debugger
 
@@ -29,8 +33,14 @@ type Namespace struct {
 	ackID uint64
 	ackMu sync.Mutex
 
+	closeOnce sync.Once
+
 	eventHandlers      *eventHandlerStore
 	connectionHandlers *handlerStore[*NamespaceConnectionFunc]
+	createRoomHandlers *handlerStore[*NamespaceCreateRoomFunc]
+	deleteRoomHandlers *handlerStore[*NamespaceDeleteRoomFunc]
+	joinRoomHandlers   *handlerStore[*NamespaceJoinRoomFunc]
+	leaveRoomHandlers  *handlerStore[*NamespaceLeaveRoomFunc]
 }
 
 func newNamespace(
@@ -38,18 +48,24 @@ func newNamespace(
 	server *Server,
 	adapterCreator adapter.Creator,
 	parserCreator parser.Creator,
+	parent *ParentNamespace,
 ) *Namespace {
 	socketStore := newNspSocketStore()
 	nsp := &Namespace{
 		name:               name,
 		server:             server,
+		parent:             parent,
 		debug:              server.debug.WithContext("[sio/server] Namespace with name: " + name),
 		sockets:            socketStore,
 		parser:             parserCreator(),
 		eventHandlers:      newEventHandlerStore(),
 		connectionHandlers: newHandlerStore[*NamespaceConnectionFunc](),
+		createRoomHandlers: newHandlerStore[*NamespaceCreateRoomFunc](),
+		deleteRoomHandlers: newHandlerStore[*NamespaceDeleteRoomFunc](),
+		joinRoomHandlers:   newHandlerStore[*NamespaceJoinRoomFunc](),
+		leaveRoomHandlers:  newHandlerStore[*NamespaceLeaveRoomFunc](),
 	}
-	nsp.adapter = adapterCreator(newAdapterSocketStore(socketStore), parserCreator)
+	nsp.adapter = adapterCreator(newAdapterSocketStore(socketStore, nsp), parserCreator)
 	return nsp
 }
 
@@ -57,6 +73,28 @@ func (n *Namespace) Name() string { return n.name }
 
 func (n *Namespace) Adapter() adapter.Adapter { return n.adapter }
 
+// Disconnects the sockets of the namespace, closes its adapter and removes
+// the namespace from the server. Clients can no longer connect to it
+// unless it is created again (with Server.Of, Server.OfDynamic or AcceptAnyNamespace).
+//
+// Calling Close more than once has no effect.
+func (n *Namespace) Close() {
+	n.closeOnce.Do(func() {
+		n.debug.Log("Closing namespace", n.name)
+		n.server.namespaces.removeNamespace(n)
+		if n.parent != nil {
+			n.parent.removeChild(n)
+		}
+		for _, socket := range n.Sockets() {
+			socket.Disconnect(false)
+		}
+		n.adapter.Close()
+	})
+}
+
+// Returns the ParentNamespace that created this namespace, or nil if it was created with Server.Of.
+func (n *Namespace) Parent() *ParentNamespace { return n.parent }
+
 // Emits an event to all connected clients in the given namespace.
 func (n *Namespace) Emit(eventName string, v ...any) {
 	n.newBroadcastOperator().Emit(eventName, v...)
@@ -82,30 +120,154 @@ func (n *Namespace) ServerSideEmit(eventName string, _v ...any) {
 	n.adapter.ServerSideEmit(header, v)
 }
 
-func (n *Namespace) OnServerSideEmit(eventName string, _v ...any) {
-	values := make([]reflect.Value, len(_v))
-	for i, v := range _v {
-		values[i] = reflect.ValueOf(v)
+// Sends a message to the other Socket.IO servers of the cluster and
+// waits for their acknowledgements.
+//
+// The last argument must be an acknowledgement function with the signature:
+// func(err error, responses []T). It is called once, with one response per node,
+// either when every node has responded or when the timeout is reached.
+// In the latter case err is ErrAckTimeout and responses contains the responses received so far.
+//
+// A response that cannot be converted to T is left out of responses,
+// and the conversion error is joined to err (see errors.Join).
+func (n *Namespace) ServerSideEmitWithAck(timeout time.Duration, eventName string, _v ...any) {
+	if len(_v) == 0 {
+		panic(fmt.Errorf("sio: Namespace.ServerSideEmitWithAck: an acknowledgement function is expected"))
+	}
+	ack := _v[len(_v)-1]
+	_v = _v[:len(_v)-1]
+
+	err := checkServerSideEmitAckFunc(ack)
+	if err != nil {
+		panic(err)
+	}
+
+	header := &parser.PacketHeader{
+		Type:      parser.PacketTypeEvent,
+		Namespace: n.Name(),
 	}
+
+	if IsEventReservedForNsp(eventName) {
+		panic(fmt.Errorf("sio: Namespace.ServerSideEmitWithAck: attempted to emit to a reserved event"))
+	}
+
+	v := make([]any, 0, len(_v)+1)
+	v = append(v, eventName)
+	v = append(v, _v...)
+
+	ackValue := reflect.ValueOf(ack)
+	responseType := ackValue.Type().In(1).Elem()
+	n.adapter.ServerSideEmitWithAck(header, v, timeout, func(err error, responses []any) {
+		if errors.Is(err, adapter.ErrRequestTimeout) {
+			err = ErrAckTimeout
+		}
+		rv := reflect.MakeSlice(ackValue.Type().In(1), 0, len(responses))
+		for _, response := range responses {
+			value, convErr := utils.ConvertValue(responseType, response)
+			if convErr != nil {
+				err = errors.Join(err, fmt.Errorf("sio: Namespace.ServerSideEmitWithAck: %w", convErr))
+				continue
+			}
+			rv = reflect.Append(rv, value)
+		}
+
+		errValue := reflect.New(reflectError).Elem()
+		if err != nil {
+			errValue.Set(reflect.ValueOf(err))
+		}
+
+		defer func() {
+			if r := recover(); r != nil {
+				n.debug.Log("Namespace.ServerSideEmitWithAck: acknowledgement function panicked:", r)
+			}
+		}()
+		ackValue.Call([]reflect.Value{errValue, rv})
+	})
+}
+
+func checkServerSideEmitAckFunc(f any) error {
+	rt := reflect.TypeOf(f)
+	if f == nil || rt.Kind() != reflect.Func {
+		return fmt.Errorf("sio: function expected")
+	}
+	if rt.NumOut() != 0 {
+		return fmt.Errorf("sio: ack handler must not have a return value")
+	}
+	if rt.NumIn() != 2 || rt.In(0) != reflectError || rt.In(1).Kind() != reflect.Slice {
+		return fmt.Errorf("sio: ack handler of a server side emit must have the signature: func(err error, responses []T)")
+	}
+	return nil
+}
+
+// Dispatches a server side event (which is received from another node of the cluster)
+// to the event handlers of this namespace.
+func (n *Namespace) OnServerSideEmit(eventName string, _v ...any) {
+	n.onServerSideEmit(eventName, _v, nil)
+}
+
+// If ack is non-nil, the node that sent the event waits for an acknowledgement.
+// The acknowledgement function of the handler (if it has one) sends the response,
+// only the first argument is sent and only the first call is taken into account.
+func (n *Namespace) onServerSideEmit(eventName string, _v []any, ack func(response any)) {
 	handlers := n.eventHandlers.getAll(eventName)
 
+	var once sync.Once
+	sendAck := func(args []reflect.Value) []reflect.Value {
+		if ack == nil {
+			return nil
+		}
+		var response any
+		if len(args) > 0 {
+			response = args[0].Interface()
+		}
+		once.Do(func() { ack(response) })
+		return nil
+	}
+
 	go func() {
 		for _, handler := range handlers {
-			if len(values) == len(handler.inputArgs) {
-				for i, v := range values {
-					if handler.inputArgs[i].Kind() != reflect.Ptr && v.Kind() == reflect.Ptr {
-						values[i] = v.Elem()
-					}
-				}
-			} else {
-				n.debug.Log("Namespace.OnServerSideEmit: handler signature mismatch")
-				return
+			types := handler.inputArgs
+			hasAck, _ := handler.ack()
+			if hasAck {
+				types = types[:len(types)-1]
+			}
+
+			values, err := convertServerSideEmitValues(types, _v)
+			if err != nil {
+				n.debug.Log("Namespace.OnServerSideEmit:", err)
+				continue
+			}
+			if hasAck {
+				values = append(values, reflect.MakeFunc(handler.inputArgs[len(handler.inputArgs)-1], sendAck))
+			}
+
+			_, err = handler.call(values...)
+			if err != nil {
+				n.debug.Log("Namespace.OnServerSideEmit:", err)
 			}
-			handler.call(values...)
 		}
 	}()
 }
 
+// Values received from the other nodes are decoded without knowing
+// the argument types of the handlers (e.g. a struct becomes a map[string]any).
+// See utils.ConvertValue.
+func convertServerSideEmitValues(types []reflect.Type, v []any) ([]reflect.Value, error) {
+	if len(types) != len(v) {
+		return nil, fmt.Errorf("handler signature mismatch")
+	}
+
+	values := make([]reflect.Value, len(v))
+	for i, typ := range types {
+		value, err := utils.ConvertValue(typ, v[i])
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
 // Sets a modifier for a subsequent event emission that the event
 // will only be broadcast to clients that have joined the given room.
 //
@@ -137,6 +299,13 @@ func (n *Namespace) Local() *BroadcastOperator {
 	return n.newBroadcastOperator().Local()
 }
 
+// Sets a modifier for a subsequent event emission that the acknowledgement function
+// will be called with an error when the given duration has elapsed without
+// every client having acknowledged the event.
+func (n *Namespace) Timeout(timeout time.Duration) *BroadcastOperator {
+	return n.newBroadcastOperator().Timeout(timeout)
+}
+
 // Gets the sockets of the namespace.
 // Beware that this is local to the current node. For sockets across all nodes, use FetchSockets
 func (n *Namespace) Sockets() []ServerSocket {
@@ -178,10 +347,7 @@ func (n *Namespace) add(c *serverConn, auth json.RawMessage) (*serverSocket, err
 	n.debug.Log("Adding a new socket to namespace", n.name)
 
 	var (
-		handshake = &Handshake{
-			Time: time.Now(),
-			Auth: auth,
-		}
+		handshake          = newHandshake(c.eio.HandshakeRequest(), auth)
 		authRecoveryFields authRecoveryFields
 		socket             *serverSocket
 	)
@@ -191,7 +357,8 @@ func (n *Namespace) add(c *serverConn, auth json.RawMessage) (*serverSocket, err
 		return nil, err
 	}
 
-	if n.server.connectionStateRecovery.Enabled {
+	// Connection state recovery is not a part of the protocol of Socket.IO v2 clients.
+	if n.server.connectionStateRecovery.Enabled && !c.eio3() {
 		session, ok := n.adapter.RestoreSession(
 			adapter.PrivateSessionID(authRecoveryFields.SessionID),
 			authRecoveryFields.Offset,
@@ -215,6 +382,8 @@ func (n *Namespace) add(c *serverConn, auth json.RawMessage) (*serverSocket, err
 		}
 	}
 
+	socket.handshake = handshake
+
 	if n.server.connectionStateRecovery.Enabled && !n.server.connectionStateRecovery.UseMiddlewares && socket.Recovered() {
 		return socket, n.doConnect(socket)
 	}
@@ -238,6 +407,9 @@ func (n *Namespace) doConnect(socket *serverSocket) error {
 
 	go func() {
 		n.server.anyConnectionHandlers.forEach(func(handler *ServerAnyConnectionFunc) { (*handler)(n.name, socket) }, false)
+		if n.parent != nil {
+			n.parent.connectionHandlers.forEach(func(handler *NamespaceConnectionFunc) { (*handler)(socket) }, false)
+		}
 		n.connectionHandlers.forEach(func(handler *NamespaceConnectionFunc) { (*handler)(socket) }, false)
 	}()
 	return nil
@@ -249,6 +421,10 @@ func (n *Namespace) remove(socket *serverSocket) {
 	} else {
 		n.debug.Log("Ignoring remove for", socket.ID())
 	}
+
+	if n.parent != nil && n.server.cleanupEmptyChildNamespaces {
+		n.parent.removeChildIfEmpty(n)
+	}
 }
 
 func (n *Namespace) nextAckID() uint64 {
@@ -38,6 +38,10 @@ func (n *Namespace) OffEvent(eventName string, handler ...any) {
 func (n *Namespace) OffAll() {
 	n.eventHandlers.offAll()
 	n.connectionHandlers.offAll()
+	n.createRoomHandlers.offAll()
+	n.deleteRoomHandlers.offAll()
+	n.joinRoomHandlers.offAll()
+	n.leaveRoomHandlers.offAll()
 }
 
 type (
@@ -59,3 +63,81 @@ func (n *Namespace) OffConnection(_f ...NamespaceConnectionFunc) {
 	}
 	n.connectionHandlers.off(f...)
 }
+
+type (
+	// Called when a room is created, that is, when the first socket of this server joins it.
+	NamespaceCreateRoomFunc func(room Room)
+
+	// Called when a room is deleted, that is, when the last socket of this server leaves it.
+	NamespaceDeleteRoomFunc func(room Room)
+
+	// Called when a socket of this server joins a room.
+	NamespaceJoinRoomFunc func(room Room, sid SocketID)
+
+	// Called when a socket of this server leaves a room.
+	NamespaceLeaveRoomFunc func(room Room, sid SocketID)
+)
+
+func (n *Namespace) OnCreateRoom(f NamespaceCreateRoomFunc) {
+	n.createRoomHandlers.on(&f)
+}
+
+func (n *Namespace) OnceCreateRoom(f NamespaceCreateRoomFunc) {
+	n.createRoomHandlers.once(&f)
+}
+
+func (n *Namespace) OffCreateRoom(_f ...NamespaceCreateRoomFunc) {
+	f := make([]*NamespaceCreateRoomFunc, len(_f))
+	for i := range f {
+		f[i] = &_f[i]
+	}
+	n.createRoomHandlers.off(f...)
+}
+
+func (n *Namespace) OnDeleteRoom(f NamespaceDeleteRoomFunc) {
+	n.deleteRoomHandlers.on(&f)
+}
+
+func (n *Namespace) OnceDeleteRoom(f NamespaceDeleteRoomFunc) {
+	n.deleteRoomHandlers.once(&f)
+}
+
+func (n *Namespace) OffDeleteRoom(_f ...NamespaceDeleteRoomFunc) {
+	f := make([]*NamespaceDeleteRoomFunc, len(_f))
+	for i := range f {
+		f[i] = &_f[i]
+	}
+	n.deleteRoomHandlers.off(f...)
+}
+
+func (n *Namespace) OnJoinRoom(f NamespaceJoinRoomFunc) {
+	n.joinRoomHandlers.on(&f)
+}
+
+func (n *Namespace) OnceJoinRoom(f NamespaceJoinRoomFunc) {
+	n.joinRoomHandlers.once(&f)
+}
+
+func (n *Namespace) OffJoinRoom(_f ...NamespaceJoinRoomFunc) {
+	f := make([]*NamespaceJoinRoomFunc, len(_f))
+	for i := range f {
+		f[i] = &_f[i]
+	}
+	n.joinRoomHandlers.off(f...)
+}
+
+func (n *Namespace) OnLeaveRoom(f NamespaceLeaveRoomFunc) {
+	n.leaveRoomHandlers.on(&f)
+}
+
+func (n *Namespace) OnceLeaveRoom(f NamespaceLeaveRoomFunc) {
+	n.leaveRoomHandlers.once(&f)
+}
+
+func (n *Namespace) OffLeaveRoom(_f ...NamespaceLeaveRoomFunc) {
+	f := make([]*NamespaceLeaveRoomFunc, len(_f))
+	for i := range f {
+		f[i] = &_f[i]
+	}
+	n.leaveRoomHandlers.off(f...)
+}
@@ -0,0 +1,351 @@
+package sio
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+
+	"github.com/hhuuson97/socket.io-go/adapter"
+	"github.com/hhuuson97/socket.io-go/internal/sync"
+)
+
+// Decides whether a client can connect to a namespace that was not created with Server.Of.
+// auth is the authentication data sent by the client.
+type NamespaceMatcherFunc func(name string, auth json.RawMessage) bool
+
+// A group of namespaces created on demand, when a client connects to a namespace
+// whose name is accepted by the matcher of the group (see Server.OfDynamic).
+//
+// The middlewares and the connection handlers of the group apply to
+// every child namespace, and the broadcasts are sent to every child namespace.
+type ParentNamespace struct {
+	server  *Server
+	matcher NamespaceMatcherFunc
+
+	children []*Namespace
+	// Number of the connections to each child that are in progress.
+	// A child without socket is not removed while a connection to it is in progress.
+	connecting map[*Namespace]int
+	childrenMu sync.Mutex
+
+	middlewareFuncs   []NspMiddlewareFunc
+	middlewareFuncsMu sync.RWMutex
+
+	connectionHandlers *handlerStore[*NamespaceConnectionFunc]
+}
+
+func newParentNamespace(server *Server, matcher NamespaceMatcherFunc) *ParentNamespace {
+	return &ParentNamespace{
+		server:             server,
+		matcher:            matcher,
+		connecting:         make(map[*Namespace]int),
+		connectionHandlers: newHandlerStore[*NamespaceConnectionFunc](),
+	}
+}
+
+func toNamespaceMatcherFunc(matcher any) (NamespaceMatcherFunc, error) {
+	switch m := matcher.(type) {
+	case *regexp.Regexp:
+		return func(name string, auth json.RawMessage) bool { return m.MatchString(name) }, nil
+	case NamespaceMatcherFunc:
+		return m, nil
+	case func(name string, auth json.RawMessage) bool:
+		return m, nil
+	default:
+		return nil, fmt.Errorf("sio: OfDynamic: matcher must be a *regexp.Regexp or a func(name string, auth json.RawMessage) bool, got: %T", matcher)
+	}
+}
+
+// Returns the child namespaces that have been created so far,
+// except the ones that have been closed or removed (see ServerConfig.CleanupEmptyChildNamespaces).
+func (p *ParentNamespace) Children() []*Namespace {
+	p.childrenMu.Lock()
+	defer p.childrenMu.Unlock()
+	children := make([]*Namespace, len(p.children))
+	copy(children, p.children)
+	return children
+}
+
+// Returns the namespace with the given name, which is created as a child if it doesn't exist.
+// A connection to the namespace is begun (see beginConnect).
+func (p *ParentNamespace) getOrCreateChild(name string) (nsp *Namespace, created bool) {
+	for {
+		p.childrenMu.Lock()
+		nsp, created = p.server.namespaces.getOrCreate(name, p.server, p.server.adapterCreator, p.server.parserCreator, p)
+		if nsp.parent == p {
+			if created {
+				p.children = append(p.children, nsp)
+			}
+			p.connecting[nsp]++
+			p.childrenMu.Unlock()
+			return nsp, created
+		}
+		p.childrenMu.Unlock()
+
+		// The namespace was created with Server.Of or by another parent in the meantime.
+		if nsp.parent == nil || nsp.parent.beginConnect(nsp) {
+			return nsp, false
+		}
+	}
+}
+
+// Begins a connection to the child namespace. It returns false if the child
+// has been removed in the meantime, in which case it must be created again.
+//
+// endConnect must be called once the connection is either established or rejected.
+func (p *ParentNamespace) beginConnect(nsp *Namespace) bool {
+	p.childrenMu.Lock()
+	defer p.childrenMu.Unlock()
+	if !slices.Contains(p.children, nsp) {
+		return false
+	}
+	p.connecting[nsp]++
+	return true
+}
+
+// Ends a connection to the child namespace. The child is removed if it has no socket,
+// which is the case when the connection was rejected.
+func (p *ParentNamespace) endConnect(nsp *Namespace) {
+	p.childrenMu.Lock()
+	p.connecting[nsp]--
+	if p.connecting[nsp] <= 0 {
+		delete(p.connecting, nsp)
+	}
+	p.childrenMu.Unlock()
+	p.removeChildIfEmpty(nsp)
+}
+
+// Removes the child namespace if it has no socket and no connection to it is in progress.
+func (p *ParentNamespace) removeChildIfEmpty(nsp *Namespace) {
+	p.childrenMu.Lock()
+	remove := p.connecting[nsp] == 0 && len(nsp.Sockets()) == 0 && slices.Contains(p.children, nsp)
+	if remove {
+		// The child is removed from the server while childrenMu is held, so that
+		// getOrCreateChild cannot return it once it is no longer a child.
+		p.server.namespaces.removeNamespace(nsp)
+		p.children = slices.DeleteFunc(p.children, func(child *Namespace) bool { return child == nsp })
+	}
+	p.childrenMu.Unlock()
+
+	if remove {
+		nsp.debug.Log("Removing empty child namespace", nsp.Name())
+		nsp.Close()
+	}
+}
+
+func (p *ParentNamespace) removeChild(nsp *Namespace) {
+	p.childrenMu.Lock()
+	defer p.childrenMu.Unlock()
+	for i, child := range p.children {
+		if child == nsp {
+			p.children = append(p.children[:i], p.children[i+1:]...)
+			return
+		}
+	}
+}
+
+// Registers a middleware that is run for the sockets of every child namespace,
+// before the middlewares of the child namespace.
+func (p *ParentNamespace) Use(f NspMiddlewareFunc) {
+	p.middlewareFuncsMu.Lock()
+	defer p.middlewareFuncsMu.Unlock()
+	p.middlewareFuncs = append(p.middlewareFuncs, f)
+}
+
+func (p *ParentNamespace) runMiddlewares(socket *serverSocket, handshake *Handshake) error {
+	p.middlewareFuncsMu.RLock()
+	defer p.middlewareFuncsMu.RUnlock()
+
+	for _, f := range p.middlewareFuncs {
+		err := f(socket, handshake)
+		if err != nil {
+			return &middlewareError{v: err}
+		}
+	}
+	return nil
+}
+
+// Registers a handler that is called when a socket connects to any of the child namespaces.
+// Use socket.Namespace() to find out which one.
+func (p *ParentNamespace) OnConnection(f NamespaceConnectionFunc) {
+	p.connectionHandlers.on(&f)
+}
+
+func (p *ParentNamespace) OnceConnection(f NamespaceConnectionFunc) {
+	p.connectionHandlers.once(&f)
+}
+
+func (p *ParentNamespace) OffConnection(_f ...NamespaceConnectionFunc) {
+	f := make([]*NamespaceConnectionFunc, len(_f))
+	for i := range f {
+		f[i] = &_f[i]
+	}
+	p.connectionHandlers.off(f...)
+}
+
+// Emits an event to all connected clients of every child namespace.
+func (p *ParentNamespace) Emit(eventName string, v ...any) {
+	p.newBroadcastOperator().Emit(eventName, v...)
+}
+
+// Sets a modifier for a subsequent event emission that the event
+// will only be broadcast to clients that have joined the given room.
+//
+// To emit to multiple rooms, you can call `To` several times.
+func (p *ParentNamespace) To(room ...Room) *ParentBroadcastOperator {
+	return p.newBroadcastOperator().To(room...)
+}
+
+// Alias of To(...)
+func (p *ParentNamespace) In(room ...Room) *ParentBroadcastOperator {
+	return p.newBroadcastOperator().In(room...)
+}
+
+// Sets a modifier for a subsequent event emission that the event
+// will only be broadcast to clients that have not joined the given rooms.
+func (p *ParentNamespace) Except(room ...Room) *ParentBroadcastOperator {
+	return p.newBroadcastOperator().Except(room...)
+}
+
+// Compression flag is unused at the moment, thus setting this will have no effect on compression.
+func (p *ParentNamespace) Compress(compress bool) *ParentBroadcastOperator {
+	return p.newBroadcastOperator().Compress(compress)
+}
+
+// Sets a modifier for a subsequent event emission that the event data will only be broadcast to the current node (when scaling to multiple nodes).
+//
+// See: https://socket.io/docs/v4/using-multiple-nodes
+func (p *ParentNamespace) Local() *ParentBroadcastOperator {
+	return p.newBroadcastOperator().Local()
+}
+
+// Gets the sockets of every child namespace.
+// Beware that this is local to the current node. For sockets across all nodes, use FetchSockets
+func (p *ParentNamespace) Sockets() []ServerSocket {
+	var sockets []ServerSocket
+	for _, child := range p.Children() {
+		sockets = append(sockets, child.Sockets()...)
+	}
+	return sockets
+}
+
+// Returns the matching socket instances of every child namespace. This method works across a cluster of several Socket.IO servers.
+func (p *ParentNamespace) FetchSockets() []adapter.Socket {
+	return p.newBroadcastOperator().FetchSockets()
+}
+
+// Makes the matching socket instances join the specified rooms.
+func (p *ParentNamespace) SocketsJoin(room ...Room) {
+	p.newBroadcastOperator().SocketsJoin(room...)
+}
+
+// Makes the matching socket instances leave the specified rooms.
+func (p *ParentNamespace) SocketsLeave(room ...Room) {
+	p.newBroadcastOperator().SocketsLeave(room...)
+}
+
+// Makes the matching socket instances disconnect from their namespace.
+//
+// If value of close is true, closes the underlying connection. Otherwise, it just disconnects the namespace.
+func (p *ParentNamespace) DisconnectSockets(close bool) {
+	p.newBroadcastOperator().DisconnectSockets(close)
+}
+
+func (p *ParentNamespace) newBroadcastOperator() *ParentBroadcastOperator {
+	return &ParentBroadcastOperator{parent: p}
+}
+
+// The BroadcastOperator of a ParentNamespace. The modifiers are recorded,
+// and they are applied to the BroadcastOperator of each child namespace
+// at the time of the operation.
+//
+// Acknowledgements are not supported.
+type ParentBroadcastOperator struct {
+	parent    *ParentNamespace
+	modifiers []func(b *BroadcastOperator) *BroadcastOperator
+}
+
+func (b *ParentBroadcastOperator) with(modifier func(b *BroadcastOperator) *BroadcastOperator) *ParentBroadcastOperator {
+	modifiers := make([]func(b *BroadcastOperator) *BroadcastOperator, 0, len(b.modifiers)+1)
+	modifiers = append(modifiers, b.modifiers...)
+	modifiers = append(modifiers, modifier)
+	return &ParentBroadcastOperator{
+		parent:    b.parent,
+		modifiers: modifiers,
+	}
+}
+
+func (b *ParentBroadcastOperator) forEachChild(f func(b *BroadcastOperator)) {
+	for _, child := range b.parent.Children() {
+		op := child.newBroadcastOperator()
+		for _, modifier := range b.modifiers {
+			op = modifier(op)
+		}
+		f(op)
+	}
+}
+
+// Emits an event to all choosen clients of every child namespace.
+func (b *ParentBroadcastOperator) Emit(eventName string, v ...any) {
+	if len(v) != 0 && v[len(v)-1] != nil && reflect.TypeOf(v[len(v)-1]).Kind() == reflect.Func {
+		panic(fmt.Errorf("sio: ParentBroadcastOperator.Emit: acknowledgements are not supported"))
+	}
+	b.forEachChild(func(op *BroadcastOperator) { op.Emit(eventName, v...) })
+}
+
+// Sets a modifier for a subsequent event emission that the event
+// will only be broadcast to clients that have joined the given room.
+//
+// To emit to multiple rooms, you can call `To` several times.
+func (b *ParentBroadcastOperator) To(room ...Room) *ParentBroadcastOperator {
+	return b.with(func(op *BroadcastOperator) *BroadcastOperator { return op.To(room...) })
+}
+
+// Alias of To(...)
+func (b *ParentBroadcastOperator) In(room ...Room) *ParentBroadcastOperator {
+	return b.To(room...)
+}
+
+// Sets a modifier for a subsequent event emission that the event
+// will only be broadcast to clients that have not joined the given rooms.
+func (b *ParentBroadcastOperator) Except(room ...Room) *ParentBroadcastOperator {
+	return b.with(func(op *BroadcastOperator) *BroadcastOperator { return op.Except(room...) })
+}
+
+// Compression flag is unused at the moment, thus setting this will have no effect on compression.
+func (b *ParentBroadcastOperator) Compress(compress bool) *ParentBroadcastOperator {
+	return b.with(func(op *BroadcastOperator) *BroadcastOperator { return op.Compress(compress) })
+}
+
+// Sets a modifier for a subsequent event emission that the event data will only be broadcast to the current node (when scaling to multiple nodes).
+//
+// See: https://socket.io/docs/v4/using-multiple-nodes
+func (b *ParentBroadcastOperator) Local() *ParentBroadcastOperator {
+	return b.with(func(op *BroadcastOperator) *BroadcastOperator { return op.Local() })
+}
+
+// Returns the matching socket instances of every child namespace. This method works across a cluster of several Socket.IO servers.
+func (b *ParentBroadcastOperator) FetchSockets() []adapter.Socket {
+	var sockets []adapter.Socket
+	b.forEachChild(func(op *BroadcastOperator) { sockets = append(sockets, op.FetchSockets()...) })
+	return sockets
+}
+
+// Makes the matching socket instances join the specified rooms.
+func (b *ParentBroadcastOperator) SocketsJoin(room ...Room) {
+	b.forEachChild(func(op *BroadcastOperator) { op.SocketsJoin(room...) })
+}
+
+// Makes the matching socket instances leave the specified rooms.
+func (b *ParentBroadcastOperator) SocketsLeave(room ...Room) {
+	b.forEachChild(func(op *BroadcastOperator) { op.SocketsLeave(room...) })
+}
+
+// Makes the matching socket instances disconnect from their namespace.
+//
+// If value of close is true, closes the underlying connection. Otherwise, it just disconnects the namespace.
+func (b *ParentBroadcastOperator) DisconnectSockets(close bool) {
+	b.forEachChild(func(op *BroadcastOperator) { op.DisconnectSockets(close) })
+}
@@ -1,13 +1,21 @@
 package sio
 
 import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/hhuuson97/socket.io-go/adapter"
 	"github.com/hhuuson97/socket.io-go/internal/sync"
 	"github.com/hhuuson97/socket.io-go/internal/utils"
+	"github.com/hhuuson97/socket.io-go/parser"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -861,6 +869,43 @@ func TestNamespace(t *testing.T) {
 		close()
 	})
 
+	t.Run("should emit the room events", func(t *testing.T) {
+		io, _, manager, close := newTestServerAndClient(t, nil, nil)
+		socket := manager.Socket("/", nil)
+		tw := utils.NewTestWaiter(4)
+
+		nsp := io.Of("/")
+		nsp.OnCreateRoom(func(room Room) {
+			if room == "a" {
+				tw.Done()
+			}
+		})
+		nsp.OnJoinRoom(func(room Room, sid SocketID) {
+			if room == "a" {
+				tw.Done()
+			}
+		})
+		nsp.OnLeaveRoom(func(room Room, sid SocketID) {
+			if room == "a" {
+				tw.Done()
+			}
+		})
+		nsp.OnDeleteRoom(func(room Room) {
+			if room == "a" {
+				tw.Done()
+			}
+		})
+
+		io.OnConnection(func(socket ServerSocket) {
+			socket.Join("a")
+			socket.Leave("a")
+		})
+		socket.Connect()
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		close()
+	})
+
 	t.Run("allows to join several rooms at once", func(t *testing.T) {
 		io, _, manager, close := newTestServerAndClient(t, nil, nil)
 		socket := manager.Socket("/", nil)
@@ -956,4 +1001,346 @@ func TestNamespace(t *testing.T) {
 		time.Sleep(200 * time.Millisecond)
 		close()
 	})
+
+	t.Run("should broadcast with acknowledgements", func(t *testing.T) {
+		io, ts, manager, close := newTestServerAndClient(t, nil, nil)
+		manager2 := newTestManager(ts, nil)
+		socket1 := manager.Socket("/", nil)
+		socket2 := manager2.Socket("/", nil)
+		tw := utils.NewTestWaiter(1)
+
+		socket1.OnEvent("hello", func(ack func(reply string)) {
+			ack("from 1")
+		})
+		socket2.OnEvent("hello", func(ack func(reply string)) {
+			ack("from 2")
+		})
+
+		var count atomic.Int32
+		io.OnConnection(func(socket ServerSocket) {
+			if count.Add(1) != 2 {
+				return
+			}
+			io.Timeout(time.Second).Emit("hello", func(err error, responses []string) {
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, []string{"from 1", "from 2"}, responses)
+				tw.Done()
+			})
+		})
+		socket1.Connect()
+		socket2.Connect()
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		close()
+	})
+
+	t.Run("should time out when a client does not acknowledge a broadcast", func(t *testing.T) {
+		io, ts, manager, close := newTestServerAndClient(t, nil, nil)
+		manager2 := newTestManager(ts, nil)
+		socket1 := manager.Socket("/", nil)
+		socket2 := manager2.Socket("/", nil)
+		tw := utils.NewTestWaiter(1)
+
+		socket1.OnEvent("hello", func(ack func(reply string)) {
+			ack("from 1")
+		})
+		socket2.OnEvent("hello", func(ack func(reply string)) {})
+
+		var count atomic.Int32
+		io.OnConnection(func(socket ServerSocket) {
+			if count.Add(1) != 2 {
+				return
+			}
+			io.Timeout(200*time.Millisecond).Emit("hello", func(err error, responses []string) {
+				assert.ErrorIs(t, err, ErrAckTimeout)
+				assert.Equal(t, []string{"from 1"}, responses)
+				tw.Done()
+			})
+		})
+		socket1.Connect()
+		socket2.Connect()
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		close()
+	})
+
+	t.Run("should receive the acknowledgements of a server side emit", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		newServer := func() *Server {
+			client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+			t.Cleanup(func() { client.Close() })
+			return NewServer(&ServerConfig{
+				AdapterCreator: adapter.NewRedisAdapterCreator(client, nil),
+			})
+		}
+		io1 := newServer()
+		io2 := newServer()
+		tw := utils.NewTestWaiter(2)
+
+		type message struct {
+			Text string `json:"text"`
+		}
+		io2.Of("/").OnEvent("hello", func(msg message, n int, ack func(reply message)) {
+			assert.Equal(t, "world", msg.Text)
+			assert.Equal(t, 1, n)
+			tw.Done()
+			ack(message{Text: "hi"})
+		})
+		io1.ServerSideEmitWithAck(2*time.Second, "hello", message{Text: "world"}, 1, func(err error, responses []message) {
+			assert.NoError(t, err)
+			assert.Equal(t, []message{{Text: "hi"}}, responses)
+			tw.Done()
+		})
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+	})
+
+	t.Run("should report the acknowledgements of a server side emit that cannot be converted", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		newServer := func() *Server {
+			client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+			t.Cleanup(func() { client.Close() })
+			return NewServer(&ServerConfig{
+				AdapterCreator: adapter.NewRedisAdapterCreator(client, nil),
+			})
+		}
+		io1 := newServer()
+		io2 := newServer()
+		tw := utils.NewTestWaiter(1)
+
+		io2.Of("/").OnEvent("hello", func(ack func(reply string)) {
+			ack("not a number")
+		})
+		io1.ServerSideEmitWithAck(2*time.Second, "hello", func(err error, responses []int) {
+			assert.Error(t, err)
+			assert.NotErrorIs(t, err, ErrAckTimeout)
+			assert.Empty(t, responses)
+			tw.Done()
+		})
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+	})
+
+	t.Run("should create dynamic namespaces matching a regexp", func(t *testing.T) {
+		io, _, manager, close := newTestServerAndClient(t, nil, nil)
+		tw := utils.NewTestWaiterString()
+		tw.Add("middleware")
+		tw.Add("new namespace")
+		tw.Add("parent connection")
+		tw.Add("child connection")
+
+		parent := io.OfDynamic(regexp.MustCompile(`^/dynamic-\d+$`))
+		parent.Use(func(socket ServerSocket, handshake *Handshake) any {
+			tw.Done("middleware")
+			return nil
+		})
+		io.OnNewNamespace(func(namespace *Namespace) {
+			assert.Equal(t, "/dynamic-101", namespace.Name())
+			assert.Equal(t, parent, namespace.Parent())
+			namespace.OnConnection(func(socket ServerSocket) {
+				tw.Done("child connection")
+			})
+			tw.Done("new namespace")
+		})
+		parent.OnConnection(func(socket ServerSocket) {
+			assert.Equal(t, "/dynamic-101", socket.Namespace().Name())
+			assert.Equal(t, []*Namespace{socket.Namespace()}, parent.Children())
+			tw.Done("parent connection")
+		})
+
+		manager.Socket("/dynamic-101", nil).Connect()
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		close()
+	})
+
+	t.Run("should create dynamic namespaces accepted by a matcher function", func(t *testing.T) {
+		io, _, manager, close := newTestServerAndClient(t, nil, nil)
+		tw := utils.NewTestWaiter(2)
+
+		io.OfDynamic(func(name string, auth json.RawMessage) bool {
+			var a struct {
+				Token string `json:"token"`
+			}
+			_ = json.Unmarshal(auth, &a)
+			return strings.HasPrefix(name, "/tenant-") && a.Token == "secret"
+		}).OnConnection(func(socket ServerSocket) {
+			assert.Equal(t, "/tenant-a", socket.Namespace().Name())
+			tw.Done()
+		})
+
+		manager.Socket("/tenant-a", &ClientSocketConfig{Auth: map[string]string{"token": "secret"}}).Connect()
+		socket := manager.Socket("/tenant-b", &ClientSocketConfig{Auth: map[string]string{"token": "wrong"}})
+		socket.OnConnectError(func(err any) {
+			assert.Contains(t, err.(error).Error(), "namespace '/tenant-b' was not created")
+			tw.Done()
+		})
+		socket.Connect()
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		close()
+	})
+
+	t.Run("should run the middlewares of the parent namespace", func(t *testing.T) {
+		io, _, manager, close := newTestServerAndClient(t, nil, nil)
+		tw := utils.NewTestWaiter(1)
+
+		parent := io.OfDynamic(regexp.MustCompile(`^/dynamic-\d+$`))
+		parent.Use(func(socket ServerSocket, handshake *Handshake) any {
+			return fmt.Errorf("not authorized")
+		})
+		parent.OnConnection(func(socket ServerSocket) {
+			t.Error("should not connect")
+		})
+
+		socket := manager.Socket("/dynamic-1", nil)
+		socket.OnConnectError(func(err any) {
+			assert.Equal(t, "not authorized", err.(error).Error())
+			tw.Done()
+		})
+		socket.Connect()
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		// The child namespace of a rejected connection is removed.
+		assert.Eventually(t, func() bool {
+			_, ok := io.namespaces.get("/dynamic-1")
+			return len(parent.Children()) == 0 && !ok
+		}, utils.DefaultTestWaitTimeout, 10*time.Millisecond)
+		close()
+	})
+
+	t.Run("should remove the child namespaces without sockets with CleanupEmptyChildNamespaces", func(t *testing.T) {
+		io, ts, manager, close := newTestServerAndClient(t, &ServerConfig{CleanupEmptyChildNamespaces: true}, nil)
+		connected := make(chan string, 3)
+		var created atomic.Int32
+
+		parent := io.OfDynamic(regexp.MustCompile(`^/dynamic-\d+$`))
+		parent.OnConnection(func(socket ServerSocket) {
+			connected <- socket.Namespace().Name()
+		})
+		io.OnNewNamespace(func(namespace *Namespace) {
+			created.Add(1)
+		})
+		waitConnected := func() {
+			select {
+			case <-connected:
+			case <-time.After(utils.DefaultTestWaitTimeout):
+				t.Fatal("timeout exceeded")
+			}
+		}
+
+		s1 := manager.Socket("/dynamic-1", nil)
+		s1.Connect()
+		manager.Socket("/dynamic-2", nil).Connect()
+		waitConnected()
+		waitConnected()
+		assert.Len(t, parent.Children(), 2)
+
+		s1.Disconnect()
+		assert.Eventually(t, func() bool {
+			children := parent.Children()
+			return len(children) == 1 && children[0].Name() == "/dynamic-2"
+		}, utils.DefaultTestWaitTimeout, 10*time.Millisecond)
+
+		// The child namespace is created again.
+		newTestManager(ts, nil).Socket("/dynamic-1", nil).Connect()
+		waitConnected()
+		assert.Equal(t, int32(3), created.Load())
+		assert.Len(t, parent.Children(), 2)
+		close()
+	})
+
+	t.Run("should emit to every child namespace", func(t *testing.T) {
+		io, _, manager, close := newTestServerAndClient(t, nil, nil)
+		tw := utils.NewTestWaiter(2)
+		connected := utils.NewTestWaiter(2)
+
+		parent := io.OfDynamic(regexp.MustCompile(`^/dynamic-\d+$`))
+		parent.OnConnection(func(socket ServerSocket) {
+			connected.Done()
+		})
+
+		for _, name := range []string{"/dynamic-1", "/dynamic-2"} {
+			socket := manager.Socket(name, nil)
+			socket.OnEvent("hello", func(message string) {
+				assert.Equal(t, "world", message)
+				tw.Done()
+			})
+			socket.Connect()
+		}
+
+		connected.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		assert.Len(t, parent.Sockets(), 2)
+		parent.Emit("hello", "world")
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		close()
+	})
+
+	t.Run("should panic when the matcher of a dynamic namespace is invalid", func(t *testing.T) {
+		io := NewServer(nil)
+		assert.Panics(t, func() { io.OfDynamic("/dynamic") })
+		assert.Panics(t, func() { io.OfDynamic(regexp.MustCompile(`.*`)).Emit("hello", func() {}) })
+	})
+
+	t.Run("should disconnect the sockets and close the adapter when the namespace is closed", func(t *testing.T) {
+		var adapterClosed atomic.Bool
+		io, _, manager, close := newTestServerAndClient(t, &ServerConfig{
+			AdapterCreator: func(socketStore adapter.SocketStore, parserCreator parser.Creator) adapter.Adapter {
+				return &testClosingAdapter{
+					Adapter: adapter.NewInMemoryAdapterCreator()(socketStore, parserCreator),
+					closed:  &adapterClosed,
+				}
+			},
+		}, nil)
+		tw := utils.NewTestWaiter(1)
+		connected := utils.NewTestWaiter(1)
+
+		nsp := io.Of("/abc")
+		nsp.OnConnection(func(socket ServerSocket) {
+			connected.Done()
+		})
+		socket := manager.Socket("/abc", nil)
+		socket.OnDisconnect(func(reason Reason) {
+			assert.Equal(t, ReasonIOServerDisconnect, reason)
+			tw.Done()
+		})
+		socket.Connect()
+		connected.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+
+		io.RemoveNamespace("abc")
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		assert.True(t, adapterClosed.Load())
+		assert.Empty(t, nsp.Sockets())
+		assert.NotSame(t, nsp, io.Of("/abc"))
+		close()
+	})
+
+	t.Run("should remove a closed child namespace from its parent", func(t *testing.T) {
+		io, _, manager, close := newTestServerAndClient(t, nil, nil)
+		tw := utils.NewTestWaiter(1)
+
+		parent := io.OfDynamic(regexp.MustCompile(`^/dynamic-\d+$`))
+		parent.OnConnection(func(socket ServerSocket) {
+			tw.Done()
+		})
+		manager.Socket("/dynamic-1", nil).Connect()
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+
+		children := parent.Children()
+		if assert.Len(t, children, 1) {
+			children[0].Close()
+			children[0].Close()
+		}
+		assert.Empty(t, parent.Children())
+		close()
+	})
+}
+
+type testClosingAdapter struct {
+	adapter.Adapter
+	closed *atomic.Bool
+}
+
+func (a *testClosingAdapter) Close() {
+	a.closed.Store(true)
+	a.Adapter.Close()
 }
@@ -11,11 +11,14 @@ import (
 
 type packetQueue struct {
 	packets []*eioparser.Packet
+	// Whether the packets returned by get are being sent.
+	sending bool
 	mu      sync.Mutex
 
-	ready  chan struct{}
+	ready chan struct{}
+	// Closed (and replaced) when the queue is drained,
+	// that is, when there is no packet left to send.
 	drain  chan struct{}
-	_reset chan struct{}
 	_close chan struct{}
 }
 
@@ -23,7 +26,6 @@ func newPacketQueue() *packetQueue {
 	return &packetQueue{
 		ready:  make(chan struct{}, 1),
 		drain:  make(chan struct{}),
-		_reset: make(chan struct{}, 1),
 		_close: make(chan struct{}, 1),
 	}
 }
@@ -45,11 +47,6 @@ func (pq *packetQueue) poll() (packets []*eioparser.Packet, ok, closed bool) {
 		if len(packets) != 0 {
 			ok = true
 		}
-
-		select {
-		case pq.drain <- struct{}{}:
-		default:
-		}
 	}
 	return
 }
@@ -59,9 +56,28 @@ func (pq *packetQueue) get() (packets []*eioparser.Packet) {
 	defer pq.mu.Unlock()
 	packets = pq.packets
 	pq.packets = nil
+	if len(packets) != 0 {
+		pq.sending = true
+	}
 	return
 }
 
+// Called when the packets returned by get are sent.
+func (pq *packetQueue) sent() {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+	pq.sending = false
+	if len(pq.packets) == 0 {
+		pq.drained()
+	}
+}
+
+// Wakes up the waitForDrain calls. mu must be held.
+func (pq *packetQueue) drained() {
+	close(pq.drain)
+	pq.drain = make(chan struct{})
+}
+
 func (pq *packetQueue) add(packets ...*eioparser.Packet) {
 	pq.mu.Lock()
 
@@ -82,10 +98,8 @@ func (pq *packetQueue) reset() {
 	pq.mu.Lock()
 	defer pq.mu.Unlock()
 	pq.packets = nil
-	select {
-	case pq._reset <- struct{}{}:
-	default:
-	}
+	pq.sending = false
+	pq.drained()
 }
 
 func (pq *packetQueue) close() {
@@ -100,15 +114,15 @@ func (pq *packetQueue) close() {
 
 func (pq *packetQueue) waitForDrain(timeout time.Duration) (timedout bool) {
 	pq.mu.Lock()
-	alreadyDrained := len(pq.packets) == 0
+	alreadyDrained := len(pq.packets) == 0 && !pq.sending
+	drain := pq.drain
 	pq.mu.Unlock()
 	if alreadyDrained {
 		return
 	}
 
 	select {
-	case <-pq.drain:
-	case <-pq._reset:
+	case <-drain:
 	case <-time.After(timeout):
 		timedout = true
 	}
@@ -125,5 +139,6 @@ func (pq *packetQueue) pollAndSend(socket eio.Socket) {
 			continue
 		}
 		socket.Send(packets...)
+		pq.sent()
 	}
 }
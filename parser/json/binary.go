@@ -1,6 +1,7 @@
 package jsonparser
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
@@ -50,6 +51,15 @@ func (p *Parser) deconstructPacket(rv reflect.Value, numBuffers *int) (buffers [
 }
 
 func (p *Parser) deconstructValue(rv reflect.Value, numBuffers *int) (buffers [][]byte, err error) {
+	if rv.Kind() == reflect.Interface && rv.CanSet() {
+		buf, ok, err := p.deconstructCodecValue(rv, rv.Set, numBuffers)
+		if err != nil {
+			return nil, err
+		} else if ok {
+			return [][]byte{buf}, nil
+		}
+	}
+
 	k := rv.Kind()
 	original := rv
 	if k == reflect.Interface || k == reflect.Ptr {
@@ -175,12 +185,47 @@ func (p *Parser) deconstructBinaryValue(
 	return
 }
 
+// If the interface value iv holds a value that is to be sent as an attachment (see Options.Codecs),
+// the value is encoded and it is replaced with a placeholder using set.
+func (p *Parser) deconstructCodecValue(iv reflect.Value, set func(v reflect.Value), numBuffers *int) (buf []byte, ok bool, err error) {
+	marshal, ok := p.codecs.marshaler(iv.Elem())
+	if !ok {
+		return nil, false, nil
+	}
+
+	phold := reflect.ValueOf(placeholder{
+		Placeholder: true,
+		Num:         *numBuffers,
+	})
+	if !phold.Type().AssignableTo(iv.Type()) {
+		return nil, false, &ValueError{err: errNonSettableValue, Value: iv}
+	}
+
+	buf, err = marshal()
+	if err != nil {
+		return nil, false, err
+	}
+	*numBuffers++
+	set(phold)
+	return buf, true, nil
+}
+
 func (p *Parser) deconstructStruct(rv reflect.Value, numBuffers *int) (buffers [][]byte, err error) {
 	nf := rv.NumField()
 
 	for i := 0; i < nf; i++ {
 		fv := rv.Field(i)
 
+		if fv.Kind() == reflect.Interface && fv.CanSet() {
+			buf, ok, err := p.deconstructCodecValue(fv, fv.Set, numBuffers)
+			if err != nil {
+				return nil, err
+			} else if ok {
+				buffers = append(buffers, buf)
+				continue
+			}
+		}
+
 		k := fv.Kind()
 		if k == reflect.Interface || k == reflect.Ptr {
 			fv = fv.Elem()
@@ -209,6 +254,16 @@ func (p *Parser) deconstructMap(rv reflect.Value, numBuffers *int) (buffers [][]
 		original := mv
 		k := mv.Kind()
 
+		if k == reflect.Interface {
+			buf, ok, err := p.deconstructCodecValue(mv, func(v reflect.Value) { rv.SetMapIndex(mk, v) }, numBuffers)
+			if err != nil {
+				return nil, err
+			} else if ok {
+				buffers = append(buffers, buf)
+				continue
+			}
+		}
+
 		if k == reflect.Interface || k == reflect.Ptr {
 			mv = mv.Elem()
 			k = mv.Kind()
@@ -252,7 +307,10 @@ type reconstructor struct {
 	eventName string
 	buffers   [][]byte
 	remaining int
-	json      serializer.JSONSerializer
+	// The size of the packet so far, including the attachments.
+	size   int
+	codecs *codecs
+	json   serializer.JSONSerializer
 }
 
 func (r *reconstructor) addBuffer(buf []byte) (ok bool) {
@@ -288,6 +346,25 @@ func (r *reconstructor) reconstruct(types ...reflect.Type) (values []reflect.Val
 		ifaces[i] = rv.Interface()
 	}
 
+	// The arguments whose type is sent as an attachment (see Options.Codecs)
+	// are decoded from the attachment their placeholder points to.
+	offset := 0
+	if r.header.IsEvent() {
+		offset = 1
+	}
+	codecArgs := make(map[int]*codecArg)
+	for i, typ := range types {
+		if typ == nil {
+			continue
+		}
+		unmarshal, ok := r.codecs.unmarshaler(typ)
+		if ok {
+			arg := &codecArg{unmarshal: unmarshal}
+			codecArgs[i] = arg
+			ifaces[i+offset] = &arg.raw
+		}
+	}
+
 	err = r.json.Unmarshal(payload, &ifaces)
 	if err != nil {
 		return
@@ -297,12 +374,61 @@ func (r *reconstructor) reconstruct(types ...reflect.Type) (values []reflect.Val
 		values = values[1:]
 	}
 
-	err = r.reconstructPacket(values)
+	rest := make([]reflect.Value, 0, len(values))
+	for i, rv := range values {
+		arg, ok := codecArgs[i]
+		if !ok {
+			rest = append(rest, rv)
+			continue
+		}
+		err = r.reconstructCodecValue(arg, rv)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = r.reconstructPacket(rest)
 	return
 }
 
+type codecArg struct {
+	raw       json.RawMessage
+	unmarshal func(data []byte, ptr reflect.Value) error
+}
+
+func (r *reconstructor) reconstructCodecValue(arg *codecArg, ptr reflect.Value) error {
+	if len(arg.raw) == 0 || string(arg.raw) == "null" {
+		return nil
+	}
+
+	var p placeholder
+	err := r.json.Unmarshal(arg.raw, &p)
+	if err != nil || !p.Placeholder {
+		// Not an attachment, decode the JSON as is.
+		return r.json.Unmarshal(arg.raw, ptr.Interface())
+	}
+
+	num := p.Num + 1
+	if num < 1 || num >= len(r.buffers) {
+		return errInvalidPlaceholderNumValue
+	}
+	return arg.unmarshal(r.buffers[num], ptr)
+}
+
 func (r *reconstructor) reconstructPacket(rv []reflect.Value) error {
 	for _, rv := range rv {
+		// The value is decoded into an any, the placeholders are maps.
+		if rv.Kind() == reflect.Ptr && rv.Type().Elem() == anyType {
+			if rv.Elem().IsNil() {
+				continue
+			}
+			v, err := r.reconstructAny(rv.Elem().Interface())
+			if err != nil {
+				return err
+			}
+			rv.Elem().Set(reflect.ValueOf(v))
+			continue
+		}
 		err := r.reconstructValue(rv)
 		if err != nil {
 			return err
@@ -416,6 +542,37 @@ func (r *reconstructor) reconstructBinaryValue(
 	return nil
 }
 
+// Replaces the placeholders of a value that is decoded into an any
+// with the binary data they stand for.
+func (r *reconstructor) reconstructAny(v any) (any, error) {
+	switch v := v.(type) {
+	case []any:
+		for i := range v {
+			x, err := r.reconstructAny(v[i])
+			if err != nil {
+				return nil, err
+			}
+			v[i] = x
+		}
+	case map[string]any:
+		if isPlaceholder, _ := v["_placeholder"].(bool); isPlaceholder && len(v) == 2 {
+			num, ok := v["num"].(float64)
+			if !ok || num < 0 || int(num)+1 >= len(r.buffers) {
+				return nil, errInvalidPlaceholderNumValue
+			}
+			return Binary(r.buffers[int(num)+1]), nil
+		}
+		for k, x := range v {
+			x, err := r.reconstructAny(x)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = x
+		}
+	}
+	return v, nil
+}
+
 func (r *reconstructor) reconstructStruct(rv reflect.Value) error {
 	nf := rv.NumField()
 
@@ -548,8 +705,14 @@ func (r *reconstructor) reconstructMap(rv reflect.Value) error {
 	return nil
 }
 
-func hasBinary(values ...reflect.Value) bool {
+func hasBinary(c *codecs, values ...reflect.Value) bool {
 	for _, rv := range values {
+		if rv.Kind() == reflect.Interface {
+			if _, ok := c.marshaler(rv.Elem()); ok {
+				return true
+			}
+		}
+
 		k := rv.Kind()
 		if k == reflect.Interface || k == reflect.Ptr {
 			rv = rv.Elem()
@@ -571,7 +734,7 @@ func hasBinary(values ...reflect.Value) bool {
 				l := rv.Len()
 				for i := 0; i < l; i++ {
 					val := rv.Index(i)
-					if hasBinary(val) {
+					if hasBinary(c, val) {
 						return true
 					}
 				}
@@ -593,7 +756,7 @@ func hasBinary(values ...reflect.Value) bool {
 					continue
 				}
 
-				if hasBinary(fv) {
+				if hasBinary(c, fv) {
 					return true
 				}
 			}
@@ -602,7 +765,7 @@ func hasBinary(values ...reflect.Value) bool {
 			iter := rv.MapRange()
 			for iter.Next() {
 				mv := iter.Value()
-				if hasBinary(mv) {
+				if hasBinary(c, mv) {
 					return true
 				}
 			}
@@ -0,0 +1,119 @@
+package jsonparser
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Encodes the values of a type into binary attachments (for example, protobuf messages).
+type Codec interface {
+	// v is the value as it was given to Emit.
+	Marshal(v any) ([]byte, error)
+	// v is a non-nil pointer to the value to decode into.
+	Unmarshal(data []byte, v any) error
+}
+
+// The values that are sent as binary attachments (see Options.Codecs and Options.BinaryMarshalers).
+// A nil *codecs sends every value as JSON.
+type codecs struct {
+	byType           map[reflect.Type]Codec
+	binaryMarshalers bool
+}
+
+func newCodecs(opts *Options) *codecs {
+	if len(opts.Codecs) == 0 && !opts.BinaryMarshalers {
+		return nil
+	}
+	c := &codecs{
+		byType:           make(map[reflect.Type]Codec, len(opts.Codecs)),
+		binaryMarshalers: opts.BinaryMarshalers,
+	}
+	for typ, codec := range opts.Codecs {
+		if typ == nil || codec == nil {
+			panic(fmt.Errorf("parser/json: Options.Codecs: the types and the codecs must be non-nil"))
+		}
+		c.byType[typ] = codec
+	}
+	return c
+}
+
+var (
+	binaryMarshalerType   = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+	jsonMarshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	jsonUnmarshalerType   = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textMarshalerType     = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType   = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func (c *codecs) find(typ reflect.Type) (Codec, bool) {
+	if len(c.byType) == 0 {
+		return nil, false
+	}
+
+	ptr := typ
+	if typ.Kind() != reflect.Ptr {
+		ptr = reflect.PointerTo(typ)
+	} else if codec, ok := c.byType[typ.Elem()]; ok {
+		return codec, true
+	}
+	if codec, ok := c.byType[typ]; ok {
+		return codec, true
+	}
+	if codec, ok := c.byType[ptr]; ok {
+		return codec, true
+	}
+	for t, codec := range c.byType {
+		if t.Kind() == reflect.Interface && (typ.Implements(t) || ptr.Implements(t)) {
+			return codec, true
+		}
+	}
+	return nil, false
+}
+
+// Returns the function that encodes v if it is to be sent as an attachment.
+func (c *codecs) marshaler(v reflect.Value) (marshal func() ([]byte, error), ok bool) {
+	if c == nil || !v.IsValid() || !v.CanInterface() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return nil, false
+	}
+	typ := v.Type()
+
+	codec, ok := c.find(typ)
+	if ok {
+		return func() ([]byte, error) { return codec.Marshal(v.Interface()) }, true
+	}
+
+	if c.binaryMarshalers && typ.Implements(binaryMarshalerType) && !typ.Implements(jsonMarshalerType) && !typ.Implements(textMarshalerType) {
+		return v.Interface().(encoding.BinaryMarshaler).MarshalBinary, true
+	}
+	return nil, false
+}
+
+// Returns the function that decodes an attachment into ptr (a pointer to a value of typ),
+// if the values of typ are sent as attachments.
+func (c *codecs) unmarshaler(typ reflect.Type) (unmarshal func(data []byte, ptr reflect.Value) error, ok bool) {
+	if c == nil {
+		return nil, false
+	}
+	base := typ
+	if base.Kind() == reflect.Ptr {
+		base = base.Elem()
+	}
+	ptrType := reflect.PointerTo(base)
+
+	codec, ok := c.find(base)
+	if ok {
+		return func(data []byte, ptr reflect.Value) error {
+			return codec.Unmarshal(data, ptr.Interface())
+		}, true
+	}
+
+	if c.binaryMarshalers && ptrType.Implements(binaryUnmarshalerType) && !ptrType.Implements(jsonUnmarshalerType) && !ptrType.Implements(textUnmarshalerType) {
+		return func(data []byte, ptr reflect.Value) error {
+			return ptr.Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(data)
+		}, true
+	}
+	return nil, false
+}
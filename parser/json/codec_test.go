@@ -0,0 +1,148 @@
+package jsonparser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hhuuson97/socket.io-go/parser"
+	"github.com/hhuuson97/socket.io-go/parser/json/serializer/stdjson"
+)
+
+// Implements encoding.BinaryMarshaler and encoding.BinaryUnmarshaler.
+type testPoint struct {
+	X, Y uint16
+}
+
+func (p testPoint) MarshalBinary() ([]byte, error) {
+	return binary.BigEndian.AppendUint16(binary.BigEndian.AppendUint16(nil, p.X), p.Y), nil
+}
+
+func (p *testPoint) UnmarshalBinary(data []byte) error {
+	if len(data) != 4 {
+		return fmt.Errorf("invalid point")
+	}
+	p.X = binary.BigEndian.Uint16(data)
+	p.Y = binary.BigEndian.Uint16(data[2:])
+	return nil
+}
+
+// Stands for a protobuf message: it has no binary methods of its own.
+type testMessage interface {
+	Text() string
+}
+
+type testTextMessage struct {
+	text string
+}
+
+func (m *testTextMessage) Text() string { return m.text }
+
+type testMessageCodec struct{}
+
+func (testMessageCodec) Marshal(v any) ([]byte, error) {
+	return []byte(v.(testMessage).Text()), nil
+}
+
+func (testMessageCodec) Unmarshal(data []byte, v any) error {
+	v.(*testTextMessage).text = string(data)
+	return nil
+}
+
+func TestCodec(t *testing.T) {
+	p := NewCreatorWithOptions(0, &Options{
+		Codecs: map[reflect.Type]Codec{
+			reflect.TypeOf((*testMessage)(nil)).Elem(): testMessageCodec{},
+		},
+		BinaryMarshalers: true,
+	}, stdjson.New())()
+	now := time.Now().Truncate(time.Second)
+
+	header := &parser.PacketHeader{
+		Type:      parser.PacketTypeEvent,
+		Namespace: "/",
+	}
+	v := []any{"event", testPoint{X: 1, Y: 2}, &testTextMessage{text: "hello"}, now, map[string]any{"p": testPoint{X: 3, Y: 4}}}
+	buffers, err := p.Encode(header, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if header.Type != parser.PacketTypeBinaryEvent {
+		t.Fatalf("BINARY_EVENT expected, got %d", header.Type)
+	}
+	if len(buffers) != 4 {
+		t.Fatalf("4 buffers expected, got %d", len(buffers))
+	}
+	if !bytes.Equal(buffers[1], []byte{0, 1, 0, 2}) || string(buffers[2]) != "hello" {
+		t.Fatalf("unexpected attachments: %v", buffers[1:])
+	}
+	if !bytes.Contains(buffers[0], []byte(`{"_placeholder":true,"num":0}`)) {
+		t.Fatalf("placeholder expected: %s", buffers[0])
+	}
+
+	var values []reflect.Value
+	finish := func(header *parser.PacketHeader, eventName string, decode parser.Decode) {
+		values, err = decode(
+			reflect.TypeOf(testPoint{}),
+			reflect.TypeOf(&testTextMessage{}),
+			reflect.TypeOf(time.Time{}),
+			reflect.TypeOf(map[string]any{}),
+		)
+		if err != nil {
+			t.Fatalf("decode error: %v", err)
+		}
+	}
+	for _, buf := range buffers {
+		err = p.Add(buf, finish)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(values) != 4 {
+		t.Fatalf("4 values expected, got %d", len(values))
+	}
+	if point := values[0].Elem().Interface().(testPoint); point != (testPoint{X: 1, Y: 2}) {
+		t.Fatalf("unexpected point: %v", point)
+	}
+	if text := values[1].Interface().(*testTextMessage).Text(); text != "hello" {
+		t.Fatalf("unexpected message: %s", text)
+	}
+	if tm := values[2].Elem().Interface().(time.Time); !tm.Equal(now) {
+		t.Fatalf("unexpected time: %v", tm)
+	}
+	// There is no type to decode into, the attachment is given as is.
+	m := values[3].Elem().Interface().(map[string]any)
+	if !reflect.DeepEqual(m["p"], []byte{0, 3, 0, 4}) {
+		t.Fatalf("unexpected map value: %#v", m["p"])
+	}
+}
+
+func TestCodecDefault(t *testing.T) {
+	p := NewCreator(0, stdjson.New())()
+
+	header := &parser.PacketHeader{
+		Type:      parser.PacketTypeEvent,
+		Namespace: "/",
+	}
+	v := []any{"event", testPoint{X: 1, Y: 2}}
+	buffers, err := p.Encode(header, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The values implementing encoding.BinaryMarshaler are encoded into JSON unless Options.BinaryMarshalers is set.
+	if header.Type != parser.PacketTypeEvent {
+		t.Fatalf("EVENT expected, got %d", header.Type)
+	}
+	if len(buffers) != 1 {
+		t.Fatalf("1 buffer expected, got %d", len(buffers))
+	}
+	if want := `2["event",{"X":1,"Y":2}]`; string(buffers[0]) != want {
+		t.Fatalf("%s expected, got %s", want, buffers[0])
+	}
+}
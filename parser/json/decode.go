@@ -18,25 +18,43 @@ var (
 	errInvalidNumberOfValues      = fmt.Errorf("parser/json: invalid number of values")
 )
 
-var stringType = reflect.TypeOf("")
+var (
+	stringType     = reflect.TypeOf("")
+	argsType       = reflect.TypeOf(parser.Args(nil))
+	argsCountType  = reflect.TypeOf(parser.ArgsCount(0))
+	decodeIntoType = reflect.TypeOf(parser.DecodeInto(nil))
+	anyType        = reflect.TypeOf((*any)(nil)).Elem()
+)
 
 func (p *Parser) Add(data []byte, finish parser.Finish) error {
 	if p.r == nil {
+		err := p.limits.checkPayloadSize(len(data))
+		if err != nil {
+			return err
+		}
+
 		header, buf, eventName, err := p.parseHeader(data)
 		if err != nil {
 			return err
 		}
 
+		err = p.limits.check(header, buf, eventName)
+		if err != nil {
+			return err
+		}
+
 		p.r = &reconstructor{
 			header:    header,
 			eventName: eventName,
 			buffers:   [][]byte{buf},
 			remaining: header.Attachments,
+			size:      len(data),
+			codecs:    p.codecs,
 			json:      p.json,
 		}
 
 		if p.maxAttachments > 0 && header.Attachments > p.maxAttachments {
-			return errMaxAttachmentsExceeded
+			return &parser.LimitError{Limit: "number of attachments", Max: p.maxAttachments}
 		}
 
 		ok := !header.IsBinary() || header.Attachments == 0
@@ -48,6 +66,12 @@ func (p *Parser) Add(data []byte, finish parser.Finish) error {
 		return nil
 	}
 
+	p.r.size += len(data)
+	err := p.limits.checkPayloadSize(p.r.size)
+	if err != nil {
+		return err
+	}
+
 	ok := p.r.addBuffer(data)
 	if ok {
 		r := p.r
@@ -105,7 +129,11 @@ func (p *Parser) parseHeader(data []byte) (header *parser.PacketHeader, buf []by
 		}
 
 		header.Namespace = string(data[:i])
-		data = data[i+1:]
+		if i < len(data) {
+			data = data[i+1:]
+		} else {
+			data = data[i:]
+		}
 	} else {
 		header.Namespace = "/"
 	}
@@ -191,6 +219,17 @@ func (p *Parser) parseHeader(data []byte) (header *parser.PacketHeader, buf []by
 }
 
 func (r *reconstructor) decode(types ...reflect.Type) (values []reflect.Value, err error) {
+	if len(types) == 1 {
+		switch types[0] {
+		case argsType:
+			return r.decodeArgs()
+		case argsCountType:
+			return r.countArgs()
+		case decodeIntoType:
+			return []reflect.Value{reflect.ValueOf(parser.DecodeInto(r.decodeInto))}, nil
+		}
+	}
+
 	// We have no binary data.
 	if len(r.buffers) == 1 {
 		payload := r.buffers[0]
@@ -290,3 +329,121 @@ func convertTypesToValues(types ...reflect.Type) (values []reflect.Value) {
 	}
 	return
 }
+
+// Decodes the arguments of the packet into the values that ptrs point to (see parser.DecodeInto).
+func (r *reconstructor) decodeInto(ptrs ...any) error {
+	// The placeholders of the attachments are replaced through reflection.
+	if len(r.buffers) != 1 || !(r.header.IsEvent() || r.header.IsAck()) {
+		return r.decodeIntoValues(ptrs)
+	}
+
+	payload := r.buffers[0]
+	ifaces := make([]any, 0, len(ptrs)+1)
+	if r.header.IsEvent() {
+		if len(payload) == 0 {
+			return errMalformedPacket
+		}
+		var eventName string
+		ifaces = append(ifaces, &eventName)
+	} else if len(payload) == 0 {
+		payload = []byte("[]")
+	}
+	ifaces = append(ifaces, ptrs...)
+	return r.json.Unmarshal(payload, &ifaces)
+}
+
+func (r *reconstructor) decodeIntoValues(ptrs []any) error {
+	types := make([]reflect.Type, len(ptrs))
+	for i, ptr := range ptrs {
+		typ := reflect.TypeOf(ptr)
+		if typ == nil || typ.Kind() != reflect.Ptr {
+			return fmt.Errorf("parser/json: invalid argument: %w", errNonPtrArgument)
+		}
+		types[i] = typ.Elem()
+	}
+
+	values, err := r.decode(types...)
+	if err != nil {
+		return err
+	}
+
+	// Only the arguments of the packet are set.
+	n := len(values)
+	if r.header.IsEvent() || r.header.IsAck() {
+		n, err = r.numArgs()
+		if err != nil {
+			return err
+		}
+	}
+	for i, v := range values {
+		if i == n {
+			break
+		}
+		dst := reflect.ValueOf(ptrs[i]).Elem()
+		if dst.Kind() == reflect.Ptr {
+			dst.Set(v)
+		} else {
+			dst.Set(v.Elem())
+		}
+	}
+	return nil
+}
+
+// Counts the arguments of the packet without decoding them.
+func (r *reconstructor) countArgs() (values []reflect.Value, err error) {
+	n, err := r.numArgs()
+	if err != nil {
+		return nil, err
+	}
+	return []reflect.Value{reflect.ValueOf(parser.ArgsCount(n))}, nil
+}
+
+func (r *reconstructor) numArgs() (n int, err error) {
+	if len(r.buffers) < 1 {
+		return 0, errInvalidNumberOfBuffers
+	}
+
+	if r.header.IsEvent() || r.header.IsAck() {
+		_, n = scanJSON(r.buffers[0])
+		if r.header.IsEvent() {
+			if n == 0 {
+				return 0, errMalformedPacket
+			}
+			n-- // The event name
+		}
+	}
+	return n, nil
+}
+
+// Decodes every argument of the packet into a parser.Args.
+func (r *reconstructor) decodeArgs() (values []reflect.Value, err error) {
+	if len(r.buffers) < 1 {
+		return nil, errInvalidNumberOfBuffers
+	}
+
+	payload := r.buffers[0]
+	if len(payload) == 0 {
+		payload = []byte("[]")
+	}
+
+	var args []any
+	err = r.json.Unmarshal(payload, &args)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.header.IsEvent() {
+		if len(args) == 0 {
+			return nil, errMalformedPacket
+		}
+		args = args[1:]
+	}
+
+	for i := range args {
+		args[i], err = r.reconstructAny(args[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return []reflect.Value{reflect.ValueOf(parser.Args(args))}, nil
+}
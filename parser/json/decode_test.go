@@ -86,6 +86,136 @@ func TestMaxAttachmentsDecode(t *testing.T) {
 	}
 }
 
+func TestDecodeArgs(t *testing.T) {
+	c := NewCreator(0, stdjson.New())
+	p := c()
+
+	header := &parser.PacketHeader{
+		Type:      parser.PacketTypeEvent,
+		Namespace: "/",
+	}
+	v := []any{"event", 1, "a", Binary("b1"), map[string]any{"b": Binary("b2")}}
+	buffers, err := p.Encode(header, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		args  parser.Args
+		count parser.ArgsCount
+	)
+	finish := func(header *parser.PacketHeader, eventName string, decode parser.Decode) {
+		values, err := decode(reflect.TypeOf(parser.Args(nil)))
+		if err != nil {
+			t.Fatalf("decode error: %v", err)
+		}
+		if len(values) != 1 {
+			t.Fatalf("1 value expected, got %d", len(values))
+		}
+		args = values[0].Interface().(parser.Args)
+
+		values, err = decode(reflect.TypeOf(parser.ArgsCount(0)))
+		if err != nil {
+			t.Fatalf("decode error: %v", err)
+		}
+		count = values[0].Interface().(parser.ArgsCount)
+	}
+
+	for _, buf := range buffers {
+		err = p.Add(buf, finish)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := parser.Args{float64(1), "a", Binary("b1"), map[string]any{"b": Binary("b2")}}
+	if !reflect.DeepEqual(expected, args) {
+		t.Fatalf("expected %v, got %v", expected, args)
+	}
+	if count != 4 {
+		t.Fatalf("4 arguments expected, got %d", count)
+	}
+}
+
+func TestDecodeInto(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+	}
+	tests := []struct {
+		name  string
+		first any
+	}{
+		{"without attachments", "a"},
+		{"with attachments", Binary("b")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewCreator(0, stdjson.New())()
+			header := &parser.PacketHeader{
+				Type:      parser.PacketTypeEvent,
+				Namespace: "/",
+			}
+			v := []any{"event", test.first, map[string]any{"name": "alice"}}
+			buffers, err := p.Encode(header, &v)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var (
+				first   any
+				u       *user
+				missing = "missing"
+			)
+			finish := func(header *parser.PacketHeader, eventName string, decode parser.Decode) {
+				values, err := decode(reflect.TypeOf(parser.DecodeInto(nil)))
+				if err != nil {
+					t.Fatalf("decode error: %v", err)
+				}
+				if len(values) != 1 {
+					t.Fatalf("1 value expected, got %d", len(values))
+				}
+				err = values[0].Interface().(parser.DecodeInto)(&first, &u, &missing)
+				if err != nil {
+					t.Fatalf("decode error: %v", err)
+				}
+			}
+			for _, buf := range buffers {
+				err = p.Add(buf, finish)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if !reflect.DeepEqual(test.first, first) {
+				t.Fatalf("expected %#v, got %#v", test.first, first)
+			}
+			if u == nil || u.Name != "alice" {
+				t.Fatalf("unexpected user: %v", u)
+			}
+			if missing != "missing" {
+				t.Fatalf("the missing argument was set: %s", missing)
+			}
+		})
+	}
+}
+
+func TestDecodeNamespaceWithoutComma(t *testing.T) {
+	p := NewCreator(0, stdjson.New())()
+
+	var namespace string
+	finish := func(header *parser.PacketHeader, eventName string, decode parser.Decode) {
+		namespace = header.Namespace
+	}
+
+	err := p.Add([]byte("0/admin?token=abc"), finish)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if namespace != "/admin?token=abc" {
+		t.Fatalf("namespace `/admin?token=abc` expected, got: `%s`", namespace)
+	}
+}
+
 func printValues(t *testing.T, values ...reflect.Value) {
 	for i, rv := range values {
 		k := rv.Kind()
@@ -45,7 +45,7 @@ func (p *Parser) Encode(header *parser.PacketHeader, v any) ([][]byte, error) {
 	}
 
 	if header.Type == parser.PacketTypeEvent || header.Type == parser.PacketTypeAck {
-		if hasBinary(rv) {
+		if hasBinary(p.codecs, rv) {
 			switch header.Type {
 			case parser.PacketTypeEvent:
 				header.Type = parser.PacketTypeBinaryEvent
@@ -0,0 +1,120 @@
+package jsonparser
+
+import (
+	"github.com/hhuuson97/socket.io-go/parser"
+)
+
+// Limits of the received packets. The packets are checked before they are decoded,
+// and a packet that exceeds a limit is rejected with a *parser.LimitError.
+//
+// A zero value means that there is no limit.
+type Limits struct {
+	// The maximum size of a packet in bytes, including its binary attachments.
+	MaxPayloadSize int
+
+	// The maximum nesting depth of the JSON payload. The array that holds the arguments
+	// of an event is at depth 1, so an argument that is an object is at depth 2.
+	MaxDepth int
+
+	// The maximum number of arguments of an event (without the event name) or an acknowledgement.
+	MaxArgs int
+
+	// The maximum length of an event name in bytes.
+	MaxEventNameLength int
+}
+
+func (l *Limits) checkPayloadSize(size int) error {
+	if l.MaxPayloadSize > 0 && size > l.MaxPayloadSize {
+		return &parser.LimitError{Limit: "payload size", Max: l.MaxPayloadSize}
+	}
+	return nil
+}
+
+func (l *Limits) check(header *parser.PacketHeader, payload []byte, eventName string) error {
+	if l.MaxEventNameLength > 0 && len(eventName) > l.MaxEventNameLength {
+		return &parser.LimitError{Limit: "event name length", Max: l.MaxEventNameLength}
+	}
+	if l.MaxDepth <= 0 && l.MaxArgs <= 0 {
+		return nil
+	}
+
+	depth, elements := scanJSON(payload)
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return &parser.LimitError{Limit: "nesting depth", Max: l.MaxDepth}
+	}
+	if l.MaxArgs > 0 && (header.IsEvent() || header.IsAck()) {
+		args := elements
+		if header.IsEvent() && args > 0 {
+			args-- // The event name
+		}
+		if args > l.MaxArgs {
+			return &parser.LimitError{Limit: "number of arguments", Max: l.MaxArgs}
+		}
+	}
+	return nil
+}
+
+// Returns the maximum nesting depth of the JSON value in data,
+// and the number of elements if the value is an array.
+//
+// data is not validated, it is only scanned (the payload is validated when it is decoded).
+func scanJSON(data []byte) (maxDepth, elements int) {
+	var (
+		depth    int
+		inString bool
+		escaped  bool
+		isArray  bool
+		hasValue bool
+	)
+	for _, c := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			continue
+		case '[', '{':
+			if depth == 0 && c == '[' {
+				isArray = true
+			}
+			if depth == 1 {
+				hasValue = true
+			}
+			depth++
+			if depth > maxDepth {
+				maxDepth = depth
+			}
+			continue
+		case ']', '}':
+			depth--
+			continue
+		case ',':
+			if depth == 1 {
+				elements++
+			}
+			continue
+		case '"':
+			inString = true
+		}
+		if depth == 1 {
+			hasValue = true
+		}
+	}
+
+	if !isArray {
+		return maxDepth, 0
+	}
+	if hasValue {
+		elements++
+	}
+	return maxDepth, elements
+}
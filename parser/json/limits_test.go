@@ -0,0 +1,66 @@
+package jsonparser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hhuuson97/socket.io-go/parser"
+	"github.com/hhuuson97/socket.io-go/parser/json/serializer/stdjson"
+)
+
+func TestLimits(t *testing.T) {
+	limits := Limits{
+		MaxPayloadSize:     64,
+		MaxDepth:           3,
+		MaxArgs:            2,
+		MaxEventNameLength: 5,
+	}
+	finish := func(header *parser.PacketHeader, eventName string, decode parser.Decode) {}
+
+	tests := []struct {
+		name    string
+		buffers []string
+		limit   string
+	}{
+		{name: "within the limits", buffers: []string{`2["hello",{"a":[1]},"]]],,,"]`}},
+		{name: "ack within the limits", buffers: []string{`31[1,2]`}},
+		{name: "binary event within the limits", buffers: []string{`51-["hello",{"_placeholder":true,"num":0}]`, "12345"}},
+		{name: "payload size", buffers: []string{`2["hello","` + strings.Repeat("a", 64) + `"]`}, limit: "payload size"},
+		{name: "payload size with attachments", buffers: []string{`51-["hello",{"_placeholder":true,"num":0}]`, strings.Repeat("a", 32)}, limit: "payload size"},
+		{name: "nesting depth", buffers: []string{`2["hello",{"a":[[1]]}]`}, limit: "nesting depth"},
+		{name: "nesting depth of the auth data", buffers: []string{`0{"a":{"b":{"c":{}}}}`}, limit: "nesting depth"},
+		{name: "number of arguments", buffers: []string{`2["hello",1,2,3]`}, limit: "number of arguments"},
+		{name: "number of arguments of an ack", buffers: []string{`31[1,2,3]`}, limit: "number of arguments"},
+		{name: "event name length", buffers: []string{`2["hello!",1]`}, limit: "event name length"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewCreatorWithLimits(0, limits, stdjson.New())()
+
+			var err error
+			for _, buf := range test.buffers {
+				err = p.Add([]byte(buf), finish)
+				if err != nil {
+					break
+				}
+			}
+
+			if test.limit == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var limitErr *parser.LimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("*parser.LimitError expected, got: %v", err)
+			}
+			if limitErr.Limit != test.limit {
+				t.Fatalf("the limit `%s` expected, got: `%s`", test.limit, limitErr.Limit)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package jsonparser
 
 import (
 	"fmt"
+	"reflect"
 
 	"github.com/hhuuson97/socket.io-go/parser"
 	"github.com/hhuuson97/socket.io-go/parser/json/serializer"
@@ -10,12 +11,53 @@ import (
 // maxAttachments is the maximum number of the binary attachments to parse/send.
 // If maxAttachments is 0, there will be no limit set for binary attachments.
 func NewCreator(maxAttachments int, json serializer.JSONSerializer) parser.Creator {
+	return NewCreatorWithLimits(maxAttachments, Limits{}, json)
+}
+
+// Same as NewCreator, with the limits of the received packets (see Limits).
+func NewCreatorWithLimits(maxAttachments int, limits Limits, json serializer.JSONSerializer) parser.Creator {
+	return NewCreatorWithOptions(maxAttachments, &Options{Limits: limits}, json)
+}
+
+type Options struct {
+	// The limits of the received packets (see Limits).
+	Limits Limits
+
+	// The codecs of the values that are sent as binary attachments instead of JSON
+	// (for example, protobuf messages), by type. If a type is an interface type,
+	// its codec is used for every type that implements it.
+	//
+	// The values are only replaced when they are held by an interface: the arguments of an event
+	// or an acknowledgement, and the elements of []any, map[string]any and interface fields.
+	// On receive, the attachment is decoded back into the argument type of the handler.
+	Codecs map[reflect.Type]Codec
+
+	// If true, the values implementing encoding.BinaryMarshaler (but neither json.Marshaler
+	// nor encoding.TextMarshaler) are sent as binary attachments in the same way,
+	// and decoded with encoding.BinaryUnmarshaler.
+	//
+	// This changes the wire format of these values, which are otherwise encoded into JSON,
+	// so every server and client must use the same setting.
+	//
+	// Default: false
+	BinaryMarshalers bool
+}
+
+// Same as NewCreator, with the options of the parsers (see Options).
+func NewCreatorWithOptions(maxAttachments int, opts *Options, json serializer.JSONSerializer) parser.Creator {
 	if json == nil {
 		panic(fmt.Errorf("sio: jsonparser.NewCreator: `json` must be set"))
 	}
+	if opts == nil {
+		opts = new(Options)
+	}
+	limits := opts.Limits
+	codecs := newCodecs(opts)
 	return func() parser.Parser {
 		return &Parser{
 			maxAttachments: maxAttachments,
+			limits:         limits,
+			codecs:         codecs,
 			json:           json,
 		}
 	}
@@ -24,6 +66,8 @@ func NewCreator(maxAttachments int, json serializer.JSONSerializer) parser.Creat
 type Parser struct {
 	r              *reconstructor
 	maxAttachments int
+	limits         Limits
+	codecs         *codecs
 	json           serializer.JSONSerializer
 }
 
@@ -0,0 +1,375 @@
+package msgpackparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/hhuuson97/socket.io-go/internal/sync"
+	"github.com/hhuuson97/socket.io-go/parser"
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+)
+
+var (
+	errInvalidPacketType = fmt.Errorf("parser/msgpack: invalid packet type")
+	errInvalidNamespace  = fmt.Errorf("parser/msgpack: invalid namespace")
+	errInvalidPayload    = fmt.Errorf("parser/msgpack: invalid payload")
+	errInvalidEventName  = fmt.Errorf("parser/msgpack: invalid event name")
+	errInvalidPacketID   = fmt.Errorf("parser/msgpack: invalid packet id")
+)
+
+var (
+	argsType       = reflect.TypeOf(parser.Args(nil))
+	argsCountType  = reflect.TypeOf(parser.ArgsCount(0))
+	decodeIntoType = reflect.TypeOf(parser.DecodeInto(nil))
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+	// An empty map. This is the payload of a CONNECT packet without data.
+	emptyMap = []byte{msgpcode.FixedMapLow}
+)
+
+type rawPacket struct {
+	Type *int               `msgpack:"type"`
+	Data msgpack.RawMessage `msgpack:"data"`
+	Nsp  *string            `msgpack:"nsp"`
+	ID   *int64             `msgpack:"id"`
+}
+
+type decoder struct {
+	header *parser.PacketHeader
+	data   msgpack.RawMessage
+	// The arguments of an EVENT (without the event name) or an ACK packet.
+	args []msgpack.RawMessage
+	p    *Parser
+}
+
+// Every message is a complete packet, finish is called before Add returns.
+func (p *Parser) Add(data []byte, finish parser.Finish) error {
+	var pkt rawPacket
+	err := newDecoder(data).Decode(&pkt)
+	if err != nil {
+		return fmt.Errorf("parser/msgpack: %w", err)
+	}
+
+	if pkt.Type == nil || *pkt.Type < int(parser.PacketTypeConnect) || *pkt.Type > int(parser.PacketTypeConnectError) {
+		return errInvalidPacketType
+	}
+	if pkt.Nsp == nil {
+		return errInvalidNamespace
+	}
+	if pkt.ID != nil && *pkt.ID < 0 {
+		return errInvalidPacketID
+	}
+	if len(pkt.Data) == 1 && pkt.Data[0] == msgpcode.Nil {
+		pkt.Data = nil
+	}
+
+	header := &parser.PacketHeader{
+		Type:      parser.PacketType(*pkt.Type),
+		Namespace: *pkt.Nsp,
+	}
+	if pkt.ID != nil {
+		id := uint64(*pkt.ID)
+		header.ID = &id
+	}
+	if header.Namespace == "" {
+		header.Namespace = "/"
+	}
+
+	d := &decoder{
+		header: header,
+		data:   pkt.Data,
+		p:      p,
+	}
+
+	var eventName string
+	switch header.Type {
+	case parser.PacketTypeConnect:
+		if d.data != nil && !isMap(d.data) {
+			return errInvalidPayload
+		}
+	case parser.PacketTypeDisconnect:
+		if d.data != nil {
+			return errInvalidPayload
+		}
+	case parser.PacketTypeConnectError:
+		if d.data == nil || !(isMap(d.data) || isString(d.data)) {
+			return errInvalidPayload
+		}
+	default:
+		if d.data == nil || !isArray(d.data) {
+			return errInvalidPayload
+		}
+		err = newDecoder(d.data).Decode(&d.args)
+		if err != nil {
+			return fmt.Errorf("parser/msgpack: %w", err)
+		}
+		if header.IsEvent() {
+			if len(d.args) == 0 {
+				return errInvalidEventName
+			}
+			err = newDecoder(d.args[0]).Decode(&eventName)
+			if err != nil {
+				return errInvalidEventName
+			}
+			d.args = d.args[1:]
+		}
+	}
+
+	finish(header, eventName, d.decode)
+	return nil
+}
+
+func (d *decoder) decode(types ...reflect.Type) (values []reflect.Value, err error) {
+	if len(types) == 1 {
+		switch types[0] {
+		case argsType:
+			return d.decodeArgs()
+		case argsCountType:
+			return []reflect.Value{reflect.ValueOf(parser.ArgsCount(len(d.args)))}, nil
+		case decodeIntoType:
+			return []reflect.Value{reflect.ValueOf(parser.DecodeInto(d.decodeInto))}, nil
+		}
+	}
+
+	values = convertTypesToValues(types...)
+
+	if d.header.IsEvent() || d.header.IsAck() {
+		for i, rv := range values {
+			if i == len(d.args) {
+				break
+			}
+			err = d.decodeValue(d.args[i], rv)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+
+	if len(values) == 1 {
+		data := d.data
+		if data == nil {
+			data = emptyMap
+		}
+		err = d.decodeValue(data, values[0])
+		if err != nil {
+			return nil, err
+		}
+	} else if len(values) > 1 {
+		return nil, errInvalidPayload
+	}
+	return values, nil
+}
+
+// Decodes the arguments of the packet into the values that ptrs point to (see parser.DecodeInto).
+func (d *decoder) decodeInto(ptrs ...any) error {
+	if d.header.IsEvent() || d.header.IsAck() {
+		for i, ptr := range ptrs {
+			if i == len(d.args) {
+				break
+			}
+			err := d.decodeArg(d.args[i], ptr)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(ptrs) == 1 {
+		data := d.data
+		if data == nil {
+			data = emptyMap
+		}
+		return d.decodeArg(data, ptrs[0])
+	} else if len(ptrs) > 1 {
+		return errInvalidPayload
+	}
+	return nil
+}
+
+// Same as decodeValue, without a reflect.Value for the types that msgpack decodes as they are.
+func (d *decoder) decodeArg(data []byte, ptr any) error {
+	typ := reflect.TypeOf(ptr)
+	if typ == nil || typ.Kind() != reflect.Ptr {
+		return fmt.Errorf("parser/msgpack: non-pointer value of type %v", typ)
+	}
+	if containsRawMessage(typ) || (typ.Elem().Kind() == reflect.Interface && typ.Elem().NumMethod() == 0) {
+		return d.decodeValue(data, reflect.ValueOf(ptr))
+	}
+
+	err := newDecoder(data).Decode(ptr)
+	if err != nil {
+		return fmt.Errorf("parser/msgpack: %w", err)
+	}
+	return nil
+}
+
+// Decodes every argument of the packet into a parser.Args.
+// Binary data is decoded into []byte, see decodeAny for the other types.
+func (d *decoder) decodeArgs() (values []reflect.Value, err error) {
+	args := make(parser.Args, len(d.args))
+	for i, arg := range d.args {
+		args[i], err = decodeAny(arg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return []reflect.Value{reflect.ValueOf(args)}, nil
+}
+
+// Decodes into an any. The integers are decoded into int64 or uint64, and the floats
+// are decoded into float64 (as with the loose decoding of msgpack, which cannot be used here
+// since it decodes binary data into strings).
+func decodeAny(data []byte) (any, error) {
+	v, err := newDecoder(data).DecodeInterface()
+	if err != nil {
+		return nil, fmt.Errorf("parser/msgpack: %w", err)
+	}
+	return normalize(v), nil
+}
+
+func normalize(v any) any {
+	switch v := v.(type) {
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint8:
+		return uint64(v)
+	case uint16:
+		return uint64(v)
+	case uint32:
+		return uint64(v)
+	case float32:
+		return float64(v)
+	case []any:
+		for i := range v {
+			v[i] = normalize(v[i])
+		}
+	case map[string]any:
+		for k := range v {
+			v[k] = normalize(v[k])
+		}
+	}
+	return v
+}
+
+// rv must be a pointer.
+func (d *decoder) decodeValue(data []byte, rv reflect.Value) error {
+	if !rv.CanInterface() {
+		return fmt.Errorf("parser/msgpack: non-interfaceable value of type %s", rv.Type())
+	}
+
+	// json.RawMessage is a []byte, but it is meant to hold the value itself, not binary data.
+	// The value is decoded and re-encoded as JSON.
+	if containsRawMessage(rv.Type()) {
+		v, err := decodeAny(data)
+		if err != nil {
+			return err
+		}
+		b, err := d.p.json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		return d.p.json.Unmarshal(b, rv.Interface())
+	}
+
+	if rv.Type().Elem().Kind() == reflect.Interface && rv.Type().Elem().NumMethod() == 0 {
+		v, err := decodeAny(data)
+		if err != nil {
+			return err
+		}
+		if v != nil {
+			rv.Elem().Set(reflect.ValueOf(v))
+		}
+		return nil
+	}
+
+	err := newDecoder(data).Decode(rv.Interface())
+	if err != nil {
+		return fmt.Errorf("parser/msgpack: %w", err)
+	}
+	return nil
+}
+
+func newDecoder(data []byte) *msgpack.Decoder {
+	d := msgpack.NewDecoder(bytes.NewReader(data))
+	d.SetCustomStructTag("json")
+	return d
+}
+
+func convertTypesToValues(types ...reflect.Type) (values []reflect.Value) {
+	values = make([]reflect.Value, len(types))
+
+	for i, typ := range types {
+		if typ == nil {
+			var (
+				unused any
+				ptr    = &unused
+			)
+			typ = reflect.TypeOf(ptr)
+		}
+
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+
+		values[i] = reflect.New(typ)
+	}
+	return
+}
+
+func isMap(data []byte) bool {
+	c := data[0]
+	return msgpcode.IsFixedMap(c) || c == msgpcode.Map16 || c == msgpcode.Map32
+}
+
+func isArray(data []byte) bool {
+	c := data[0]
+	return msgpcode.IsFixedArray(c) || c == msgpcode.Array16 || c == msgpcode.Array32
+}
+
+func isString(data []byte) bool {
+	return msgpcode.IsString(data[0])
+}
+
+// Types that contain a json.RawMessage. The values are bool.
+var rawMessageTypes sync.Map
+
+func containsRawMessage(typ reflect.Type) bool {
+	if v, ok := rawMessageTypes.Load(typ); ok {
+		return v.(bool)
+	}
+	contains := _containsRawMessage(typ, make(map[reflect.Type]bool))
+	rawMessageTypes.Store(typ, contains)
+	return contains
+}
+
+func _containsRawMessage(typ reflect.Type, visited map[reflect.Type]bool) bool {
+	if typ == rawMessageType {
+		return true
+	}
+	if visited[typ] {
+		return false
+	}
+	visited[typ] = true
+
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return _containsRawMessage(typ.Elem(), visited)
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			if f.IsExported() && _containsRawMessage(f.Type, visited) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package msgpackparser
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"github.com/hhuuson97/socket.io-go/parser"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// The packet as it is encoded by socket.io-msgpack-parser.
+type packet struct {
+	Type parser.PacketType `msgpack:"type"`
+	Data any               `msgpack:"data,omitempty"`
+	Nsp  string            `msgpack:"nsp"`
+	ID   *uint64           `msgpack:"id,omitempty"`
+}
+
+// Encodes the packet into a single buffer.
+//
+// There are no binary packet types in this format, so a BINARY_EVENT or
+// a BINARY_ACK packet is encoded as an EVENT or an ACK. header is not modified.
+// The struct tags of encoding/json are used for the field names.
+func (p *Parser) Encode(header *parser.PacketHeader, v any) ([][]byte, error) {
+	typ := header.Type
+	switch typ {
+	case parser.PacketTypeBinaryEvent:
+		typ = parser.PacketTypeEvent
+	case parser.PacketTypeBinaryAck:
+		typ = parser.PacketTypeAck
+	}
+
+	pkt := packet{
+		Type: typ,
+		Nsp:  header.Namespace,
+		ID:   header.ID,
+	}
+	if pkt.Nsp == "" {
+		pkt.Nsp = "/"
+	}
+	if v != nil {
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Ptr || !rv.IsNil() {
+			pkt.Data = v
+		}
+	}
+
+	var buf bytes.Buffer
+	e := msgpack.NewEncoder(&buf)
+	e.SetCustomStructTag("json")
+	e.UseCompactInts(true)
+	err := e.Encode(&pkt)
+	if err != nil {
+		return nil, fmt.Errorf("parser/msgpack: %w", err)
+	}
+	return [][]byte{buf.Bytes()}, nil
+}
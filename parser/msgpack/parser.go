@@ -0,0 +1,36 @@
+// Package msgpackparser implements a parser that is compatible with
+// socket.io-msgpack-parser (https://github.com/socketio/socket.io-msgpack-parser).
+//
+// Every packet is encoded into a single MessagePack map, thus binary data
+// (any []byte value) is encoded natively without attachments.
+package msgpackparser
+
+import (
+	"github.com/hhuuson97/socket.io-go/parser"
+	"github.com/hhuuson97/socket.io-go/parser/json/serializer"
+	"github.com/hhuuson97/socket.io-go/parser/json/serializer/stdjson"
+)
+
+func NewCreator() parser.Creator {
+	json := stdjson.New()
+	return func() parser.Parser {
+		return &Parser{json: json}
+	}
+}
+
+type Parser struct {
+	// Used for the json.RawMessage values (such as the authentication data).
+	json serializer.JSONSerializer
+}
+
+var _ parser.BinaryEncoder = (*Parser)(nil)
+
+// Every packet is a single binary message.
+func (p *Parser) EncodesBinary() bool { return true }
+
+// Every packet is a single message, so there is nothing to reset.
+func (p *Parser) Reset() {}
+
+func (p *Parser) JSONSerializer() serializer.JSONSerializer {
+	return p.json
+}
@@ -0,0 +1,170 @@
+package msgpackparser
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/hhuuson97/socket.io-go/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestEncode(t *testing.T) {
+	p := NewCreator()()
+	require.True(t, parser.EncodesBinary(p))
+
+	t.Run("should encode an event with binary data natively", func(t *testing.T) {
+		id := uint64(7)
+		header := &parser.PacketHeader{Type: parser.PacketTypeEvent, Namespace: "/chat", ID: &id}
+		v := []any{"hello", []byte{1, 2, 3}, map[string]any{"n": 1}}
+		buffers, err := p.Encode(header, &v)
+		require.NoError(t, err)
+		require.Len(t, buffers, 1)
+		assert.Equal(t, parser.PacketTypeEvent, header.Type)
+		assert.Equal(t, 0, header.Attachments)
+
+		var m map[string]any
+		require.NoError(t, msgpack.Unmarshal(buffers[0], &m))
+		assert.Equal(t, map[string]any{
+			"type": int8(2),
+			"nsp":  "/chat",
+			"id":   int8(7),
+			"data": []any{"hello", []byte{1, 2, 3}, map[string]any{"n": int8(1)}},
+		}, m)
+	})
+
+	t.Run("should encode the binary packet types without modifying the header", func(t *testing.T) {
+		id := uint64(1)
+		header := &parser.PacketHeader{Type: parser.PacketTypeBinaryAck, Namespace: "/", ID: &id, Attachments: 1}
+		v := []any{[]byte{1}}
+		buffers, err := p.Encode(header, &v)
+		require.NoError(t, err)
+		assert.Equal(t, &parser.PacketHeader{Type: parser.PacketTypeBinaryAck, Namespace: "/", ID: &id, Attachments: 1}, header)
+
+		var m map[string]any
+		require.NoError(t, msgpack.Unmarshal(buffers[0], &m))
+		assert.Equal(t, int8(parser.PacketTypeAck), m["type"])
+	})
+
+	t.Run("should omit the data and use the JSON field names", func(t *testing.T) {
+		buffers, err := p.Encode(&parser.PacketHeader{Type: parser.PacketTypeDisconnect}, nil)
+		require.NoError(t, err)
+		var m map[string]any
+		require.NoError(t, msgpack.Unmarshal(buffers[0], &m))
+		assert.Equal(t, map[string]any{"type": int8(1), "nsp": "/"}, m)
+
+		v := struct {
+			SID string `json:"sid"`
+			PID string `json:"pid,omitempty"`
+		}{SID: "abc"}
+		buffers, err = p.Encode(&parser.PacketHeader{Type: parser.PacketTypeConnect, Namespace: "/"}, &v)
+		require.NoError(t, err)
+		require.NoError(t, msgpack.Unmarshal(buffers[0], &m))
+		assert.Equal(t, map[string]any{"sid": "abc"}, m["data"])
+	})
+}
+
+func TestDecode(t *testing.T) {
+	encode := func(t *testing.T, v any) []byte {
+		data, err := msgpack.Marshal(v)
+		require.NoError(t, err)
+		return data
+	}
+
+	add := func(t *testing.T, data []byte) (header *parser.PacketHeader, eventName string, decode parser.Decode) {
+		p := NewCreator()()
+		called := false
+		err := p.Add(data, func(h *parser.PacketHeader, e string, d parser.Decode) {
+			header, eventName, decode, called = h, e, d, true
+		})
+		require.NoError(t, err)
+		require.True(t, called)
+		return
+	}
+
+	t.Run("should decode an event", func(t *testing.T) {
+		data := encode(t, map[string]any{
+			"type": 2,
+			"nsp":  "/",
+			"id":   3,
+			"data": []any{"hello", "world", []byte{1, 2}, map[string]any{"name": "alice"}},
+		})
+		header, eventName, decode := add(t, data)
+		assert.Equal(t, parser.PacketTypeEvent, header.Type)
+		assert.Equal(t, "/", header.Namespace)
+		require.NotNil(t, header.ID)
+		assert.Equal(t, uint64(3), *header.ID)
+		assert.Equal(t, "hello", eventName)
+
+		type user struct {
+			Name string `json:"name"`
+		}
+		values, err := decode(reflect.TypeOf(""), reflect.TypeOf([]byte(nil)), reflect.TypeOf(&user{}))
+		require.NoError(t, err)
+		require.Len(t, values, 3)
+		assert.Equal(t, "world", values[0].Elem().Interface())
+		assert.Equal(t, []byte{1, 2}, values[1].Elem().Interface())
+		assert.Equal(t, &user{Name: "alice"}, values[2].Interface())
+
+		values, err = decode(reflect.TypeOf(parser.Args(nil)))
+		require.NoError(t, err)
+		require.Len(t, values, 1)
+		assert.Equal(t, parser.Args{"world", []byte{1, 2}, map[string]any{"name": "alice"}}, values[0].Interface())
+
+		values, err = decode(reflect.TypeOf(parser.ArgsCount(0)))
+		require.NoError(t, err)
+		require.Len(t, values, 1)
+		assert.Equal(t, parser.ArgsCount(3), values[0].Interface())
+
+		values, err = decode(reflect.TypeOf(parser.DecodeInto(nil)))
+		require.NoError(t, err)
+		require.Len(t, values, 1)
+		var (
+			s       string
+			b       []byte
+			u       *user
+			missing = "missing"
+		)
+		err = values[0].Interface().(parser.DecodeInto)(&s, &b, &u, &missing)
+		require.NoError(t, err)
+		assert.Equal(t, "world", s)
+		assert.Equal(t, []byte{1, 2}, b)
+		assert.Equal(t, &user{Name: "alice"}, u)
+		assert.Equal(t, "missing", missing)
+	})
+
+	t.Run("should decode the authentication data into a json.RawMessage", func(t *testing.T) {
+		data := encode(t, map[string]any{"type": 0, "nsp": "/", "data": map[string]any{"token": "123"}})
+		_, _, decode := add(t, data)
+		values, err := decode(reflect.TypeOf(&json.RawMessage{}))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"token":"123"}`, string(*values[0].Interface().(*json.RawMessage)))
+
+		data = encode(t, map[string]any{"type": 0, "nsp": "/"})
+		_, _, decode = add(t, data)
+		values, err = decode(reflect.TypeOf(&json.RawMessage{}))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{}`, string(*values[0].Interface().(*json.RawMessage)))
+	})
+
+	t.Run("should reject invalid packets", func(t *testing.T) {
+		p := NewCreator()()
+		finish := func(*parser.PacketHeader, string, parser.Decode) { t.Error("finish must not be called") }
+
+		for _, v := range []map[string]any{
+			{"type": 5, "nsp": "/", "data": []any{"hello"}},
+			{"type": "2", "nsp": "/", "data": []any{"hello"}},
+			{"type": 2, "data": []any{"hello"}},
+			{"type": 2, "nsp": "/", "data": "hello"},
+			{"type": 2, "nsp": "/", "data": []any{1}},
+			{"type": 1, "nsp": "/", "data": "bye"},
+			{"type": 0, "nsp": "/", "data": "auth"},
+			{"type": 3, "nsp": "/", "id": -1, "data": []any{}},
+		} {
+			require.Error(t, p.Add(encode(t, v), finish), v)
+		}
+		require.Error(t, p.Add([]byte("2[\"hello\"]"), finish))
+	})
+}
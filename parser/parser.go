@@ -1,8 +1,10 @@
 package parser
 
 import (
-	"github.com/hhuuson97/socket.io-go/parser/json/serializer"
+	"fmt"
 	"reflect"
+
+	"github.com/hhuuson97/socket.io-go/parser/json/serializer"
 )
 
 const ProtocolVersion = 5
@@ -13,9 +15,59 @@ type (
 	Decode  func(types ...reflect.Type) (values []reflect.Value, err error)
 )
 
+// When the type of Args is the only type given to Decode, every argument
+// of the packet is decoded (as if it was decoded into an any),
+// and a single value of type Args is returned.
+//
+// Binary data is decoded into the binary type of the parser.
+//
+// This is for when the number and the types of the arguments are not known.
+type Args []any
+
+// When the type of ArgsCount is the only type given to Decode, the arguments
+// of the packet are counted without being decoded, and a single value of type ArgsCount is returned.
+//
+// This is for when the types to decode into depend on the number of the arguments.
+type ArgsCount int
+
+// When the type of DecodeInto is the only type given to Decode, a single value of type DecodeInto is returned.
+// It decodes the arguments of the packet into the values that ptrs point to,
+// without a reflect.Value for each argument. The values of the missing arguments are left as they are.
+//
+// This is for when the types of the arguments are known at compile time.
+type DecodeInto func(ptrs ...any) error
+
 type Parser interface {
 	Encode(header *PacketHeader, v any) (buffers [][]byte, err error)
 	Add(data []byte, finish Finish) error
 	Reset()
 	JSONSerializer() serializer.JSONSerializer
 }
+
+// Implemented by the parsers whose packets are binary (such as parser/msgpack).
+//
+// By default, the first buffer returned by Encode is sent as a text message,
+// and the rest of the buffers (the binary attachments) are sent as binary messages.
+type BinaryEncoder interface {
+	// Whether every buffer returned by Encode is to be sent as a binary message.
+	EncodesBinary() bool
+}
+
+// Whether every buffer encoded by p is to be sent as a binary message (see BinaryEncoder).
+func EncodesBinary(p Parser) bool {
+	e, ok := p.(BinaryEncoder)
+	return ok && e.EncodesBinary()
+}
+
+// Returned by Parser.Add when a packet exceeds a limit of the parser
+// (e.g. its size or the number of its arguments).
+// The connection that sent the packet is closed with the reason "parse error".
+type LimitError struct {
+	// The name of the limit, e.g. "payload size".
+	Limit string
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("parser: %s exceeds the limit of %d", e.Limit, e.Max)
+}
@@ -1,12 +1,15 @@
 package sio
 
 import (
-	"fmt"
+	"context"
+	"encoding/json"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/hhuuson97/socket.io-go/adapter"
 	eio "github.com/hhuuson97/socket.io-go/engine.io"
+	"github.com/hhuuson97/socket.io-go/internal/sync"
 	"github.com/hhuuson97/socket.io-go/parser"
 	jsonparser "github.com/hhuuson97/socket.io-go/parser/json"
 	"github.com/hhuuson97/socket.io-go/parser/json/serializer/stdjson"
@@ -43,6 +46,17 @@ type (
 		// Default: false
 		AcceptAnyNamespace bool
 
+		// Whether to remove a child namespace of a dynamic namespace (see Server.OfDynamic)
+		// when its last socket disconnects. The child namespace is created again
+		// when a client connects to it.
+		//
+		// A child namespace that has no socket after a connection was rejected
+		// (such as by a middleware) is always removed, so that the clients cannot
+		// create child namespaces without being allowed to connect to them.
+		//
+		// Default: false
+		CleanupEmptyChildNamespaces bool
+
 		ServerConnectionStateRecovery ServerConnectionStateRecovery
 
 		// For debugging purposes. Leave it nil if it is of no use.
@@ -75,8 +89,15 @@ type (
 		eio        *eio.Server
 		namespaces *nspStore
 
-		connectTimeout     time.Duration
-		acceptAnyNamespace bool
+		parentNamespaces   []*ParentNamespace
+		parentNamespacesMu sync.Mutex
+
+		connectTimeout              time.Duration
+		acceptAnyNamespace          bool
+		cleanupEmptyChildNamespaces bool
+
+		// Set by Close and Shutdown in order to reject the connections to namespaces.
+		shuttingDown atomic.Bool
 
 		connectionStateRecovery ServerConnectionStateRecovery
 
@@ -93,13 +114,14 @@ func NewServer(config *ServerConfig) *Server {
 	}
 
 	server := &Server{
-		parserCreator:           config.ParserCreator,
-		adapterCreator:          config.AdapterCreator,
-		namespaces:              newNspStore(),
-		acceptAnyNamespace:      config.AcceptAnyNamespace,
-		connectionStateRecovery: config.ServerConnectionStateRecovery,
-		newNamespaceHandlers:    newHandlerStore[*ServerNewNamespaceFunc](),
-		anyConnectionHandlers:   newHandlerStore[*ServerAnyConnectionFunc](),
+		parserCreator:               config.ParserCreator,
+		adapterCreator:              config.AdapterCreator,
+		namespaces:                  newNspStore(),
+		acceptAnyNamespace:          config.AcceptAnyNamespace,
+		cleanupEmptyChildNamespaces: config.CleanupEmptyChildNamespaces,
+		connectionStateRecovery:     config.ServerConnectionStateRecovery,
+		newNamespaceHandlers:        newHandlerStore[*ServerNewNamespaceFunc](),
+		anyConnectionHandlers:       newHandlerStore[*ServerAnyConnectionFunc](),
 	}
 
 	if config.Debugger != nil {
@@ -139,7 +161,11 @@ func NewServer(config *ServerConfig) *Server {
 }
 
 func (s *Server) onEIOSocket(eioSocket eio.ServerSocket) *eio.Callbacks {
-	_, callbacks := newServerConn(s, eioSocket, s.parserCreator)
+	c, callbacks := newServerConn(s, eioSocket, s.parserCreator)
+	// Socket.IO v2 clients are connected to the main namespace upon connection.
+	if c.eio3() {
+		go c.connectNamespace("/", json.RawMessage("{}"))
+	}
 	return callbacks
 }
 
@@ -147,13 +173,66 @@ func (s *Server) Of(namespace string) *Namespace {
 	if len(namespace) == 0 || (len(namespace) != 0 && namespace[0] != '/') {
 		namespace = "/" + namespace
 	}
-	n, created := s.namespaces.getOrCreate(namespace, s, s.adapterCreator, s.parserCreator)
+	n, created := s.namespaces.getOrCreate(namespace, s, s.adapterCreator, s.parserCreator, nil)
 	if created && namespace != "/" {
 		s.newNamespaceHandlers.forEach(func(handler *ServerNewNamespaceFunc) { (*handler)(n) }, true)
 	}
 	return n
 }
 
+// Closes the namespace with the given name, if it exists. See Namespace.Close.
+func (s *Server) RemoveNamespace(namespace string) {
+	if len(namespace) == 0 || namespace[0] != '/' {
+		namespace = "/" + namespace
+	}
+	n, ok := s.namespaces.get(namespace)
+	if ok {
+		n.Close()
+	}
+}
+
+// Creates a group of dynamic namespaces. When a client connects to a namespace
+// that was not created with Of, and whose name is accepted by matcher,
+// the namespace is created as a child of the returned ParentNamespace.
+//
+// matcher must be either a *regexp.Regexp, which is matched against the name of the namespace,
+// or a func(name string, auth json.RawMessage) bool (see NamespaceMatcherFunc).
+//
+// The groups are checked in the order they were created.
+func (s *Server) OfDynamic(matcher any) *ParentNamespace {
+	f, err := toNamespaceMatcherFunc(matcher)
+	if err != nil {
+		panic(err)
+	}
+	p := newParentNamespace(s, f)
+	s.parentNamespacesMu.Lock()
+	s.parentNamespaces = append(s.parentNamespaces, p)
+	s.parentNamespacesMu.Unlock()
+	return p
+}
+
+// Creates the namespace if it is accepted by one of the parent namespaces.
+// If the namespace has a parent, a connection to it is begun (see ParentNamespace.beginConnect).
+func (s *Server) getOrCreateDynamicNamespace(name string, auth json.RawMessage) (nsp *Namespace, ok bool) {
+	s.parentNamespacesMu.Lock()
+	parents := make([]*ParentNamespace, len(s.parentNamespaces))
+	copy(parents, s.parentNamespaces)
+	s.parentNamespacesMu.Unlock()
+
+	for _, p := range parents {
+		if !p.matcher(name, auth) {
+			continue
+		}
+		nsp, created := p.getOrCreateChild(name)
+		if created {
+			// Not concurrent: the handlers can set up the namespace before the socket connects.
+			s.newNamespaceHandlers.forEach(func(handler *ServerNewNamespaceFunc) { (*handler)(nsp) }, false)
+		}
+		return nsp, true
+	}
+	return nil, false
+}
+
 // Alias of: s.Of("/").Use(...)
 func (s *Server) Use(f NspMiddlewareFunc) {
 	s.Of("/").Use(f)
@@ -220,6 +299,15 @@ func (s *Server) Local() *BroadcastOperator {
 	return s.Of("/").Local()
 }
 
+// Sets a modifier for a subsequent event emission that the acknowledgement function
+// will be called with an error when the given duration has elapsed without
+// every client having acknowledged the event.
+//
+// Alias of: s.Of("/").Timeout(...)
+func (s *Server) Timeout(timeout time.Duration) *BroadcastOperator {
+	return s.Of("/").Timeout(timeout)
+}
+
 // Gets the sockets of the namespace.
 // Beware that this is local to the current node. For sockets across all nodes, use FetchSockets
 //
@@ -263,6 +351,13 @@ func (s *Server) ServerSideEmit(eventName string, v ...any) {
 	s.Of("/").ServerSideEmit(eventName, v...)
 }
 
+// Sends a message to the other Socket.IO servers of the cluster and waits for their acknowledgements.
+//
+// Alias of: s.Of("/").ServerSideEmitWithAck(...)
+func (s *Server) ServerSideEmitWithAck(timeout time.Duration, eventName string, v ...any) {
+	s.Of("/").ServerSideEmitWithAck(timeout, eventName, v...)
+}
+
 // Start the server.
 func (s *Server) Run() error {
 	return s.eio.Run()
@@ -285,11 +380,93 @@ func (s *Server) IsClosed() bool {
 }
 
 // Shut down the server. Server cannot be restarted once it is closed.
+//
+// The sockets of every namespace are closed, then the adapters of the namespaces are closed.
+// Packets that are not sent yet might be lost, use Shutdown to wait for them.
 func (s *Server) Close() error {
-	fmt.Print("io server shutdown")
-	for _, _socket := range s.Sockets() {
-		socket := _socket.(*serverSocket)
-		socket.onClose(ReasonServerShuttingDown)
+	s.shuttingDown.Store(true)
+	nsps := s.namespaces.getAll()
+	for _, nsp := range nsps {
+		for _, _socket := range nsp.Sockets() {
+			socket := _socket.(*serverSocket)
+			socket.onClose(ReasonServerShuttingDown)
+		}
+	}
+	for _, nsp := range nsps {
+		nsp.adapter.Close()
 	}
 	return s.eio.Close()
 }
+
+// Shuts down the server gracefully. Server cannot be restarted once it is shut down.
+//
+// New connections are rejected, and the sockets of every namespace are disconnected
+// with ReasonServerShuttingDown (their sessions are persisted if connection state recovery is enabled).
+// Then Shutdown waits for the queued packets to be sent before closing the adapters and the connections.
+//
+// If ctx is done before that, the server is closed right away and ctx.Err() is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.shuttingDown.Store(true)
+	s.eio.StopHandshakes()
+
+	var (
+		nsps  = s.namespaces.getAll()
+		conns = make(map[*serverConn]struct{})
+		wg    sync.WaitGroup
+	)
+	for _, nsp := range nsps {
+		for _, _socket := range nsp.Sockets() {
+			socket := _socket.(*serverSocket)
+			conns[socket.conn] = struct{}{}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				socket.onClose(ReasonServerShuttingDown)
+			}()
+		}
+	}
+
+	err := waitContext(ctx, &wg)
+	if err == nil {
+		// The disconnecting handlers might have sent packets as well.
+		timeout := 2 * time.Minute
+		if deadline, ok := ctx.Deadline(); ok {
+			timeout = time.Until(deadline)
+		}
+		for conn := range conns {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				conn.eioPacketQueue.waitForDrain(timeout)
+			}()
+		}
+		err = waitContext(ctx, &wg)
+	}
+	if err == nil {
+		// The packets might still be waiting to be polled by the clients.
+		err = s.eio.WaitForDrain(ctx)
+	}
+
+	for _, nsp := range nsps {
+		nsp.adapter.Close()
+	}
+	closeErr := s.eio.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+func waitContext(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"reflect"
 	"time"
 
@@ -82,7 +83,12 @@ func (c *serverConn) onEIOPacket(packets ...*eioparser.Packet) {
 		if packet.Type == eioparser.PacketTypeMessage {
 			err := c.parser.Add(packet.Data, c.onParserFinish)
 			if err != nil {
-				c.onFatalError(wrapInternalError(err))
+				var limitErr *parser.LimitError
+				if errors.As(err, &limitErr) {
+					go c.onParseError(err)
+				} else {
+					c.onFatalError(wrapInternalError(err))
+				}
 				return
 			}
 		}
@@ -111,26 +117,10 @@ func (c *serverConn) onParserFinish(header *parser.PacketHeader, eventName strin
 }
 
 func (c *serverConn) connect(header *parser.PacketHeader, decode parser.Decode) {
-	var (
-		nsp *Namespace
-		ok  bool
-	)
-
-	if c.server.acceptAnyNamespace {
-		nsp, _ = c.server.namespaces.getOrCreate(
-			header.Namespace,
-			c.server,
-			c.server.adapterCreator,
-			c.server.parserCreator,
-		)
-	} else {
-		nsp, ok = c.server.namespaces.get(header.Namespace)
-		if !ok {
-			c.connectError(fmt.Errorf("sio: namespace '%s' was not created and AcceptAnyNamespace was not set", header.Namespace), header.Namespace)
-			return
-		}
+	if c.server.shuttingDown.Load() {
+		c.connectError(fmt.Errorf("sio: server is shutting down"), header.Namespace)
+		return
 	}
-	c.debug.Log("Connecting to namespace", nsp.name)
 
 	var auth json.RawMessage
 	at := reflect.TypeOf(&auth)
@@ -147,6 +137,64 @@ func (c *serverConn) connect(header *parser.PacketHeader, decode parser.Decode)
 		}
 	}
 
+	name := header.Namespace
+	if c.eio3() {
+		name, auth, err = parseNamespaceQuery(name)
+		if err != nil {
+			c.onFatalError(wrapInternalError(err))
+			return
+		}
+	}
+	c.connectNamespace(name, auth)
+}
+
+// Socket.IO v2 clients send the query of a namespace with its name (e.g. "/admin?token=abc").
+// The query is used as the auth data, as it is done by the original Socket.IO.
+func parseNamespaceQuery(nsp string) (name string, auth json.RawMessage, err error) {
+	u, err := url.Parse(nsp)
+	if err != nil {
+		return "", nil, err
+	}
+
+	query := make(map[string]any)
+	for key, values := range u.Query() {
+		if len(values) == 1 {
+			query[key] = values[0]
+		} else {
+			query[key] = values
+		}
+	}
+	auth, err = json.Marshal(query)
+	return u.Path, auth, err
+}
+
+func (c *serverConn) connectNamespace(name string, auth json.RawMessage) {
+	nsp, ok := c.server.namespaces.get(name)
+	if ok && nsp.parent != nil && !nsp.parent.beginConnect(nsp) {
+		// The child namespace has just been removed, it is created again.
+		ok = false
+	}
+	if !ok {
+		nsp, ok = c.server.getOrCreateDynamicNamespace(name, auth)
+	}
+	if !ok {
+		if !c.server.acceptAnyNamespace {
+			c.connectError(fmt.Errorf("sio: namespace '%s' was not created and AcceptAnyNamespace was not set", name), name)
+			return
+		}
+		nsp, _ = c.server.namespaces.getOrCreate(
+			name,
+			c.server,
+			c.server.adapterCreator,
+			c.server.parserCreator,
+			nil,
+		)
+	}
+	if nsp.parent != nil {
+		defer nsp.parent.endConnect(nsp)
+	}
+	c.debug.Log("Connecting to namespace", nsp.name)
+
 	socket, err := nsp.add(c, auth)
 	if err != nil {
 		c.debug.Log("Connection to namespace", nsp.name, "was denied")
@@ -168,16 +216,20 @@ func (c *serverConn) connectError(message any, nsp string) {
 	if ok {
 		message = err.Error()
 	}
-	e := &connectError{
+	var v any = &connectError{
 		Message: message,
 	}
+	// Socket.IO v2 clients expect the message itself.
+	if c.eio3() {
+		v = &message
+	}
 
 	header := parser.PacketHeader{
 		Type:      parser.PacketTypeConnectError,
 		Namespace: nsp,
 	}
 
-	buffers, err := c.parser.Encode(&header, e)
+	buffers, err := c.parser.Encode(&header, v)
 	if err != nil {
 		c.onFatalError(wrapInternalError(err))
 		return
@@ -186,6 +238,16 @@ func (c *serverConn) connectError(message any, nsp string) {
 	c.sendBuffers(buffers...)
 }
 
+// Whether the client is a Socket.IO v2 client. These clients use the protocol revision 3
+// of Engine.IO (see eio.ServerConfig.AllowEIO3) and the protocol revision 4 of Socket.IO:
+//
+//   - They are connected to the main namespace without sending a CONNECT packet.
+//   - The CONNECT packet of the server has no payload, and the ID of a socket is derived from the Engine.IO session ID.
+//   - The payload of a CONNECT_ERROR packet is the error message.
+func (c *serverConn) eio3() bool {
+	return c.eio.ProtocolVersion() == 3
+}
+
 func (c *serverConn) sendBuffers(buffers ...[]byte) {
 	if len(buffers) > 0 {
 		packets := make([]*eioparser.Packet, len(buffers))
@@ -193,7 +255,7 @@ func (c *serverConn) sendBuffers(buffers ...[]byte) {
 		buffers = buffers[1:]
 
 		var err error
-		packets[0], err = eioparser.NewPacket(eioparser.PacketTypeMessage, false, buf)
+		packets[0], err = eioparser.NewPacket(eioparser.PacketTypeMessage, parser.EncodesBinary(c.parser), buf)
 		if err != nil {
 			c.onFatalError(wrapInternalError(err))
 			return
@@ -230,6 +292,13 @@ func (c *serverConn) onFatalError(err error) {
 	go c.eio.Close()
 }
 
+// Closes the connection with ReasonParseError.
+func (c *serverConn) onParseError(err error) {
+	c.onError(err)
+	c.onClose(ReasonParseError, err)
+	c.eio.Close()
+}
+
 func (c *serverConn) onClose(reason Reason, err error) {
 	// Server connection is one-time, it cannot be reconnected.
 	// We don't want it to close more than once,
@@ -1,6 +1,7 @@
 package sio
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"time"
@@ -23,10 +24,12 @@ type serverSocket struct {
 	connected   bool
 	connectedMu sync.RWMutex
 
-	server  *Server
-	conn    *serverConn
-	nsp     *Namespace
-	adapter adapter.Adapter
+	server    *Server
+	conn      *serverConn
+	nsp       *Namespace
+	adapter   adapter.Adapter
+	handshake *Handshake
+	data      *SocketData
 
 	parser parser.Parser
 
@@ -46,6 +49,8 @@ type serverSocket struct {
 	errorHandlers         *handlerStore[*ServerSocketErrorFunc]
 	disconnectingHandlers *handlerStore[*ServerSocketDisconnectingFunc]
 	disconnectHandlers    *handlerStore[*ServerSocketDisconnectFunc]
+	anyEventHandlers      *handlerStore[*AnyEventFunc]
+	anyOutgoingHandlers   *handlerStore[*AnyEventFunc]
 }
 
 // previousSession can be nil
@@ -63,6 +68,7 @@ func newServerSocket(
 		nsp:     nsp,
 		adapter: _adapter,
 		parser:  parser,
+		data:    adapter.NewSocketData(),
 		acks:    make(map[uint64]*ackHandler),
 		debug:   server.debug.WithContext("[sio/server] Socket (nsp: `" + nsp.Name() + "`)"),
 
@@ -70,6 +76,8 @@ func newServerSocket(
 		errorHandlers:         newHandlerStore[*ServerSocketErrorFunc](),
 		disconnectingHandlers: newHandlerStore[*ServerSocketDisconnectingFunc](),
 		disconnectHandlers:    newHandlerStore[*ServerSocketDisconnectFunc](),
+		anyEventHandlers:      newHandlerStore[*AnyEventFunc](),
+		anyOutgoingHandlers:   newHandlerStore[*AnyEventFunc](),
 	}
 
 	s.join = func(room ...Room) {
@@ -81,6 +89,9 @@ func newServerSocket(
 		s.id = previousSession.SID
 		s.pid = previousSession.PID
 		s.recovered = true
+		if previousSession.Data != nil {
+			s.data = previousSession.Data
+		}
 		s.Join(previousSession.Rooms...)
 		for _, missedPacket := range previousSession.MissedPackets {
 			if missedPacket.EncodedData != nil {
@@ -93,6 +104,12 @@ func newServerSocket(
 				s.conn.sendBuffers(buffers...)
 			}
 		}
+	} else if c.eio3() {
+		// As in Socket.IO v2, the ID is derived from the Engine.IO session ID.
+		s.id = SocketID(c.eio.ID())
+		if nsp.Name() != "/" {
+			s.id = SocketID(nsp.Name() + "#" + c.eio.ID())
+		}
 	} else {
 		id, err := eio.GenerateBase64ID(eio.Base64IDSize)
 		if err != nil {
@@ -119,6 +136,10 @@ func (s *serverSocket) Namespace() *Namespace { return s.nsp }
 
 func (s *serverSocket) Recovered() bool { return s.recovered }
 
+func (s *serverSocket) Handshake() *Handshake { return s.handshake }
+
+func (s *serverSocket) Data() *SocketData { return s.data }
+
 func (s *serverSocket) Connected() bool {
 	s.connectedMu.RLock()
 	defer s.connectedMu.RUnlock()
@@ -146,8 +167,16 @@ func (s *serverSocket) onPacket(header *parser.PacketHeader, eventName string, d
 			s.sendAckPacket(ackID, values)
 		}
 
+		err := callAnyEventHandlers(s.anyEventHandlers, eventName, decode)
+		if err != nil {
+			s.onError(wrapInternalError(err))
+		}
+
 		for _, handler := range s.eventHandlers.getAll(eventName) {
-			s.onEvent(handler, header, decode, sendAck)
+			s.onEvent(handler, header, eventName, decode, sendAck)
+		}
+		for _, handler := range s.eventHandlers.getAllPatterns(eventName) {
+			s.onEvent(handler, header, eventName, decode, sendAck)
 		}
 	case parser.PacketTypeAck, parser.PacketTypeBinaryAck:
 		s.onAck(header, decode)
@@ -168,23 +197,17 @@ func (s *serverSocket) onDisconnect() {
 func (s *serverSocket) onEvent(
 	handler *eventHandler,
 	header *parser.PacketHeader,
+	eventName string,
 	decode parser.Decode,
 	sendAck ackSendFunc,
 ) (hasAckFunc bool) {
-	values, err := decode(handler.inputArgs...)
-	if err != nil {
-		s.onError(wrapInternalError(err))
-		return
+	if handler.decodeTyped != nil {
+		return s.onTypedEvent(handler, header, decode, sendAck)
 	}
 
-	if len(values) == len(handler.inputArgs) {
-		for i, v := range values {
-			if handler.inputArgs[i].Kind() != reflect.Ptr && v.Kind() == reflect.Ptr {
-				values[i] = v.Elem()
-			}
-		}
-	} else {
-		s.onError(fmt.Errorf("sio: onEvent: invalid number of arguments"))
+	values, err := handler.decodeArgs(decode)
+	if err != nil {
+		s.onError(wrapInternalError(err))
 		return
 	}
 
@@ -199,6 +222,10 @@ func (s *serverSocket) onEvent(
 		return
 	}
 
+	if handler.hasEventName {
+		values = append([]reflect.Value{reflect.ValueOf(eventName).Convert(handler.inputArgs[0])}, values...)
+	}
+
 	ack, _ := handler.ack()
 	if header.ID != nil && ack {
 		hasAckFunc = true
@@ -210,6 +237,9 @@ func (s *serverSocket) onEvent(
 		rt := reflect.FuncOf(in, nil, variadic)
 
 		f = reflect.MakeFunc(rt, func(args []reflect.Value) (results []reflect.Value) {
+			if variadic {
+				args = expandVariadicArgs(args)
+			}
 			sendAck(*header.ID, args)
 			return nil
 		})
@@ -224,6 +254,45 @@ func (s *serverSocket) onEvent(
 	return
 }
 
+// Same as onEvent, for the handlers registered with On and OnAck.
+func (s *serverSocket) onTypedEvent(
+	handler *eventHandler,
+	header *parser.PacketHeader,
+	decode parser.Decode,
+	sendAck ackSendFunc,
+) (hasAckFunc bool) {
+	event, err := handler.decodeTyped(decode)
+	if err != nil {
+		s.onError(wrapInternalError(err))
+		return
+	}
+
+	if s.hasMiddlewares() {
+		err = s.callMiddlewares(event.values())
+		if err != nil {
+			s.onError(err)
+			return
+		}
+	}
+
+	if !s.Connected() {
+		s.debug.Log("ignore packet received after disconnection")
+		return
+	}
+
+	var typedSendAck func(args []reflect.Value)
+	ack, _ := handler.ack()
+	if header.ID != nil && ack {
+		hasAckFunc = true
+		typedSendAck = func(args []reflect.Value) { sendAck(*header.ID, args) }
+	}
+	err = handler.callTyped(event, typedSendAck)
+	if err != nil {
+		s.onError(wrapInternalError(err))
+	}
+	return
+}
+
 func (s *serverSocket) onAck(header *parser.PacketHeader, decode parser.Decode) {
 	if header.ID == nil {
 		s.onError(wrapInternalError(fmt.Errorf("header.ID is nil")))
@@ -244,24 +313,17 @@ func (s *serverSocket) onAck(header *parser.PacketHeader, decode parser.Decode)
 		return
 	}
 
-	inputArgs := ack.inputArgs
-	if ack.hasError {
-		inputArgs = inputArgs[1:]
-	}
-	values, err := decode(inputArgs...)
-	if err != nil {
-		s.onError(wrapInternalError(err))
+	if ack.decodeLater {
+		err := ack.call(reflect.ValueOf(decode))
+		if err != nil {
+			s.onError(wrapInternalError(err))
+		}
 		return
 	}
 
-	if len(values) == len(inputArgs) {
-		for i, v := range values {
-			if inputArgs[i].Kind() != reflect.Ptr && v.Kind() == reflect.Ptr {
-				values[i] = v.Elem()
-			}
-		}
-	} else {
-		s.onError(fmt.Errorf("sio: onEvent: invalid number of arguments"))
+	values, err := ack.decodeArgs(decode)
+	if err != nil {
+		s.onError(wrapInternalError(err))
 		return
 	}
 
@@ -329,12 +391,15 @@ func (s *serverSocket) onConnect() error {
 	// Socket ID is the default room a socket joins to.
 	s.Join(Room(s.ID()))
 
-	c := sidInfo{
-		SID: string(s.ID()),
-		PID: string(s.pid),
+	if s.conn.eio3() {
+		s.sendControlPacket(parser.PacketTypeConnect, nil)
+	} else {
+		c := sidInfo{
+			SID: string(s.ID()),
+			PID: string(s.pid),
+		}
+		s.sendControlPacket(parser.PacketTypeConnect, &c)
 	}
-
-	s.sendControlPacket(parser.PacketTypeConnect, &c)
 	s.connected = true
 	return nil
 }
@@ -364,7 +429,7 @@ func (s *serverSocket) onClose(reason Reason) {
 			}()
 		}, false)
 
-		if s.server.connectionStateRecovery.Enabled && recoverableDisconnectReasons.Contains(reason) {
+		if s.server.connectionStateRecovery.Enabled && !s.conn.eio3() && recoverableDisconnectReasons.Contains(reason) {
 			s.debug.Log("Connection state recovery is enabled")
 			rooms, ok := s.adapter.SocketRooms(s.ID())
 			if !ok {
@@ -374,6 +439,7 @@ func (s *serverSocket) onClose(reason Reason) {
 				SID:   s.ID(),
 				PID:   s.pid,
 				Rooms: rooms.ToSlice(),
+				Data:  s.data,
 			})
 		}
 
@@ -410,7 +476,7 @@ func (s *serverSocket) emit(
 	eventName string,
 	timeout time.Duration,
 	volatile, fromQueue bool,
-	_v ...any) {
+	_v ...any) (ackID *uint64) {
 	header := &parser.PacketHeader{
 		Type:      parser.PacketTypeEvent,
 		Namespace: s.nsp.Name(),
@@ -435,6 +501,8 @@ func (s *serverSocket) emit(
 		v = v[:len(v)-1]
 	}
 
+	callAnyOutgoingHandlers(s.anyOutgoingHandlers, eventName, v[1:])
+
 	if s.server.connectionStateRecovery.Enabled {
 		opts := adapter.NewBroadcastOptions()
 		opts.Rooms.Add(Room(s.id))
@@ -443,15 +511,21 @@ func (s *serverSocket) emit(
 		buffers, err := s.parser.Encode(header, &v)
 		if err != nil {
 			s.onError(wrapInternalError(err))
-			return
+			return header.ID
 		}
 		s.conn.sendBuffers(buffers...)
 	}
+	return header.ID
 }
 
 // 0 as the timeout argument means there is no timeout.
 func (s *serverSocket) registerAckHandler(f any, timeout time.Duration) (id uint64) {
 	id = s.nsp.nextAckID()
+	s.registerAckHandlerWithID(id, f, timeout)
+	return
+}
+
+func (s *serverSocket) registerAckHandlerWithID(id uint64, f any, timeout time.Duration) {
 	s.debug.Log("Registering ack with ID", id)
 	if timeout == 0 {
 		s.acksMu.Lock()
@@ -466,9 +540,7 @@ func (s *serverSocket) registerAckHandler(f any, timeout time.Duration) (id uint
 
 	h, err := newAckHandlerWithTimeout(f, timeout, func() {
 		s.debug.Log("Timeout occured for ack with ID", id, "timeout", timeout)
-		s.acksMu.Lock()
-		delete(s.acks, id)
-		s.acksMu.Unlock()
+		s.removeAck(id)
 	})
 	if err != nil {
 		panic(err)
@@ -477,7 +549,16 @@ func (s *serverSocket) registerAckHandler(f any, timeout time.Duration) (id uint
 	s.acksMu.Lock()
 	s.acks[id] = h
 	s.acksMu.Unlock()
-	return
+}
+
+func (s *serverSocket) removeAck(id uint64) {
+	s.acksMu.Lock()
+	delete(s.acks, id)
+	s.acksMu.Unlock()
+}
+
+func (s *serverSocket) EmitWithAck(ctx context.Context, eventName string, v ...any) (AckResponse, error) {
+	return Emitter{socket: s}.EmitWithAck(ctx, eventName, v...)
 }
 
 func (s *serverSocket) Timeout(timeout time.Duration) Emitter {
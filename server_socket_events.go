@@ -27,6 +27,38 @@ func (s *serverSocket) OnceEvent(eventName string, handler any) {
 	s.eventHandlers.once(eventName, h)
 }
 
+// Used by On, Once, OnAck and OnceAck.
+func (s *serverSocket) addEventHandler(eventName string, handler *eventHandler, once bool) {
+	if IsEventReservedForServer(eventName) {
+		panic(fmt.Errorf("sio: attempted to register a reserved event: `%s`", eventName))
+	}
+	if once {
+		s.eventHandlers.once(eventName, handler)
+	} else {
+		s.eventHandlers.on(eventName, handler)
+	}
+}
+
+func (s *serverSocket) OnPatternEvent(pattern string, handler any) (off func()) {
+	h, err := newPatternEventHandler(pattern, handler)
+	if err != nil {
+		panic(err)
+	}
+	return s.eventHandlers.onPattern(pattern, h, false)
+}
+
+func (s *serverSocket) OncePatternEvent(pattern string, handler any) (off func()) {
+	h, err := newPatternEventHandler(pattern, handler)
+	if err != nil {
+		panic(err)
+	}
+	return s.eventHandlers.onPattern(pattern, h, true)
+}
+
+func (s *serverSocket) OffPatternEvent(pattern string) {
+	s.eventHandlers.offPattern(pattern)
+}
+
 func (s *serverSocket) OffEvent(eventName string, handler ...any) {
 	values := make([]reflect.Value, len(handler))
 	for i := range values {
@@ -35,8 +67,34 @@ func (s *serverSocket) OffEvent(eventName string, handler ...any) {
 	s.eventHandlers.off(eventName, values...)
 }
 
+func (s *serverSocket) OnAnyEvent(f AnyEventFunc) (off func()) {
+	return addAnyEventHandler(s.anyEventHandlers, f, false)
+}
+
+func (s *serverSocket) PrependAnyEvent(f AnyEventFunc) (off func()) {
+	return addAnyEventHandler(s.anyEventHandlers, f, true)
+}
+
+func (s *serverSocket) OffAnyEvent() {
+	s.anyEventHandlers.offAll()
+}
+
+func (s *serverSocket) OnAnyOutgoing(f AnyEventFunc) (off func()) {
+	return addAnyEventHandler(s.anyOutgoingHandlers, f, false)
+}
+
+func (s *serverSocket) PrependAnyOutgoing(f AnyEventFunc) (off func()) {
+	return addAnyEventHandler(s.anyOutgoingHandlers, f, true)
+}
+
+func (s *serverSocket) OffAnyOutgoing() {
+	s.anyOutgoingHandlers.offAll()
+}
+
 func (s *serverSocket) OffAll() {
 	s.eventHandlers.offAll()
+	s.anyEventHandlers.offAll()
+	s.anyOutgoingHandlers.offAll()
 	s.errorHandlers.offAll()
 	s.disconnectingHandlers.offAll()
 	s.disconnectHandlers.offAll()
@@ -1,18 +1,27 @@
 package sio
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/hhuuson97/socket.io-go/adapter"
 	eio "github.com/hhuuson97/socket.io-go/engine.io"
+	eioparser "github.com/hhuuson97/socket.io-go/engine.io/parser"
 	"github.com/hhuuson97/socket.io-go/internal/sync"
 	"github.com/hhuuson97/socket.io-go/internal/utils"
+	"github.com/hhuuson97/socket.io-go/parser"
+	jsonparser "github.com/hhuuson97/socket.io-go/parser/json"
+	"github.com/hhuuson97/socket.io-go/parser/json/serializer/stdjson"
+	msgpackparser "github.com/hhuuson97/socket.io-go/parser/msgpack"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"nhooyr.io/websocket"
@@ -189,6 +198,81 @@ func TestServer(t *testing.T) {
 		close()
 	})
 
+	t.Run("should exchange events with the msgpack parser", func(t *testing.T) {
+		io, _, manager, close := newTestServerAndClient(
+			t,
+			&ServerConfig{ParserCreator: msgpackparser.NewCreator()},
+			&ManagerConfig{ParserCreator: msgpackparser.NewCreator()},
+		)
+		tw := utils.NewTestWaiter(3)
+
+		io.Of("/admin").Use(func(socket ServerSocket, handshake *Handshake) any {
+			return fmt.Errorf("not authorized")
+		})
+		io.OnConnection(func(socket ServerSocket) {
+			assert.JSONEq(t, `{"token":"123"}`, string(socket.Handshake().Auth))
+			socket.OnEvent("hello", func(n int, b []byte, ack func(b Binary)) {
+				assert.Equal(t, 1, n)
+				assert.Equal(t, []byte{1, 2, 3}, b)
+				ack(Binary{4, 5})
+			})
+		})
+
+		socket := manager.Socket("/", &ClientSocketConfig{Auth: map[string]string{"token": "123"}})
+		socket.Connect()
+		socket.Emit("hello", 1, []byte{1, 2, 3}, func(b []byte) {
+			assert.Equal(t, []byte{4, 5}, b)
+			tw.Done()
+		})
+		socket.Emit("hello", 1, []byte{1, 2, 3}, func(b Binary) {
+			assert.Equal(t, Binary{4, 5}, b)
+			tw.Done()
+		})
+
+		admin := manager.Socket("/admin", nil)
+		admin.OnConnectError(func(err any) {
+			assert.Equal(t, "not authorized", fmt.Sprint(err))
+			tw.Done()
+		})
+		admin.Connect()
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		close()
+	})
+
+	t.Run("should send the values implementing encoding.BinaryMarshaler as attachments", func(t *testing.T) {
+		newParserCreator := func() parser.Creator {
+			return jsonparser.NewCreatorWithOptions(0, &jsonparser.Options{BinaryMarshalers: true}, stdjson.New())
+		}
+		io, _, manager, close := newTestServerAndClient(
+			t,
+			&ServerConfig{ParserCreator: newParserCreator()},
+			&ManagerConfig{ParserCreator: newParserCreator()},
+		)
+		socket := manager.Socket("/", nil)
+		tw := utils.NewTestWaiter(2)
+
+		io.OnConnection(func(socket ServerSocket) {
+			socket.OnEvent("point", func(p testBinaryPoint, ack func(p *testBinaryPoint)) {
+				assert.Equal(t, testBinaryPoint{X: 1, Y: 2}, p)
+				ack(&testBinaryPoint{X: p.Y, Y: p.X})
+			})
+			socket.OnEvent("raw", func(v any) {
+				assert.Equal(t, jsonparser.Binary{1, 2}, v)
+				tw.Done()
+			})
+		})
+		socket.Connect()
+		socket.Emit("point", testBinaryPoint{X: 1, Y: 2}, func(p testBinaryPoint) {
+			assert.Equal(t, testBinaryPoint{X: 2, Y: 1}, p)
+			tw.Done()
+		})
+		socket.Emit("raw", testBinaryPoint{X: 1, Y: 2})
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		close()
+	})
+
 	t.Run("should receive all events emitted from namespaced client immediately and in order", func(t *testing.T) {
 		io, _, manager, close := newTestServerAndClient(
 			t,
@@ -324,6 +408,337 @@ func TestServer(t *testing.T) {
 		close()
 	})
 
+	t.Run("should receive events with variadic handlers", func(t *testing.T) {
+		io, _, manager, close := newTestServerAndClient(
+			t,
+			nil,
+			nil,
+		)
+		socket := manager.Socket("/", nil)
+		tw := utils.NewTestWaiter(3)
+
+		io.OnConnection(func(socket ServerSocket) {
+			socket.OnEvent("raw", func(a int, rest ...json.RawMessage) {
+				assert.Equal(t, 1, a)
+				require.Len(t, rest, 2)
+				assert.JSONEq(t, `"two"`, string(rest[0]))
+				assert.JSONEq(t, `{"three":3}`, string(rest[1]))
+				tw.Done()
+			})
+			socket.OnEvent("any", func(args ...any) {
+				require.Len(t, args, 2)
+				assert.Equal(t, "a", args[0])
+				assert.EqualValues(t, []byte{1, 2, 3}, args[1])
+				tw.Done()
+			})
+			socket.OnEvent("none", func(args ...any) {
+				assert.Len(t, args, 0)
+				tw.Done()
+			})
+		})
+		socket.Connect()
+		socket.Emit("raw", 1, "two", map[string]any{"three": 3})
+		socket.Emit("any", "a", Binary{1, 2, 3})
+		socket.Emit("none")
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		close()
+	})
+
+	t.Run("should receive events with missing and extra arguments", func(t *testing.T) {
+		io, _, manager, close := newTestServerAndClient(
+			t,
+			nil,
+			nil,
+		)
+		socket := manager.Socket("/", nil)
+		tw := utils.NewTestWaiter(2)
+
+		io.OnConnection(func(socket ServerSocket) {
+			socket.OnEvent("missing", func(a string, b *int, c *string, ack func(string)) {
+				assert.Equal(t, "a", a)
+				if assert.NotNil(t, b) {
+					assert.Equal(t, 2, *b)
+				}
+				assert.Nil(t, c)
+				ack(a)
+			})
+			socket.OnEvent("extra", func(a string, ack func(string)) {
+				ack(a)
+			})
+		})
+		socket.Connect()
+		socket.Emit("missing", "a", 2, func(reply string) {
+			assert.Equal(t, "a", reply)
+			tw.Done()
+		})
+		socket.Emit("extra", "b", "c", 3, func(reply string, extra *int) {
+			assert.Equal(t, "b", reply)
+			assert.Nil(t, extra)
+			tw.Done()
+		})
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		close()
+	})
+
+	t.Run("should emit events with variadic handlers and callbacks", func(t *testing.T) {
+		io, _, manager, close := newTestServerAndClient(
+			t,
+			nil,
+			nil,
+		)
+		socket := manager.Socket("/", nil)
+		tw := utils.NewTestWaiter(2)
+
+		io.OnConnection(func(socket ServerSocket) {
+			socket.Emit("woot", 1, 2, func(args ...int) {
+				assert.Equal(t, []int{3, 4, 5}, args)
+				tw.Done()
+			})
+		})
+		socket.OnEvent("woot", func(args ...json.RawMessage) {
+			assert.Len(t, args, 2)
+			tw.Done()
+		})
+		socket.OnEvent("woot", func(a, b int, ack func(...int)) {
+			ack(a+b, b+b, a+b+b)
+		})
+		socket.Connect()
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		close()
+	})
+
+	t.Run("should receive events with typed handlers", func(t *testing.T) {
+		io, _, manager, close := newTestServerAndClient(t, nil, nil)
+		socket := manager.Socket("/", nil)
+		tw := utils.NewTestWaiter(3)
+
+		type message struct {
+			Text string `json:"text"`
+		}
+		io.OnConnection(func(socket ServerSocket) {
+			On(socket, "message", func(msg message) {
+				assert.Equal(t, "hello", msg.Text)
+				tw.Done()
+			})
+			OnAck(socket, "echo", func(msg message, ack func(reply message)) {
+				ack(msg)
+			})
+			socket.Emit("woot", 1)
+			socket.Emit("woot", 2)
+		})
+		Once(socket, "woot", func(n int) {
+			assert.Equal(t, 1, n)
+			tw.Done()
+		})
+		socket.Connect()
+		socket.Emit("message", message{Text: "hello"})
+		socket.Emit("echo", message{Text: "hi"}, func(reply message) {
+			assert.Equal(t, "hi", reply.Text)
+			tw.Done()
+		})
+		// An acknowledgement function is not required by the sender.
+		socket.Emit("echo", message{Text: "hi"})
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		close()
+	})
+
+	t.Run("should receive events with typed handlers with the msgpack parser and the event middlewares", func(t *testing.T) {
+		io, _, manager, close := newTestServerAndClient(
+			t,
+			&ServerConfig{ParserCreator: msgpackparser.NewCreator()},
+			&ManagerConfig{ParserCreator: msgpackparser.NewCreator()},
+		)
+		socket := manager.Socket("/", nil)
+		tw := utils.NewTestWaiter(1)
+
+		io.OnConnection(func(socket ServerSocket) {
+			// The middlewares receive the arguments of the handler.
+			socket.Use(func(msg string, v ...any) error {
+				if msg == "blocked" {
+					return fmt.Errorf("blocked")
+				}
+				return nil
+			})
+			On(socket, "message", func(msg string) {
+				assert.Equal(t, "hello", msg)
+				tw.Done()
+			})
+		})
+		socket.Connect()
+		socket.Emit("message", "blocked")
+		socket.Emit("message", "hello")
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		close()
+	})
+
+	t.Run("should emit events with the typed emitters", func(t *testing.T) {
+		io, _, manager, close := newTestServerAndClient(t, nil, nil)
+		socket := manager.Socket("/", nil)
+		tw := utils.NewTestWaiter(2)
+
+		type message struct {
+			Text string `json:"text"`
+		}
+		io.OnConnection(func(socket ServerSocket) {
+			OnAck(socket, "echo", func(msg message, ack func(reply message)) {
+				ack(message{Text: msg.Text + "!"})
+			})
+			Emit(socket, "message", message{Text: "hello"})
+		})
+		On(socket, "message", func(msg message) {
+			assert.Equal(t, "hello", msg.Text)
+			tw.Done()
+		})
+		socket.OnConnect(func() {
+			go func() {
+				defer tw.Done()
+				reply, err := EmitWithAck[message, message](context.Background(), socket.Timeout(3*time.Second), "echo", message{Text: "hi"})
+				assert.NoError(t, err)
+				assert.Equal(t, "hi!", reply.Text)
+			}()
+		})
+		socket.Connect()
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		close()
+	})
+
+	t.Run("should remove typed handlers with OffEvent", func(t *testing.T) {
+		_, _, manager, close := newTestServerAndClient(t, nil, nil)
+		socket := manager.Socket("/", nil)
+
+		handler := func(n int) {}
+		On(socket, "woot", handler)
+		socket.OffEvent("woot", handler)
+		assert.Empty(t, socket.(*clientSocket).eventHandlers.getAll("woot"))
+		close()
+	})
+
+	t.Run("should call the pattern event handlers after the event handlers", func(t *testing.T) {
+		io, _, manager, close := newTestServerAndClient(t, nil, nil)
+		socket := manager.Socket("/", nil)
+		tw := utils.NewTestWaiter(3)
+
+		io.OnConnection(func(socket ServerSocket) {
+			var (
+				calls []string
+				mu    sync.Mutex
+			)
+			socket.OnPatternEvent("*:message", func(eventName string, msg string, ack func(reply string)) {
+				mu.Lock()
+				calls = append(calls, "pattern "+eventName)
+				assert.Equal(t, []string{"exact", "pattern chat:message"}, calls)
+				mu.Unlock()
+				ack(msg)
+			})
+			socket.OncePatternEvent("*:typing", func(eventName string) {
+				assert.Equal(t, "chat:typing", eventName)
+				tw.Done()
+			})
+			socket.OnEvent("chat:message", func(msg string) {
+				mu.Lock()
+				calls = append(calls, "exact")
+				mu.Unlock()
+			})
+		})
+		socket.OnPatternEvent("chat:*", func(eventName string, msg string) {
+			assert.Equal(t, "chat:message", eventName)
+			assert.Equal(t, "hello", msg)
+			tw.Done()
+		})
+		io.OnConnection(func(socket ServerSocket) {
+			socket.Emit("chat:message", "hello")
+		})
+		socket.Connect()
+		socket.Emit("chat:message", "hello", func(reply string) {
+			assert.Equal(t, "hello", reply)
+			tw.Done()
+		})
+		socket.Emit("chat:typing")
+		socket.Emit("chat:typing")
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		close()
+	})
+
+	t.Run("should call the catch-all listeners of the incoming events", func(t *testing.T) {
+		io, _, manager, close := newTestServerAndClient(t, nil, nil)
+		socket := manager.Socket("/", nil)
+		tw := utils.NewTestWaiter(1)
+
+		io.OnConnection(func(socket ServerSocket) {
+			var calls []string
+			newListener := func(name string) AnyEventFunc {
+				return func(eventName string, v []any) {
+					calls = append(calls, name)
+				}
+			}
+			socket.OnAnyEvent(func(eventName string, v []any) {
+				calls = append(calls, "on")
+				assert.Equal(t, "woot", eventName)
+				// Binary data is decoded into the binary type of the parser.
+				assert.Equal(t, []any{float64(1), "a", jsonparser.Binary("b")}, v)
+			})
+			socket.PrependAnyEvent(func(eventName string, v []any) {
+				calls = append(calls, "prepend")
+			})
+			// The listeners are closures of the same function literal, only the first one is removed.
+			off := socket.OnAnyEvent(newListener("removed"))
+			socket.OnAnyEvent(newListener("kept"))
+			off()
+			off()
+
+			// The event handlers are called after the catch-all listeners.
+			socket.OnEvent("woot", func(n int, s string, b Binary, ack func()) {
+				assert.Equal(t, []string{"prepend", "on", "kept"}, calls)
+				ack()
+			})
+		})
+		socket.Connect()
+		socket.Emit("woot", 1, "a", Binary("b"), func() {
+			tw.Done()
+		})
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		close()
+	})
+
+	t.Run("should call the catch-all listeners of the outgoing events", func(t *testing.T) {
+		io, _, manager, close := newTestServerAndClient(t, nil, nil)
+		socket := manager.Socket("/", nil)
+		tw := utils.NewTestWaiter(2)
+
+		io.OnConnection(func(socket ServerSocket) {
+			socket.OnAnyOutgoing(func(eventName string, v []any) {
+				assert.Equal(t, "hello", eventName)
+				assert.Equal(t, []any{"world"}, v)
+				tw.Done()
+			})
+			socket.OnEvent("woot", func(n int) {
+				socket.Emit("hello", "world", func() {})
+			})
+		})
+		socket.OnAnyOutgoing(func(eventName string, v []any) {
+			assert.Equal(t, "woot", eventName)
+			assert.Equal(t, []any{1}, v)
+			tw.Done()
+		})
+		socket.OnAnyEvent(func(eventName string, v []any) {
+			t.Error("the outgoing listeners of the client were not removed")
+		})
+		socket.OffAnyEvent()
+		socket.Connect()
+		socket.Emit("woot", 1)
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		close()
+	})
+
 	t.Run("should receive events with binary args and callbacks", func(t *testing.T) {
 		randomBin := []byte("\x36\x43\x78\x6a\x4c\xad\x7b\x6f\x33\x96\xc6\xdb\x4b\xd3\xe4\x8c\xc7\x12")
 
@@ -554,6 +969,7 @@ func TestServer(t *testing.T) {
 			socket.Join("room1")
 		})
 		socket.OnDisconnect(func(reason Reason) {
+			t.Log("reason", reason)
 			_, ok := io.Of("/").Adapter().SocketRooms(socket.ID())
 			assert.False(t, ok)
 			tw.Done()
@@ -730,6 +1146,122 @@ func TestServer(t *testing.T) {
 		close()
 	})
 
+	t.Run("should close the connection with ReasonParseError when a packet exceeds the limits of the parser", func(t *testing.T) {
+		io, _, manager, close := newTestServerAndClient(
+			t,
+			&ServerConfig{
+				ParserCreator: jsonparser.NewCreatorWithLimits(0, jsonparser.Limits{MaxArgs: 2}, stdjson.New()),
+			},
+			nil,
+		)
+		socket := manager.Socket("/", nil)
+		tw := utils.NewTestWaiter(3)
+
+		io.OnConnection(func(socket ServerSocket) {
+			socket.OnEvent("woot", func(a, b int, ack func()) {
+				ack()
+			})
+			socket.OnError(func(err error) {
+				var limitErr *parser.LimitError
+				if assert.ErrorAs(t, err, &limitErr) {
+					assert.Equal(t, "number of arguments", limitErr.Limit)
+				}
+				tw.Done()
+			})
+			socket.OnDisconnect(func(reason Reason) {
+				assert.Equal(t, ReasonParseError, reason)
+				tw.Done()
+			})
+		})
+		socket.Connect()
+		socket.Emit("woot", 1, 2, func() {
+			tw.Done()
+			socket.Emit("woot", 1, 2, 3)
+		})
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		close()
+	})
+
+	t.Run("should speak the protocol revision 4 to the Socket.IO v2 clients", func(t *testing.T) {
+		io, ts, _, close := newTestServerAndClient(
+			t,
+			&ServerConfig{
+				EIO: eio.ServerConfig{AllowEIO3: true},
+			},
+			nil,
+		)
+		ts.Client().Timeout = 1000 * time.Millisecond
+
+		request := func(method, sid string, body []byte) []*eioparser.Packet {
+			req, err := http.NewRequest(method, ts.URL+"/socket.io/?EIO=3&transport=polling&b64=1&sid="+sid, bytes.NewReader(body))
+			require.NoError(t, err)
+			resp, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			if method != "GET" {
+				return nil
+			}
+			packets, err := eioparser.DecodePayloadsV3(resp.Body, false)
+			require.NoError(t, err)
+			return packets
+		}
+		push := func(sid string, messages ...string) {
+			packets := make([]*eioparser.Packet, len(messages))
+			for i, message := range messages {
+				packets[i] = &eioparser.Packet{Type: eioparser.PacketTypeMessage, Data: []byte(message)}
+			}
+			var buf bytes.Buffer
+			require.NoError(t, eioparser.EncodePayloadsV3(&buf, false, packets...))
+			request("POST", sid, buf.Bytes())
+		}
+		poll := func(sid string) string {
+			packets := request("GET", sid, nil)
+			require.Len(t, packets, 1)
+			return string(packets[0].Data)
+		}
+
+		tw := utils.NewTestWaiter(2)
+		var mainID, adminID SocketID
+		io.OnConnection(func(socket ServerSocket) {
+			mainID = socket.ID()
+			socket.OnEvent("hello", func(n int, ack func(string)) {
+				assert.Equal(t, 1, n)
+				ack("hi")
+			})
+			tw.Done()
+		})
+		io.Of("/admin").OnConnection(func(socket ServerSocket) {
+			adminID = socket.ID()
+			assert.JSONEq(t, `{"token":"abc"}`, string(socket.Handshake().Auth))
+			tw.Done()
+		})
+
+		packets := request("GET", "", nil)
+		require.Len(t, packets, 1)
+		hr, err := eioparser.ParseHandshakeResponse(packets[0])
+		require.NoError(t, err)
+		eioSid := hr.SID
+
+		// The client is connected to the main namespace without sending CONNECT.
+		assert.Equal(t, "0", poll(eioSid))
+
+		push(eioSid, `21["hello",1]`)
+		assert.Equal(t, `31["hi"]`, poll(eioSid))
+
+		push(eioSid, "0/admin?token=abc,")
+		assert.Equal(t, "0/admin,", poll(eioSid))
+
+		push(eioSid, "0/unknown,")
+		assert.Equal(t, `4/unknown,"sio: namespace '/unknown' was not created and AcceptAnyNamespace was not set"`, poll(eioSid))
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		assert.Equal(t, SocketID(eioSid), mainID)
+		assert.Equal(t, SocketID("/admin#"+eioSid), adminID)
+		close()
+	})
+
 	restoreSessionInit := func(t *testing.T, io *Server, ts *httptest.Server) (sioSid, sioPid, offset string) {
 		// Engine.IO handshake
 		sid := utils.EIOHandshake(t, ts)
@@ -851,6 +1383,7 @@ func TestServer(t *testing.T) {
 			assert.False(t, socket.Recovered())
 			socket.Join("room1")
 			socket.Join("room2")
+			socket.Data().Set("username", "alice")
 		})
 
 		sioSid, sioPid, offset := restoreSessionInit(t, io, ts)
@@ -867,6 +1400,9 @@ func TestServer(t *testing.T) {
 		assert.Equal(t, SocketID(sioSid), socket.ID())
 		assert.True(t, socket.Recovered())
 		assert.True(t, socket.Rooms().Contains("room1", "room2"))
+		username, ok := adapter.GetSocketData[string](socket.Data(), "username")
+		assert.True(t, ok)
+		assert.Equal(t, "alice", username)
 
 		close()
 	})
@@ -1185,6 +1721,136 @@ func TestServer(t *testing.T) {
 		assert.True(t, serverSockets[1].Rooms().ContainsAny("room1"))
 		close()
 	})
+
+	t.Run("should broadcast to the clients of the other servers of a TCP cluster", func(t *testing.T) {
+		// Each node must know the address of the other one,
+		// the address of node2 is only known once it is listening.
+		var addr2 atomic.Value
+		addr2.Store("")
+		newNode := func(peers []string, discover func(ctx context.Context) ([]string, error)) *adapter.TCPNode {
+			node, err := adapter.NewTCPNode(&adapter.TCPNodeOptions{
+				ListenAddr:        "127.0.0.1:0",
+				Peers:             peers,
+				Discover:          discover,
+				DiscoveryInterval: 50 * time.Millisecond,
+			})
+			require.NoError(t, err)
+			return node
+		}
+		node1 := newNode(nil, func(ctx context.Context) ([]string, error) {
+			return []string{addr2.Load().(string)}, nil
+		})
+		node2 := newNode([]string{node1.Addr()}, nil)
+		addr2.Store(node2.Addr())
+		io1, _, _, close1 := newTestServerAndClient(t, &ServerConfig{AdapterCreator: adapter.NewTCPAdapterCreator(node1)}, nil)
+		io2, _, manager2, close2 := newTestServerAndClient(t, &ServerConfig{AdapterCreator: adapter.NewTCPAdapterCreator(node2)}, nil)
+		socket := manager2.Socket("/", nil)
+		tw := utils.NewTestWaiter(1)
+
+		socket.OnEvent("hello", func(message string) {
+			assert.Equal(t, "world", message)
+			tw.Done()
+		})
+		io2.OnConnection(func(socket ServerSocket) {
+			assert.Eventually(t, func() bool {
+				return io1.Of("/").Adapter().ServerCount() == 2
+			}, utils.DefaultTestWaitTimeout, 10*time.Millisecond)
+			io1.Emit("hello", "world")
+		})
+		socket.Connect()
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		close1()
+		close2()
+		node1.Close()
+		node2.Close()
+	})
+
+	t.Run("should close the adapters of every namespace on Close", func(t *testing.T) {
+		var closed [2]atomic.Bool
+		i := 0
+		io := NewServer(&ServerConfig{
+			AdapterCreator: func(socketStore adapter.SocketStore, parserCreator parser.Creator) adapter.Adapter {
+				a := &testClosingAdapter{
+					Adapter: adapter.NewInMemoryAdapterCreator()(socketStore, parserCreator),
+					closed:  &closed[i],
+				}
+				i++
+				return a
+			},
+		})
+		io.Of("/")
+		io.Of("/custom")
+		err := io.Run()
+		require.NoError(t, err)
+		err = io.Close()
+		require.NoError(t, err)
+		assert.True(t, closed[0].Load())
+		assert.True(t, closed[1].Load())
+	})
+
+	t.Run("should send the queued packets and disconnect the sockets on Shutdown", func(t *testing.T) {
+		io, ts, manager, _ := newTestServerAndClient(t, nil, nil)
+		socket := manager.Socket("/", nil)
+		tw := utils.NewTestWaiterString()
+		tw.Add("hello")
+		tw.Add("disconnecting")
+		tw.Add("client disconnect")
+		connected := utils.NewTestWaiter(1)
+
+		io.OnConnection(func(socket ServerSocket) {
+			socket.OnDisconnecting(func(reason Reason) {
+				assert.Equal(t, ReasonServerShuttingDown, reason)
+				tw.Done("disconnecting")
+			})
+			connected.Done()
+		})
+		// Unlike the event handlers, the catch-all listeners are called
+		// even if the socket is disconnected by the time the event is dispatched.
+		socket.OnAnyEvent(func(eventName string, v []any) {
+			assert.Equal(t, "hello", eventName)
+			assert.Equal(t, []any{"world"}, v)
+			tw.Done("hello")
+		})
+		socket.OnDisconnect(func(reason Reason) {
+			tw.Done("client disconnect")
+		})
+		socket.Connect()
+		connected.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+
+		io.Emit("hello", "world")
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err := io.Shutdown(ctx)
+		require.NoError(t, err)
+		assert.True(t, io.IsClosed())
+		assert.Empty(t, io.Sockets())
+
+		tw.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+		ts.Close()
+	})
+
+	t.Run("should return the error of the context when Shutdown times out", func(t *testing.T) {
+		io, ts, manager, _ := newTestServerAndClient(t, nil, nil)
+		socket := manager.Socket("/", nil)
+		connected := utils.NewTestWaiter(1)
+
+		io.OnConnection(func(socket ServerSocket) {
+			socket.OnDisconnecting(func(reason Reason) {
+				time.Sleep(500 * time.Millisecond)
+			})
+			connected.Done()
+		})
+		socket.Connect()
+		connected.WaitTimeout(t, utils.DefaultTestWaitTimeout)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		err := io.Shutdown(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.True(t, io.IsClosed())
+		ts.Close()
+	})
 }
 
 func newTestServerAndClient(
@@ -1248,3 +1914,17 @@ func newTestManager(ts *httptest.Server, managerConfig *ManagerConfig) *Manager
 	}
 	return NewManager(ts.URL, managerConfig)
 }
+
+type testBinaryPoint struct {
+	X, Y byte
+}
+
+func (p testBinaryPoint) MarshalBinary() ([]byte, error) { return []byte{p.X, p.Y}, nil }
+
+func (p *testBinaryPoint) UnmarshalBinary(data []byte) error {
+	if len(data) != 2 {
+		return fmt.Errorf("invalid point")
+	}
+	p.X, p.Y = data[0], data[1]
+	return nil
+}
@@ -1,14 +1,19 @@
 package sio
 
 import (
+	"context"
+	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/hhuuson97/socket.io-go/adapter"
+	"github.com/hhuuson97/socket.io-go/parser"
 )
 
 type (
-	SocketID = adapter.SocketID
-	Room     = adapter.Room
+	SocketID   = adapter.SocketID
+	Room       = adapter.Room
+	SocketData = adapter.SocketData
 )
 
 type Socket interface {
@@ -31,6 +36,10 @@ type Socket interface {
 	// Return an emitter with timeout set.
 	Timeout(timeout time.Duration) Emitter
 
+	// Emit a message and block until its acknowledgement is received or ctx is done.
+	// See Emitter.EmitWithAck.
+	EmitWithAck(ctx context.Context, eventName string, v ...any) (AckResponse, error)
+
 	// Register an event handler.
 	OnEvent(eventName string, handler any)
 
@@ -46,7 +55,113 @@ type Socket interface {
 	// Otherwise, provide both the eventName and handler arguments.
 	OffEvent(eventName string, handler ...any)
 
+	// Register an event handler for the events whose name matches the pattern.
+	// In the pattern, '*' matches any sequence of characters and '?' matches any single character.
+	// The 1st parameter of the handler is the name of the event, e.g. func(eventName string, msg string).
+	//
+	// The handlers registered with OnEvent for the event name are called first,
+	// then the pattern handlers, in the order they were registered.
+	//
+	// It returns a function that removes the handler.
+	OnPatternEvent(pattern string, handler any) (off func())
+
+	// Register a one-time pattern event handler.
+	// The handler will run once and will be removed afterwards.
+	//
+	// It returns a function that removes the handler before it runs.
+	OncePatternEvent(pattern string, handler any) (off func())
+
+	// Remove all the handlers of a pattern.
+	// To remove a single handler, call the function returned by OnPatternEvent.
+	OffPatternEvent(pattern string)
+
+	// Register a catch-all listener of the incoming events.
+	// The arguments are decoded as if they were decoded into an any.
+	//
+	// It returns a function that removes the listener.
+	OnAnyEvent(f AnyEventFunc) (off func())
+
+	// Register a catch-all listener of the incoming events,
+	// which is called before the catch-all listeners registered so far.
+	//
+	// It returns a function that removes the listener.
+	PrependAnyEvent(f AnyEventFunc) (off func())
+
+	// Remove all the catch-all listeners of the incoming events.
+	// To remove a single listener, call the function returned by OnAnyEvent.
+	OffAnyEvent()
+
+	// Register a catch-all listener of the outgoing events.
+	// The broadcasts (see BroadcastOperator) are not included.
+	//
+	// It returns a function that removes the listener.
+	OnAnyOutgoing(f AnyEventFunc) (off func())
+
+	// Register a catch-all listener of the outgoing events,
+	// which is called before the catch-all listeners registered so far.
+	//
+	// It returns a function that removes the listener.
+	PrependAnyOutgoing(f AnyEventFunc) (off func())
+
+	// Remove all the catch-all listeners of the outgoing events.
+	// To remove a single listener, call the function returned by OnAnyOutgoing.
+	OffAnyOutgoing()
+
 	// Remove all event handlers.
 	// Including special event handlers (connect, disconnect, disconnecting, etc.).
 	OffAll()
 }
+
+// A catch-all listener of the events of a socket (see OnAnyEvent and OnAnyOutgoing).
+// v contains the arguments of the event, without the acknowledgement function.
+type AnyEventFunc func(eventName string, v []any)
+
+var (
+	reflectArgs      = reflect.TypeOf(parser.Args(nil))
+	reflectArgsCount = reflect.TypeOf(parser.ArgsCount(0))
+)
+
+// Decodes every argument of an incoming event and calls the catch-all listeners.
+// The arguments are only decoded if there is a listener.
+func callAnyEventHandlers(handlers *handlerStore[*AnyEventFunc], eventName string, decode parser.Decode) error {
+	h := handlers.getAll()
+	if len(h) == 0 {
+		return nil
+	}
+
+	values, err := decode(reflectArgs)
+	if err != nil {
+		return err
+	}
+	if len(values) != 1 {
+		return fmt.Errorf("sio: invalid number of values")
+	}
+	args, _ := values[0].Interface().(parser.Args)
+
+	for _, handler := range h {
+		(*handler)(eventName, args)
+	}
+	return nil
+}
+
+func callAnyOutgoingHandlers(handlers *handlerStore[*AnyEventFunc], eventName string, v []any) {
+	for _, handler := range handlers.getAll() {
+		(*handler)(eventName, v)
+	}
+}
+
+// Adds a catch-all listener and returns the function that removes it.
+//
+// The listener is removed by its pointer, since functions cannot be compared
+// (the closures of a function literal have the same code pointer).
+func addAnyEventHandler(handlers *handlerStore[*AnyEventFunc], f AnyEventFunc, prepend bool) (off func()) {
+	h := &f
+	if prepend {
+		handlers.prepend(h)
+	} else {
+		handlers.on(h)
+	}
+	return func() {
+		handlers.offMatching(func(handler *AnyEventFunc) bool { return handler == h })
+	}
+}
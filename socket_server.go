@@ -13,6 +13,15 @@ type (
 		// Retrieves the Namespace this socket is connected to.
 		Namespace() *Namespace
 
+		// Handshake details of the socket.
+		Handshake() *Handshake
+
+		// Arbitrary data attached to the socket. It is restored with the
+		// connection state, and it is included in the sockets returned by FetchSockets.
+		//
+		// Use adapter.GetSocketData to get a typed value.
+		Data() *SocketData
+
 		// Join room(s)
 		Join(room ...Room)
 		// Leave a room
@@ -2,6 +2,7 @@ package sio
 
 import (
 	"reflect"
+	"time"
 
 	"github.com/hhuuson97/socket.io-go/internal/sync"
 
@@ -38,6 +39,7 @@ type (
 	// `SocketStore`.
 	adapterSocketStore struct {
 		store *nspSocketStore
+		nsp   *Namespace
 	}
 
 	handlerStore[T comparable] struct {
@@ -51,6 +53,14 @@ type (
 		mu         sync.Mutex
 		events     map[string][]*eventHandler
 		eventsOnce map[string][]*eventHandler
+		// In the order they were registered.
+		patterns []*patternEventHandler
+	}
+
+	patternEventHandler struct {
+		pattern string
+		handler *eventHandler
+		once    bool
 	}
 )
 
@@ -73,8 +83,8 @@ func newNspSocketStore() *nspSocketStore {
 	return &nspSocketStore{sockets: make(map[SocketID]ServerSocket)}
 }
 
-func newAdapterSocketStore(store *nspSocketStore) *adapterSocketStore {
-	return &adapterSocketStore{store: store}
+func newAdapterSocketStore(store *nspSocketStore, nsp *Namespace) *adapterSocketStore {
+	return &adapterSocketStore{store: store, nsp: nsp}
 }
 
 func newHandlerStore[T comparable]() *handlerStore[T] {
@@ -184,13 +194,14 @@ func (s *nspStore) getOrCreate(
 	server *Server,
 	adapterCreator adapter.Creator,
 	parserCreator parser.Creator,
+	parent *ParentNamespace,
 ) (nsp *Namespace, created bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	var ok bool
 	nsp, ok = s.nsps[name]
 	if !ok {
-		nsp = newNamespace(name, server, adapterCreator, parserCreator)
+		nsp = newNamespace(name, server, adapterCreator, parserCreator, parent)
 		s.nsps[nsp.Name()] = nsp
 		created = true
 	}
@@ -222,6 +233,25 @@ func (s *nspStore) remove(name string) {
 	delete(s.nsps, name)
 }
 
+// Removes the namespace unless it has already been replaced by another one with the same name.
+func (s *nspStore) removeNamespace(nsp *Namespace) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.nsps[nsp.Name()] == nsp {
+		delete(s.nsps, nsp.Name())
+	}
+}
+
+func (s *nspStore) getAll() []*Namespace {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	nsps := make([]*Namespace, 0, len(s.nsps))
+	for _, nsp := range s.nsps {
+		nsps = append(nsps, nsp)
+	}
+	return nsps
+}
+
 // Send Engine.IO packets to a specific socket.
 func (s *nspSocketStore) sendBuffers(sid SocketID, buffers [][]byte) (ok bool) {
 	_socket, ok := s.get(sid)
@@ -287,12 +317,68 @@ func (s *adapterSocketStore) Remove(sid SocketID) {
 	s.store.remove(sid)
 }
 
+func (s *adapterSocketStore) Namespace() string {
+	return s.nsp.Name()
+}
+
+func (s *adapterSocketStore) OnServerSideEmit(eventName string, v []any, ack func(response any)) {
+	s.nsp.onServerSideEmit(eventName, v, ack)
+}
+
+func (s *adapterSocketStore) NextAckID() uint64 {
+	return s.nsp.nextAckID()
+}
+
+func (s *adapterSocketStore) RegisterAck(sid SocketID, id uint64, timeout time.Duration, ack func(response any)) (ok bool) {
+	socket, ok := s.store.get(sid)
+	if !ok {
+		return false
+	}
+	_socket, ok := socket.(*serverSocket)
+	if !ok {
+		return false
+	}
+	if timeout == 0 {
+		_socket.registerAckHandlerWithID(id, ack, 0)
+	} else {
+		_socket.registerAckHandlerWithID(id, func(err error, response any) {
+			if err == nil {
+				ack(response)
+			}
+		}, timeout)
+	}
+	return true
+}
+
+func (s *adapterSocketStore) OnCreateRoom(room Room) {
+	s.nsp.createRoomHandlers.forEach(func(handler *NamespaceCreateRoomFunc) { (*handler)(room) }, false)
+}
+
+func (s *adapterSocketStore) OnDeleteRoom(room Room) {
+	s.nsp.deleteRoomHandlers.forEach(func(handler *NamespaceDeleteRoomFunc) { (*handler)(room) }, false)
+}
+
+func (s *adapterSocketStore) OnJoinRoom(room Room, sid SocketID) {
+	s.nsp.joinRoomHandlers.forEach(func(handler *NamespaceJoinRoomFunc) { (*handler)(room, sid) }, false)
+}
+
+func (s *adapterSocketStore) OnLeaveRoom(room Room, sid SocketID) {
+	s.nsp.leaveRoomHandlers.forEach(func(handler *NamespaceLeaveRoomFunc) { (*handler)(room, sid) }, false)
+}
+
 func (e *handlerStore[T]) on(handler T) {
 	e.mu.Lock()
 	e.funcs = append(e.funcs, handler)
 	e.mu.Unlock()
 }
 
+// Adds the handler before the handlers registered so far.
+func (e *handlerStore[T]) prepend(handler T) {
+	e.mu.Lock()
+	e.funcs = append([]T{handler}, e.funcs...)
+	e.mu.Unlock()
+}
+
 func (e *handlerStore[T]) onSubEvent(handler T) {
 	e.mu.Lock()
 	e.subs = append(e.subs, handler)
@@ -352,6 +438,24 @@ func (e *handlerStore[T]) off(handler ...T) {
 	}
 }
 
+// Removes the handlers for which match returns true.
+func (e *handlerStore[T]) offMatching(match func(handler T) bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	filter := func(slice []T) []T {
+		n := slice[:0]
+		for _, h := range slice {
+			if !match(h) {
+				n = append(n, h)
+			}
+		}
+		return n
+	}
+	e.funcs = filter(e.funcs)
+	e.funcsOnce = filter(e.funcsOnce)
+}
+
 func (e *handlerStore[T]) offAll() {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -456,10 +560,64 @@ func (e *eventHandlerStore) off(eventName string, handler ...reflect.Value) {
 	}
 }
 
+// Returns a function that removes this registration of the handler.
+func (e *eventHandlerStore) onPattern(pattern string, handler *eventHandler, once bool) (off func()) {
+	ph := &patternEventHandler{
+		pattern: pattern,
+		handler: handler,
+		once:    once,
+	}
+	e.mu.Lock()
+	e.patterns = append(e.patterns, ph)
+	e.mu.Unlock()
+	return func() {
+		e.offPatternMatching(func(p *patternEventHandler) bool { return p == ph })
+	}
+}
+
+// Removes all the handlers of the pattern.
+func (e *eventHandlerStore) offPattern(pattern string) {
+	e.offPatternMatching(func(p *patternEventHandler) bool { return p.pattern == pattern })
+}
+
+func (e *eventHandlerStore) offPatternMatching(match func(p *patternEventHandler) bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	patterns := e.patterns[:0]
+	for _, p := range e.patterns {
+		if !match(p) {
+			patterns = append(patterns, p)
+		}
+	}
+	e.patterns = patterns
+}
+
+// Returns the handlers whose pattern matches the event name, in the order they were registered.
+func (e *eventHandlerStore) getAllPatterns(eventName string) (handlers []*eventHandler) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	patterns := e.patterns[:0]
+	for _, p := range e.patterns {
+		matched := matchEventPattern(p.pattern, eventName)
+		if matched {
+			handlers = append(handlers, p.handler)
+		}
+		if !matched || !p.once {
+			patterns = append(patterns, p)
+		}
+	}
+	e.patterns = patterns
+	return
+}
+
 func (e *eventHandlerStore) offAll() {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	e.patterns = nil
+
 	for k := range e.events {
 		delete(e.events, k)
 	}
@@ -135,11 +135,11 @@ func TestNamespaceStore(t *testing.T) {
 	require.False(t, ok)
 	require.True(t, n == nil)
 
-	n, created := store.getOrCreate("/jkl", server, server.adapterCreator, server.parserCreator)
+	n, created := store.getOrCreate("/jkl", server, server.adapterCreator, server.parserCreator, nil)
 	require.True(t, created)
 	require.Equal(t, "/jkl", n.Name())
 
-	n, created = store.getOrCreate("/", server, server.adapterCreator, server.parserCreator)
+	n, created = store.getOrCreate("/", server, server.adapterCreator, server.parserCreator, nil)
 	require.False(t, created)
 	require.True(t, n == main)
 	close()
@@ -472,3 +472,60 @@ func TestEventHandlerStore(t *testing.T) {
 		require.Equal(t, 0, len(all))
 	})
 }
+
+func TestEventHandlerStorePatterns(t *testing.T) {
+	newHandler := func(f any) *eventHandler {
+		h, err := newPatternEventHandler("chat:*", f)
+		require.NoError(t, err)
+		return h
+	}
+
+	t.Run("on and once", func(t *testing.T) {
+		store := newEventHandlerStore()
+		f1 := func(eventName string) {}
+		f2 := func(eventName string) {}
+		h1 := newHandler(f1)
+		h2 := newHandler(f2)
+		store.onPattern("chat:*", h1, false)
+		store.onPattern("chat:*", h2, true)
+
+		require.Equal(t, []*eventHandler{h1, h2}, store.getAllPatterns("chat:message"))
+		require.Empty(t, store.getAllPatterns("room:message"))
+		require.Equal(t, []*eventHandler{h1}, store.getAllPatterns("chat:message"))
+		// Exact event handlers are stored separately.
+		require.Empty(t, store.getAll("chat:message"))
+	})
+
+	t.Run("off", func(t *testing.T) {
+		store := newEventHandlerStore()
+		f1 := func(eventName string) {}
+		f2 := func(eventName string, n int) {}
+		h1 := newHandler(f1)
+		h2 := newHandler(f2)
+		off := store.onPattern("chat:*", h1, false)
+		store.onPattern("chat:*", h2, false)
+		store.onPattern("room:*", h1, false)
+
+		off()
+		require.Equal(t, []*eventHandler{h2}, store.getAllPatterns("chat:message"))
+		require.Equal(t, []*eventHandler{h1}, store.getAllPatterns("room:message"))
+
+		store.offPattern("chat:*")
+		require.Empty(t, store.getAllPatterns("chat:message"))
+
+		store.offAll()
+		require.Empty(t, store.getAllPatterns("room:message"))
+	})
+	t.Run("off should only remove its own handler", func(t *testing.T) {
+		store := newEventHandlerStore()
+		// Both closures have the same code pointer.
+		newFunc := func() func(eventName string) { return func(eventName string) {} }
+		h1 := newHandler(newFunc())
+		h2 := newHandler(newFunc())
+		off := store.onPattern("chat:*", h1, false)
+		store.onPattern("chat:*", h2, true)
+
+		off()
+		require.Equal(t, []*eventHandler{h2}, store.getAllPatterns("chat:message"))
+	})
+}